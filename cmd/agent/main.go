@@ -6,8 +6,20 @@ import (
 )
 
 func main() {
+	sharemain.DecorateContext(agent.ScopeInformers)
 	sharemain.Main(
 		agent.NewAgent,
 		agent.NewGC,
+		agent.NewDiskPressure,
+		agent.NewMigration,
+		agent.NewBackup,
+		agent.NewSnapshotPolicy,
+		agent.NewUsageAlert,
+		agent.NewAutoGrow,
+		agent.NewMetaPropagation,
+		agent.NewAccounting,
+		agent.NewLiveness,
+		agent.NewFencing,
+		agent.NewNodeLabels,
 	)
 }
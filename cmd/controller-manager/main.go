@@ -0,0 +1,29 @@
+package main
+
+import (
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/agent"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/sharemain"
+)
+
+// The controller manager runs every node side controller in one binary
+// sharing one informer factory, controllers can be toggled with their
+// -enable-<name> flags.
+func main() {
+	sharemain.MainNamed(
+		sharemain.NamedController{Name: "agent", Constructor: agent.NewAgent},
+		sharemain.NamedController{Name: "gc", Constructor: agent.NewGC},
+		sharemain.NamedController{Name: "disk-pressure", Constructor: agent.NewDiskPressure},
+		sharemain.NamedController{Name: "migration", Constructor: agent.NewMigration},
+		sharemain.NamedController{Name: "backup", Constructor: agent.NewBackup},
+		sharemain.NamedController{Name: "snapshot-policy", Constructor: agent.NewSnapshotPolicy},
+		sharemain.NamedController{Name: "usage-alert", Constructor: agent.NewUsageAlert},
+		sharemain.NamedController{Name: "auto-grow", Constructor: agent.NewAutoGrow},
+		sharemain.NamedController{Name: "meta-propagation", Constructor: agent.NewMetaPropagation},
+		sharemain.NamedController{Name: "liveness", Constructor: agent.NewLiveness},
+		sharemain.NamedController{Name: "stale-node-cleanup", Constructor: agent.NewStaleNodeCleanup},
+		sharemain.NamedController{Name: "fencing", Constructor: agent.NewFencing},
+		sharemain.NamedController{Name: "node-labels", Constructor: agent.NewNodeLabels},
+		sharemain.NamedController{Name: "sc-default", Constructor: agent.NewSCDefault},
+		sharemain.NamedController{Name: "sc-generation", Constructor: agent.NewSCGeneration},
+	)
+}
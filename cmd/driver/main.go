@@ -18,21 +18,54 @@ package main
 
 import (
 	"flag"
+	"log"
 	"os"
 
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/config"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/debug"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/features"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/metrics"
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/driver"
 )
 
 var (
-	endpoint = flag.String("endpoint", "unix://tmp/csi.sock", "CSI endpoint")
-	nodeID   = flag.String("nodeid", "", "node id")
+	endpoint    = flag.String("endpoint", "unix://tmp/csi.sock", "CSI endpoint")
+	nodeID      = flag.String("nodeid", "", "node id")
+	metricsAddr = flag.String("metrics-addr", ":9100", "The address the prometheus metrics endpoint binds to")
+	configFile  = flag.String("config", "", "Path to a LocalVolumeConfiguration yaml file")
 )
 
 // Nas CSI Plugin
 func main() {
 	flag.Parse()
 
+	if *configFile != "" {
+		conf, err := config.Load(*configFile)
+		if err != nil {
+			log.Fatal("Error loading config file ", err)
+		}
+		config.Apply("endpoint", conf.Driver.Endpoint)
+		config.Apply("metrics-addr", conf.Driver.MetricsAddress)
+		config.ApplyClientConnection(conf)
+		if err := features.SetFromMap(conf.FeatureGates); err != nil {
+			log.Fatal("Error applying feature gates ", err)
+		}
+	}
+	if err := features.Setup(); err != nil {
+		log.Fatal("Error applying feature gates ", err)
+	}
+
+	if err := logging.SetupFromFlags(); err != nil {
+		log.Fatal("Error configuring logging", err)
+	}
+
+	// debug dump on SIGUSR1
+	debug.SetupDumpHandler()
+
+	go metrics.Serve(*metricsAddr)
+
 	driver := driver.NewLocalVolumeDriver(types.DriverName, types.Version, *nodeID, *endpoint)
 	driver.Run()
 
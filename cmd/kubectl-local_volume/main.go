@@ -0,0 +1,13 @@
+package main
+
+import (
+	"os"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/cli/kubectl"
+)
+
+func main() {
+	if err := kubectl.NewRootCommand().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,13 @@
+package main
+
+import (
+	"os"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/cli/admin"
+)
+
+func main() {
+	if err := admin.NewRootCommand().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
@@ -3,12 +3,15 @@ package main
 import (
 	"flag"
 	"log"
-	"net/http"
+	"strconv"
 
 	"github.com/julienschmidt/httprouter"
 	"k8s.io/client-go/rest"
 
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/config"
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/controller"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/debug"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/features"
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/injection"
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/kubeconfig"
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
@@ -16,26 +19,83 @@ import (
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/scheduler"
 )
 
+var (
+	captureDir  = flag.String("capture-dir", "", "If set, record sanitized predicate/bind request and response pairs into this directory")
+	captureRing = flag.Int("capture-ring-size", 100, "Maximum number of capture files kept in the capture directory")
+	replayDir   = flag.String("replay-dir", "", "If set, feed captured requests from this directory back into the handlers and exit")
+	configFile  = flag.String("config", "", "Path to a LocalVolumeConfiguration yaml file")
+)
+
 func main() {
 	// kube config
 	cfg := getKubeConfig()
 
+	// configuration file, explicit flags win over it
+	listenAddr := ":80"
+	tlsCertFile, tlsKeyFile := "", ""
+	if *configFile != "" {
+		conf, err := config.Load(*configFile)
+		if err != nil {
+			log.Fatal("Error loading config file ", err)
+		}
+		config.Apply("capture-dir", conf.Scheduler.CaptureDir)
+		config.Apply("capture-ring-size", strconv.Itoa(conf.Scheduler.CaptureRingSize))
+		config.Apply("priority-headroom-percent", strconv.Itoa(conf.Scheduler.PriorityHeadroomPercent))
+		config.Apply("priority-headroom-min-priority", strconv.Itoa(conf.Scheduler.PriorityHeadroomMinPriority))
+		config.Apply("no-bind", strconv.FormatBool(conf.Scheduler.NoBind))
+		config.ApplyClientConnection(conf)
+		listenAddr = conf.Scheduler.Address
+		tlsCertFile, tlsKeyFile = conf.Scheduler.TLSCertFile, conf.Scheduler.TLSKeyFile
+		if err := features.SetFromMap(conf.FeatureGates); err != nil {
+			log.Fatal("Error applying feature gates ", err)
+		}
+	}
+	if err := features.Setup(); err != nil {
+		log.Fatal("Error applying feature gates ", err)
+	}
+
 	// context
 	ctx := signals.NewContext()
 
+	// debug dump on SIGUSR1
+	debug.SetupDumpHandler()
+
 	// logging
 	logger := logging.FromContext(ctx)
 
 	// injection
 	ctx, informers := injection.Default.SetupInformers(ctx, cfg)
 
-	// start informers
-	logger.Info("Starting informers.")
-	if err := controller.StartInformers(ctx.Done(), informers...); err != nil {
-		logger.Fatalw("Failed to start informers", err)
+	lvs := scheduler.NewLocalVolumeScheduler(ctx)
+
+	// replay mode: feed captured requests back into the handlers and
+	// exit, the replay wants warm caches so the sync stays blocking here
+	if *replayDir != "" {
+		logger.Info("Starting informers.")
+		if err := controller.StartInformers(ctx.Done(), informers...); err != nil {
+			logger.Fatalw("Failed to start informers", err)
+		}
+		if err := scheduler.Replay(ctx, lvs, *replayDir); err != nil {
+			logger.Fatalw("Failed to replay captured requests", err)
+		}
+		return
 	}
 
-	lvs := scheduler.NewLocalVolumeScheduler(ctx)
+	// start informers in the background, the listener comes up right away
+	// and the decision routes answer 503 until the caches are synced
+	logger.Info("Starting informers.")
+	go func() {
+		if err := controller.StartInformers(ctx.Done(), informers...); err != nil {
+			logger.Fatalw("Failed to start informers", err)
+		}
+	}()
+
+	// capture debug mode
+	if *captureDir != "" {
+		if err := scheduler.EnableCapture(*captureDir, *captureRing); err != nil {
+			logger.Fatalw("Failed to enable request capture", err)
+		}
+	}
 
 	router := httprouter.New()
 
@@ -48,14 +108,36 @@ func main() {
 	// add prioritize route
 	scheduler.AddPrioritize(router, lvs)
 
-	// add bind route
-	scheduler.AddBind(router, lvs)
+	// add bind route, in no-bind mode the default scheduler binds
+	// instead
+	if !scheduler.NoBindMode() {
+		scheduler.AddBind(router, lvs)
+	}
+
+	// the reservation controller reserves from placed pods, in no-bind
+	// mode it replaces the bind route and in bind mode it backfills pods
+	// bound outside the extender so the accounting stays correct
+	go controller.StartAll(ctx.Done(), scheduler.NewReservationController(ctx, lvs))
+
+	// the expiry controller frees reservations whose volume never came
+	go controller.StartAll(ctx.Done(), scheduler.NewExpiryController(ctx, lvs))
+
+	// the verification controller checks that bound pods actually start
+	// and releases the reservations of the ones whose provisioning failed
+	go controller.StartAll(ctx.Done(), scheduler.NewVerificationController(ctx, lvs))
 
 	// add preemption route
 	scheduler.AddPreemption(router, lvs)
 
-	logger.Infof("local volume scheduler starting on the port :80")
-	if err := http.ListenAndServe(":80", router); err != nil {
+	// add drain validation webhook route, admission only reaches it when
+	// the scheduler serves tls
+	scheduler.AddDrainValidation(router, lvs)
+
+	// add claim validation webhook route enforcing class size caps
+	scheduler.AddClaimValidation(router, lvs)
+
+	logger.Infof("local volume scheduler starting on %s", listenAddr)
+	if err := scheduler.Serve(listenAddr, tlsCertFile, tlsKeyFile, router); err != nil {
 		logger.Fatal(err)
 	}
 }
@@ -67,6 +149,10 @@ func getKubeConfig() *rest.Config {
 	)
 	flag.Parse()
 
+	if err := logging.SetupFromFlags(); err != nil {
+		log.Fatal("Error configuring logging", err)
+	}
+
 	cfg, err := kubeconfig.GetConfig(*masterURL, *config)
 	if err != nil {
 		log.Fatal("Error building kubeconfig", err)
@@ -29,15 +29,23 @@ replace (
 require (
 	github.com/comail/colog v0.0.0-20160416085026-fba8e7b1f46c // indirect
 	github.com/container-storage-interface/spec v1.2.0
+	github.com/evanphx/json-patch v4.5.0+incompatible
+	github.com/golang/protobuf v1.3.2
 	github.com/google/uuid v1.1.1
 	github.com/julienschmidt/httprouter v1.3.0
 	github.com/kubernetes-csi/csi-lib-utils v0.7.0 // indirect
+	github.com/kubernetes-csi/csi-test v2.2.0+incompatible
 	github.com/kubernetes-csi/drivers v1.0.2
 	github.com/kubernetes-local-volume/go-lvm v0.0.0-20171011124118-3bc94854c09e // indirect
+	github.com/onsi/ginkgo v1.10.2
+	github.com/prometheus/client_golang v1.0.0
+	github.com/robfig/cron v1.1.0
+	github.com/spf13/cobra v0.0.5
 	github.com/spf13/pflag v1.0.5
 	go.uber.org/zap v1.10.0
 	golang.org/x/net v0.0.0-20191209160850-c0dbc17a3553
 	golang.org/x/sync v0.0.0-20190911185100-cd5d95a43a6e
+	golang.org/x/sys v0.0.0-20191220220014-0732a990476f
 	google.golang.org/grpc v1.26.0
 	k8s.io/api v0.17.0
 	k8s.io/apimachinery v0.17.1-beta.0
@@ -47,4 +55,5 @@ require (
 	k8s.io/klog v1.0.0
 	k8s.io/kubernetes v1.17.0
 	k8s.io/utils v0.0.0-20191114184206-e782cd3c129f
+	sigs.k8s.io/yaml v1.1.0
 )
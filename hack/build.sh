@@ -16,7 +16,10 @@ go_build () {
 	echo "[START] building "kubernetes local volume component $1"..."
 	# We’re disabling cgo which gives us a static binary.
 	# This is needed for building minimal container based on alpine image.
-	GOOS=linux GOARCH=amd64 CGO_ENABLED=0 go build -o ${OUTPUT_DIR}/$2 -installsuffix cgo -ldflags "$go_ldflags" ${WORK_DIR}/cmd/$1/
+	# BUILD_TAGS=chaos builds binaries with the failure injection hooks
+	# compiled in, never use it for production images.
+	# GOOS/GOARCH can be overridden for cross builds, e.g. GOARCH=arm64.
+	GOOS=${GOOS:-linux} GOARCH=${GOARCH:-amd64} CGO_ENABLED=0 go build -tags "${BUILD_TAGS:-}" -o ${OUTPUT_DIR}/$2 -installsuffix cgo -ldflags "$go_ldflags" ${WORK_DIR}/cmd/$1/
 	echo "[END] building "kubernetes local volume component $1"..."
 }
 
@@ -27,9 +30,11 @@ if ! which go > /dev/null; then
 fi
 
 GIT_SHA=`git rev-parse --short HEAD || echo "GitNotFound"`
+BUILD_DATE=`date -u +%Y-%m-%dT%H:%M:%SZ`
 
 gitHash="github.com/kubernetes-local-volume/kubernetes-local-volume/version.GitSHA=${GIT_SHA}"
+buildDate="github.com/kubernetes-local-volume/kubernetes-local-volume/version.BuildDate=${BUILD_DATE}"
 
-go_ldflags="-X ${gitHash}"
+go_ldflags="-X ${gitHash} -X ${buildDate}"
 
 go_build $@
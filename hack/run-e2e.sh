@@ -0,0 +1,53 @@
+#!/usr/bin/env bash
+
+set -o errexit
+set -o nounset
+
+# work dir
+export WORK_DIR=$(cd `dirname $0`; cd ..; pwd)
+
+CLUSTER_NAME=${CLUSTER_NAME:-local-volume-e2e}
+LOOP_SIZE_MB=${LOOP_SIZE_MB:-4096}
+
+# check tooling
+for tool in kind kubectl docker; do
+	if ! which ${tool} > /dev/null; then
+		echo "${tool} needs to be installed"
+		exit 1
+	fi
+done
+
+# 1. create kind cluster
+if ! kind get clusters | grep -q "^${CLUSTER_NAME}$"; then
+	kind create cluster --name ${CLUSTER_NAME}
+fi
+export KUBECONFIG=$(mktemp)
+kind get kubeconfig --name ${CLUSTER_NAME} > ${KUBECONFIG}
+
+# 2. back every kind node with a loop device, it becomes /dev/vdb inside
+# the node and is picked up by the agent when creating the vg
+for node in $(kind get nodes --name ${CLUSTER_NAME}); do
+	docker exec ${node} sh -c "
+		dd if=/dev/zero of=/lv-backing.img bs=1M count=${LOOP_SIZE_MB} 2>/dev/null
+		losetup -f /lv-backing.img
+		loopdev=\$(losetup -j /lv-backing.img | cut -d: -f1)
+		ln -sf \${loopdev} /dev/vdb
+	"
+done
+
+# 3. build images and load them into the cluster
+(cd ${WORK_DIR} && make make-image)
+for image in local-volume-driver local-volume-agent local-volume-scheduler; do
+	if docker image inspect ${image}:latest > /dev/null 2>&1; then
+		kind load docker-image ${image}:latest --name ${CLUSTER_NAME}
+	fi
+done
+
+# 4. install driver, agent and scheduler
+${WORK_DIR}/hack/deploy.sh
+${WORK_DIR}/hack/deploy-scheduler.sh
+kubectl apply -f ${WORK_DIR}/examples/storageclass.yaml
+
+# 5. run the suite
+cd ${WORK_DIR}
+go test -tags e2e -count=1 -timeout 40m ./test/e2e/... "$@"
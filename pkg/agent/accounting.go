@@ -0,0 +1,67 @@
+package agent
+
+import (
+	"context"
+	"flag"
+	"time"
+
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/apis/storage/v1alpha1"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/injection/informers/storage/v1alpha1/localvolume"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/informers/core/v1/persistentvolume"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/informers/core/v1/persistentvolumeclaim"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/controller"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+)
+
+var (
+	accountingInterval = flag.Duration("accounting-interval", 5*time.Minute,
+		"Interval between chargeback aggregation passes over the local volumes of the node")
+	accountingTenantLabel = flag.String("accounting-tenant-label", "team",
+		"Claim label whose value groups the chargeback metrics into tenants")
+)
+
+// NewAccounting aggregates provisioned and used bytes of the local
+// volumes on the node per namespace and tenant into the
+// local_volume_tenant_* metrics, so platform teams can bill or budget
+// local storage without walking claims themselves.
+func NewAccounting(
+	ctx context.Context,
+) *controller.Impl {
+	logger := logging.FromContext(ctx)
+	lvInformer := localvolume.Get(ctx)
+	pvcInformer := persistentvolumeclaim.Get(ctx)
+	pvInformer := persistentvolume.Get(ctx)
+
+	r := &AccountingReconciler{
+		nodeID:      *nodeID,
+		pvcLister:   pvcInformer.Lister(),
+		pvLister:    pvInformer.Lister(),
+		tenantLabel: *accountingTenantLabel,
+		interval:    *accountingInterval,
+	}
+
+	impl := controller.NewImpl(r, logger, AccountingReconcilerName)
+	r.enqueueAfter = impl.EnqueueKeyAfter
+
+	// the LocalVolume of the node paces the aggregation, one pass per
+	// interval
+	lvInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: accountingFilter(*nodeID),
+		Handler:    controller.HandleAll(impl.Enqueue),
+	})
+
+	logger.Info("Accounting Controller Started")
+	return impl
+}
+
+func accountingFilter(nodeID string) func(obj interface{}) bool {
+	return func(obj interface{}) bool {
+		lv, ok := obj.(*v1alpha1.LocalVolume)
+		if !ok {
+			return false
+		}
+		return lv.Name == nodeID
+	}
+}
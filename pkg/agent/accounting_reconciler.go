@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	listerv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/metrics"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+)
+
+const (
+	// ReconcilerName is the name of the reconciler
+	AccountingReconcilerName = "Accounting"
+
+	// unlabeledTenant groups the claims without the tenant label.
+	unlabeledTenant = "unlabeled"
+)
+
+type AccountingReconciler struct {
+	nodeID       string
+	pvcLister    listerv1.PersistentVolumeClaimLister
+	pvLister     listerv1.PersistentVolumeLister
+	tenantLabel  string
+	interval     time.Duration
+	enqueueAfter func(key k8stypes.NamespacedName, delay time.Duration)
+}
+
+func (r *AccountingReconciler) Reconcile(ctx context.Context, key string) error {
+	logger := logging.FromContext(ctx)
+
+	_, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logger.Errorw("Invalid resource key", zap.Error(err))
+		return nil
+	}
+	if name != r.nodeID {
+		return nil
+	}
+
+	if err := r.aggregate(); err != nil {
+		return err
+	}
+	if r.enqueueAfter != nil {
+		r.enqueueAfter(k8stypes.NamespacedName{Namespace: corev1.NamespaceDefault, Name: name}, r.interval)
+	}
+	return nil
+}
+
+// aggregate rebuilds the tenant metrics from the claims whose volume
+// lives on this node, provisioned bytes come from the pv capacity and
+// used bytes from a filesystem probe of mounted volumes.
+func (r *AccountingReconciler) aggregate() error {
+	claims, err := r.pvcLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	metrics.ResetTenantUsage()
+	for _, pvc := range claims {
+		volumeID := pvc.Spec.VolumeName
+		if volumeID == "" {
+			continue
+		}
+		pv, err := r.pvLister.Get(volumeID)
+		if err != nil || !types.IsPVInMyNode(pv, r.nodeID) {
+			continue
+		}
+
+		capacity := pv.Spec.Capacity[corev1.ResourceStorage]
+		provisioned := float64(capacity.Value())
+
+		// an unmounted volume is billed by its provisioned size only
+		used := float64(0)
+		if _, usedBytes, err := volumeUsage(volumeID); err == nil {
+			used = float64(usedBytes)
+		}
+
+		tenant := pvc.Labels[r.tenantLabel]
+		if tenant == "" {
+			tenant = unlabeledTenant
+		}
+		metrics.AddTenantUsage(r.nodeID, pvc.Namespace, tenant, provisioned, used)
+	}
+	return nil
+}
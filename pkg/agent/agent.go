@@ -3,6 +3,7 @@ package agent
 import (
 	"context"
 	"flag"
+	"time"
 
 	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -13,18 +14,41 @@ import (
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/apis/storage/v1alpha1"
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/injection/client"
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/injection/informers/storage/v1alpha1/localvolume"
+	kubeclient "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/client"
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/informers/core/v1/persistentvolume"
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/controller"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/events"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/injection"
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/lvm"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/sharemain"
 	internaltypes "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
 	lvtypes "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
 )
 
 var (
 	nodeID = flag.String("nodeid", "", "node id")
+
+	heartbeatInterval = flag.Duration("heartbeat-interval", time.Minute,
+		"Interval between heartbeat refreshes in the LocalVolume status, consumers treat a heartbeat older than their staleness window as an agent outage")
+
+	dryRun = flag.Bool("dry-run", false,
+		"Only discover and report what the agent would create or clean up, no lvm command that changes the node is executed")
 )
 
+// ScopeInformers narrows the LocalVolume informer of the agent binary
+// to the object of its own node, every agent caching the LocalVolumes
+// of all nodes does not scale to large clusters. cmd/agent registers it
+// with sharemain.DecorateContext.
+func ScopeInformers(ctx context.Context) context.Context {
+	if *nodeID == "" {
+		return ctx
+	}
+	return injection.WithInformerSelector(ctx, "localvolumes", injection.InformerSelector{
+		FieldSelector: "metadata.name=" + *nodeID,
+	})
+}
+
 func NewAgent(
 	ctx context.Context,
 ) *controller.Impl {
@@ -35,8 +59,13 @@ func NewAgent(
 	pvInformer := persistentvolume.Get(ctx)
 
 	// create vg
-	_, err := lvm.CreateVG(lvtypes.VGName)
-	if err != nil {
+	if *dryRun {
+		if lvm.GetVGInfo(lvtypes.VGName) == nil {
+			logger.Infof("dry-run :: would create vg(%s) from devices %v", lvtypes.VGName, lvm.DeviceCandidates())
+		} else {
+			logger.Infof("dry-run :: vg(%s) already exists, nothing to create", lvtypes.VGName)
+		}
+	} else if _, err := lvm.CreateVG(lvtypes.VGName); err != nil {
 		logger.Fatalf("Create vg(%s) error = %s", lvtypes.VGName, err.Error())
 	}
 
@@ -46,12 +75,48 @@ func NewAgent(
 		lvInformer: lvInformer,
 		lvLister:   lvInformer.Lister(),
 		pvLister:   pvInformer.Lister(),
+		recorder:   events.NewDedupRecorder(events.NewRecorder(kubeclient.Get(ctx), AgentReconcilerName), events.DefaultDedupWindow),
+		coalescer:  newWriteCoalescer(*statusWriteInterval, *statusWriteDeltaGB),
 	}
 
 	// register node local volume storage resource
 	registerNodeLocalVolumeStorage(r)
 
+	// announce the agent and take it back on a clean shutdown, in between
+	// the heartbeat covers liveness
+	if err := r.setAgentReady(v1alpha1.ConditionTrue, "AgentStarted", "the agent is running"); err != nil {
+		logger.Warnf("mark node(%s) ready error = %s", *nodeID, err.Error())
+	}
+	sharemain.OnShutdown(AgentReconcilerName, r.shutdown)
+
+	// run periodic slow io detection
+	go newSlowIODetector(*nodeID, client).run(ctx)
+
+	// run the optional scheduled fstrim, skipped in dry-run because the
+	// trim changes the device even though no lv is touched
+	if *fstrimInterval > 0 && !*dryRun {
+		go newTrimmer().run(ctx)
+	}
+
+	// run periodic per volume io accounting from diskstats
+	go newIOStatCollector(*nodeID, pvInformer.Lister()).run(ctx)
+
+	// verify the csi registration plumbing at startup and periodically
+	if *registrationCheckInterval > 0 {
+		go newRegistrationChecker(*nodeID, kubeclient.Get(ctx)).run(ctx)
+	}
+
+	// run the optional warm pool of pre-provisioned volumes
+	if pool, err := newWarmPool(); err != nil {
+		logger.Fatalf("warm pool config error = %s", err.Error())
+	} else if pool != nil {
+		go pool.run(ctx)
+	}
+
 	impl := controller.NewImpl(r, logger, AgentReconcilerName)
+	// the heartbeat refresh paces itself through requeues, the informer
+	// events alone stop once the capacity is stable
+	r.enqueueAfter = impl.EnqueueKeyAfter
 
 	lvInformer.Informer().AddEventHandler(controller.HandleAll(impl.Enqueue))
 
@@ -3,11 +3,14 @@ package agent
 import (
 	"context"
 	"math"
+	"time"
 
 	"go.uber.org/zap"
 	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	k8stypes "k8s.io/apimachinery/pkg/types"
 	corev1 "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 
@@ -15,8 +18,11 @@ import (
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/clientset/versioned"
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/informers/externalversions/storage/v1alpha1"
 	nlvslisters "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/listers/storage/v1alpha1"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/events"
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/lvm"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/metrics"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/status"
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
 )
 
@@ -26,11 +32,14 @@ const (
 )
 
 type AgentReconciler struct {
-	nodeID     string
-	client     versioned.Interface
-	lvInformer v1alpha1.LocalVolumeInformer
-	lvLister   nlvslisters.LocalVolumeLister
-	pvLister   corev1.PersistentVolumeLister
+	nodeID       string
+	client       versioned.Interface
+	lvInformer   v1alpha1.LocalVolumeInformer
+	lvLister     nlvslisters.LocalVolumeLister
+	pvLister     corev1.PersistentVolumeLister
+	recorder     *events.DedupRecorder
+	coalescer    *writeCoalescer
+	enqueueAfter func(key k8stypes.NamespacedName, delay time.Duration)
 }
 
 func (r *AgentReconciler) Reconcile(ctx context.Context, key string) error {
@@ -59,6 +68,10 @@ func (r *AgentReconciler) Reconcile(ctx context.Context, key string) error {
 	if err := r.reconciler(n); err != nil {
 		return err
 	}
+	// keep the heartbeat fresh even when no capacity event arrives
+	if r.enqueueAfter != nil {
+		r.enqueueAfter(k8stypes.NamespacedName{Namespace: namespace, Name: name}, *heartbeatInterval)
+	}
 	return nil
 }
 
@@ -85,21 +98,81 @@ func (r *AgentReconciler) reconciler(lv *nlvsv1alpha1.LocalVolume) error {
 		isNlvsChange = true
 	}
 
-	// 3. update preallocated info
+	// 3. record usage trend sample and projection
+	if recordUsageSample(&lv.Status, freeSize) {
+		isNlvsChange = true
+	}
+	metrics.RecordDaysToFull(lv.Name, projectDaysToFull(lv.Status.UsageSamples))
+
+	// 4. refresh the heartbeat, its age tells the scheduler and the
+	// liveness controller whether the agent is still alive
+	now := metav1.Now()
+	if lv.Status.Heartbeat == nil || now.Sub(lv.Status.Heartbeat.Time) >= *heartbeatInterval {
+		lv.Status.Heartbeat = &now
+		isNlvsChange = true
+	}
+
+	// 5. update preallocated info
 	myNodePVCs := r.getMyNodeBoundedPVCList()
+	var boundedPVCs []string
 	for key := range myNodePVCs {
 		if _, ok := lv.Status.PreAllocated[key]; ok {
 			delete(lv.Status.PreAllocated, key)
-			isNlvsChange = true
+			boundedPVCs = append(boundedPVCs, key)
 		}
 	}
 
-	// 4. update nlvs
+	// 6. update nlvs, the capacity fields are server-side applied under
+	// the agent field manager so the scheduler never clobbers them. The
+	// coalescer defers write bursts, a requeue picks the write up once
+	// the interval has passed
+	if isNlvsChange && !r.coalescer.allow(lv.Name, lv.Status.TotalSize, lv.Status.FreeSize) {
+		if r.enqueueAfter != nil {
+			r.enqueueAfter(k8stypes.NamespacedName{Namespace: v1.NamespaceDefault, Name: lv.Name}, r.coalescer.delay(lv.Name))
+		}
+		logger.Infof("Reconcile NodeLocalVolumeStorage Resource Node = %s deferred a coalesced status write", lv.Name)
+		isNlvsChange = false
+	}
 	if isNlvsChange {
-		_, err := r.client.LocalV1alpha1().LocalVolumes(lv.Namespace).UpdateStatus(lv)
-		if err != nil {
+		applied := &nlvsv1alpha1.LocalVolumeStatus{
+			TotalSize:    lv.Status.TotalSize,
+			FreeSize:     lv.Status.FreeSize,
+			UsageSamples: lv.Status.UsageSamples,
+			Heartbeat:    lv.Status.Heartbeat,
+		}
+		if _, err := status.Apply(r.client, lv.Name, status.AgentFieldManager, applied); err != nil {
+			r.recorder.Eventf(lv, v1.EventTypeWarning, "CapacityUpdateFailed",
+				"update local volume capacity error: %s", err.Error())
 			return err
 		}
+		r.coalescer.wrote(lv.Name, lv.Status.TotalSize, lv.Status.FreeSize)
+	}
+
+	// the preallocated entries are applied by the scheduler, drop the
+	// bounded ones with a merge patch instead of apply
+	if len(boundedPVCs) > 0 {
+		if err := status.RemovePreAllocated(r.client, lv.Name, boundedPVCs); err != nil {
+			r.recorder.Eventf(lv, v1.EventTypeWarning, "CapacityUpdateFailed",
+				"remove pre allocated entries error: %s", err.Error())
+			return err
+		}
+
+		// a reservation turning into a bound volume shows up in the
+		// allocation log, losing a record to a concurrent writer is fine
+		now := metav1.Now()
+		records := make([]nlvsv1alpha1.AllocationRecord, 0, len(boundedPVCs))
+		for _, key := range boundedPVCs {
+			records = append(records, nlvsv1alpha1.AllocationRecord{
+				Op:    nlvsv1alpha1.AllocationOpBind,
+				PVC:   key,
+				Size:  myNodePVCs[key],
+				Time:  now,
+				Actor: status.AgentFieldManager,
+			})
+		}
+		if err := status.AppendAllocations(r.client, lv.Name, records...); err != nil {
+			logger.Warnf("append allocation log of node(%s) error = %s", lv.Name, err.Error())
+		}
 	}
 
 	logger.Infof("Reconcile NodeLocalVolumeStorage Resource Node = %s, totalSize = %d, freeSize = %d",
@@ -107,8 +180,10 @@ func (r *AgentReconciler) reconciler(lv *nlvsv1alpha1.LocalVolume) error {
 	return nil
 }
 
-func (r *AgentReconciler) getMyNodeBoundedPVCList() map[string]string {
-	result := make(map[string]string)
+// getMyNodeBoundedPVCList maps the claim keys bound on this node to
+// their volume size in GB.
+func (r *AgentReconciler) getMyNodeBoundedPVCList() map[string]uint64 {
+	result := make(map[string]uint64)
 
 	allPV, err := r.pvLister.List(labels.Everything())
 	if err != nil {
@@ -117,9 +192,18 @@ func (r *AgentReconciler) getMyNodeBoundedPVCList() map[string]string {
 
 	for _, pv := range allPV {
 		if types.IsPVInMyNode(pv, r.nodeID) && pv.Status.Phase == v1.VolumeBound {
-			result[types.MakePVCKey(pv.Spec.ClaimRef.Namespace, pv.Spec.ClaimRef.Name)] = ""
+			result[types.MakePVCKey(pv.Spec.ClaimRef.Namespace, pv.Spec.ClaimRef.Name)] = pvSizeGB(pv)
 		}
 	}
 
 	return result
 }
+
+// pvSizeGB returns the capacity of the pv in GB.
+func pvSizeGB(pv *v1.PersistentVolume) uint64 {
+	size, ok := pv.Spec.Capacity[v1.ResourceStorage]
+	if !ok {
+		return 0
+	}
+	return uint64(math.Ceil(float64(size.Value()) / 1024 / 1024 / 1024))
+}
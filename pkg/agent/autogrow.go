@@ -0,0 +1,47 @@
+package agent
+
+import (
+	"context"
+
+	"k8s.io/client-go/tools/cache"
+
+	kubeclient "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/client"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/informers/core/v1/persistentvolume"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/informers/core/v1/persistentvolumeclaim"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/controller"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/events"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+)
+
+// NewAutoGrow expands local volumes before they run full: a storage
+// class opting in with the auto-grow parameters gets its claims grown
+// by the configured step whenever the filesystem usage crosses the
+// threshold, up to the configured maximum. The grow is a plain bump of
+// the claim request, the regular expand path does the rest.
+func NewAutoGrow(
+	ctx context.Context,
+) *controller.Impl {
+	logger := logging.FromContext(ctx)
+	pvcInformer := persistentvolumeclaim.Get(ctx)
+	pvInformer := persistentvolume.Get(ctx)
+
+	r := &AutoGrowReconciler{
+		nodeID:        *nodeID,
+		kubeClient:    kubeclient.Get(ctx),
+		pvcLister:     pvcInformer.Lister(),
+		pvLister:      pvInformer.Lister(),
+		probeInterval: *usageProbeInterval,
+		recorder:      events.NewDedupRecorder(events.NewRecorder(kubeclient.Get(ctx), AutoGrowReconcilerName), events.DefaultDedupWindow),
+	}
+
+	impl := controller.NewImpl(r, logger, AutoGrowReconcilerName)
+	r.enqueueAfter = impl.EnqueueKeyAfter
+
+	pvcInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: boundClaimFilter,
+		Handler:    controller.HandleAll(impl.Enqueue),
+	})
+
+	logger.Info("Auto Grow Controller Started")
+	return impl
+}
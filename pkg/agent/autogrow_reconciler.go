@@ -0,0 +1,191 @@
+package agent
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	listerv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/events"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+)
+
+const (
+	// ReconcilerName is the name of the reconciler
+	AutoGrowReconcilerName = "AutoGrow"
+)
+
+// Parameters of a storage class opting into automatic expansion.
+const (
+	// AutoGrowParameter turns the automatic expansion on.
+	AutoGrowParameter = "auto-grow"
+	// AutoGrowThresholdParameter is the usage percent that triggers a
+	// grow, 80 when unset.
+	AutoGrowThresholdParameter = "auto-grow-threshold-percent"
+	// AutoGrowStepParameter is the quantity one grow adds, 1Gi when
+	// unset.
+	AutoGrowStepParameter = "auto-grow-step"
+	// AutoGrowMaxParameter caps the claim size, a class without it does
+	// not grow.
+	AutoGrowMaxParameter = "auto-grow-max"
+
+	defaultAutoGrowThreshold = 80
+	defaultAutoGrowStep      = "1Gi"
+)
+
+type AutoGrowReconciler struct {
+	nodeID        string
+	kubeClient    kubernetes.Interface
+	pvcLister     listerv1.PersistentVolumeClaimLister
+	pvLister      listerv1.PersistentVolumeLister
+	probeInterval time.Duration
+	recorder      *events.DedupRecorder
+	enqueueAfter  func(key k8stypes.NamespacedName, delay time.Duration)
+}
+
+func (r *AutoGrowReconciler) Reconcile(ctx context.Context, key string) error {
+	logger := logging.FromContext(ctx)
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logger.Errorw("Invalid resource key", zap.Error(err))
+		return nil
+	}
+
+	pvc, err := r.pvcLister.PersistentVolumeClaims(namespace).Get(name)
+	if err != nil && errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// only the agent on the node of the volume probes it
+	volumeID := pvc.Spec.VolumeName
+	if volumeID == "" || pvc.Spec.StorageClassName == nil {
+		return nil
+	}
+	pv, err := r.pvLister.Get(volumeID)
+	if err != nil && errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if !types.IsPVInMyNode(pv, r.nodeID) {
+		return nil
+	}
+
+	sc, err := r.kubeClient.StorageV1().StorageClasses().Get(*pvc.Spec.StorageClassName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if sc.Parameters[AutoGrowParameter] != "true" {
+		return nil
+	}
+
+	if err := r.growIfNeeded(pvc, sc, volumeID); err != nil {
+		return err
+	}
+	if r.enqueueAfter != nil {
+		r.enqueueAfter(k8stypes.NamespacedName{Namespace: namespace, Name: name}, r.probeInterval)
+	}
+	return nil
+}
+
+// growIfNeeded bumps the claim request by the step of the class when
+// the filesystem crossed the threshold, the regular expand path picks
+// the new request up.
+func (r *AutoGrowReconciler) growIfNeeded(pvc *corev1.PersistentVolumeClaim, sc *storagev1.StorageClass, volumeID string) error {
+	logger := logging.GetLogger()
+
+	threshold, step, max, err := autoGrowSettings(sc)
+	if err != nil {
+		logger.Warnf("Auto Grow Controller class %s has invalid auto-grow parameters: %s", sc.Name, err.Error())
+		return nil
+	}
+	if max == nil {
+		logger.Warnf("Auto Grow Controller class %s misses the %s parameter, not growing", sc.Name, AutoGrowMaxParameter)
+		return nil
+	}
+	if sc.AllowVolumeExpansion == nil || !*sc.AllowVolumeExpansion {
+		logger.Warnf("Auto Grow Controller class %s does not allow volume expansion, not growing", sc.Name)
+		return nil
+	}
+
+	// an expansion still in flight goes first
+	request := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	if capacity, ok := pvc.Status.Capacity[corev1.ResourceStorage]; ok && capacity.Cmp(request) < 0 {
+		return nil
+	}
+
+	percent, err := volumeUsagePercent(volumeID)
+	if err != nil {
+		logger.Debugf("Auto Grow Controller probe volume %s: %s", volumeID, err.Error())
+		return nil
+	}
+	if percent < threshold {
+		return nil
+	}
+	if request.Cmp(*max) >= 0 {
+		r.recorder.Eventf(pvc, corev1.EventTypeWarning, "VolumeAtMaxSize",
+			"Filesystem of volume %s is %d%% full but the claim already reached the maximum of %s", volumeID, percent, max.String())
+		return nil
+	}
+
+	grown := request.DeepCopy()
+	grown.Add(*step)
+	if grown.Cmp(*max) > 0 {
+		grown = max.DeepCopy()
+	}
+
+	clone := pvc.DeepCopy()
+	clone.Spec.Resources.Requests[corev1.ResourceStorage] = grown
+	if _, err := r.kubeClient.CoreV1().PersistentVolumeClaims(clone.Namespace).Update(clone); err != nil {
+		return err
+	}
+	r.recorder.Eventf(pvc, corev1.EventTypeNormal, "VolumeAutoGrown",
+		"Filesystem of volume %s was %d%% full, grew the claim from %s to %s", volumeID, percent, request.String(), grown.String())
+	logger.Infof("Auto Grow Controller grew claim %s/%s from %s to %s", pvc.Namespace, pvc.Name, request.String(), grown.String())
+	return nil
+}
+
+// autoGrowSettings reads the expansion policy of the class, max stays
+// nil when the class sets none.
+func autoGrowSettings(sc *storagev1.StorageClass) (threshold uint64, step, max *resource.Quantity, err error) {
+	threshold = defaultAutoGrowThreshold
+	if v := sc.Parameters[AutoGrowThresholdParameter]; v != "" {
+		threshold, err = strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return 0, nil, nil, err
+		}
+	}
+
+	stepValue := sc.Parameters[AutoGrowStepParameter]
+	if stepValue == "" {
+		stepValue = defaultAutoGrowStep
+	}
+	stepQuantity, err := resource.ParseQuantity(stepValue)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	step = &stepQuantity
+
+	if v := sc.Parameters[AutoGrowMaxParameter]; v != "" {
+		maxQuantity, err := resource.ParseQuantity(v)
+		if err != nil {
+			return 0, nil, nil, err
+		}
+		max = &maxQuantity
+	}
+	return threshold, step, max, nil
+}
@@ -0,0 +1,78 @@
+package agent
+
+import (
+	"context"
+	"flag"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+
+	kubeclient "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/client"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/informers/core/v1/persistentvolume"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/informers/core/v1/persistentvolumeclaim"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/controller"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/features"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/injection"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+)
+
+var (
+	backupSnapshotSize = flag.Uint64("backup-snapshot-size-gb", 1,
+		"Size in GB of the copy-on-write snapshots taken for backup sessions")
+)
+
+// NewBackup serves backup sessions of tools like velero: a pvc annotated
+// with a backup request gets a crash consistent lvm snapshot on its
+// node, framed by the pre and post freeze commands of the claim, and the
+// snapshot device is published back on the pvc for upload. The
+// controller only acts when the Snapshots feature gate is on.
+func NewBackup(
+	ctx context.Context,
+) *controller.Impl {
+	logger := logging.FromContext(ctx)
+	pvcInformer := persistentvolumeclaim.Get(ctx)
+	pvInformer := persistentvolume.Get(ctx)
+
+	r := &BackupReconciler{
+		nodeID:       *nodeID,
+		kubeClient:   kubeclient.Get(ctx),
+		restConfig:   injection.GetConfig(ctx),
+		pvcLister:    pvcInformer.Lister(),
+		pvLister:     pvInformer.Lister(),
+		snapshotSize: *backupSnapshotSize,
+	}
+	if r.restConfig != nil {
+		dynamicClient, err := dynamic.NewForConfig(r.restConfig)
+		if err != nil {
+			logger.Fatalw("Error building dynamic clientset", err)
+		}
+		r.dynamicClient = dynamicClient
+	}
+
+	impl := controller.NewImpl(r, logger, BackupReconcilerName)
+
+	if !features.Enabled(features.Snapshots) {
+		logger.Infof("Backup Controller disabled, enable the %s feature gate to serve backup sessions", features.Snapshots)
+		return impl
+	}
+
+	pvcInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: backupFilter,
+		Handler:    controller.HandleAll(impl.Enqueue),
+	})
+
+	logger.Info("Backup Controller Started")
+	return impl
+}
+
+func backupFilter(obj interface{}) bool {
+	pvc, ok := obj.(*corev1.PersistentVolumeClaim)
+	if !ok {
+		return false
+	}
+	_, requested := pvc.Annotations[types.BackupRequestedAnnotation]
+	_, session := pvc.Annotations[types.BackupSessionAnnotation]
+	return requested || session
+}
@@ -0,0 +1,153 @@
+package agent
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/utils"
+)
+
+// volumeSnapshotClassGVR is the snapshot.storage.k8s.io class resource,
+// read with a dynamic client so the snapshot crds stay optional.
+var volumeSnapshotClassGVR = schema.GroupVersionResource{
+	Group:    "snapshot.storage.k8s.io",
+	Version:  "v1beta1",
+	Resource: "volumesnapshotclasses",
+}
+
+// snapshotParameters returns the parameters of the VolumeSnapshotClass
+// named on the claim, or an empty map when the claim names none or the
+// class cannot be read.
+func (r *BackupReconciler) snapshotParameters(pvc *corev1.PersistentVolumeClaim) map[string]string {
+	className := pvc.Annotations[types.SnapshotClassAnnotation]
+	if className == "" || r.dynamicClient == nil {
+		return map[string]string{}
+	}
+
+	class, err := r.dynamicClient.Resource(volumeSnapshotClassGVR).Get(className, metav1.GetOptions{})
+	if err != nil {
+		logging.GetLogger().Warnf("Backup Controller read snapshot class %s of claim %s/%s error = %s",
+			className, pvc.Namespace, pvc.Name, err.Error())
+		return map[string]string{}
+	}
+	params, _, _ := unstructured.NestedStringMap(class.Object, "parameters")
+	if params == nil {
+		params = map[string]string{}
+	}
+	return params
+}
+
+// execHook runs the hook command in the pod mounting the claim on this
+// node, in the container the class selects or the first one.
+func (r *BackupReconciler) execHook(pvc *corev1.PersistentVolumeClaim, params map[string]string, command string) error {
+	if r.restConfig == nil {
+		return fmt.Errorf("no rest config, cannot exec into pods")
+	}
+
+	pod, err := r.findHookPod(pvc)
+	if err != nil {
+		return err
+	}
+
+	container := params[types.SnapshotParameterHookContainer]
+	if container == "" {
+		container = pod.Spec.Containers[0].Name
+	}
+
+	request := r.kubeClient.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(pod.Namespace).
+		Name(pod.Name).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: container,
+			Command:   []string{"/bin/sh", "-c", command},
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(r.restConfig, "POST", request.URL())
+	if err != nil {
+		return err
+	}
+	var stdout, stderr bytes.Buffer
+	if err := executor.Stream(remotecommand.StreamOptions{Stdout: &stdout, Stderr: &stderr}); err != nil {
+		return fmt.Errorf("hook in pod %s/%s container %s failed: %s, stderr: %s",
+			pod.Namespace, pod.Name, container, err.Error(), stderr.String())
+	}
+	logging.GetLogger().Infof("Backup Controller ran hook in pod %s/%s container %s", pod.Namespace, pod.Name, container)
+	return nil
+}
+
+// findHookPod returns the running pod on this node mounting the claim.
+func (r *BackupReconciler) findHookPod(pvc *corev1.PersistentVolumeClaim) (*corev1.Pod, error) {
+	pods, err := r.kubeClient.CoreV1().Pods(pvc.Namespace).List(metav1.ListOptions{
+		FieldSelector: "spec.nodeName=" + r.nodeID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		for _, volume := range pod.Spec.Volumes {
+			if volume.PersistentVolumeClaim != nil && volume.PersistentVolumeClaim.ClaimName == pvc.Name {
+				return pod, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no running pod mounts claim %s/%s on node %s", pvc.Namespace, pvc.Name, r.nodeID)
+}
+
+// freezeVolume flushes and blocks writes on the filesystem of the lv,
+// so the cow snapshot sees a clean superblock.
+func freezeVolume(volumeID string) error {
+	mountpoint, err := volumeMountpoint(volumeID)
+	if err != nil {
+		return err
+	}
+	if _, err := utils.Run(fmt.Sprintf("%s fsfreeze -f %s", types.NsenterCmd, mountpoint)); err != nil {
+		return fmt.Errorf("freeze filesystem of volume %s at %s failed: %s", volumeID, mountpoint, err.Error())
+	}
+	return nil
+}
+
+// unfreezeVolume unblocks writes again after the snapshot.
+func unfreezeVolume(volumeID string) error {
+	mountpoint, err := volumeMountpoint(volumeID)
+	if err != nil {
+		return err
+	}
+	if _, err := utils.Run(fmt.Sprintf("%s fsfreeze -u %s", types.NsenterCmd, mountpoint)); err != nil {
+		return fmt.Errorf("unfreeze filesystem of volume %s at %s failed: %s", volumeID, mountpoint, err.Error())
+	}
+	return nil
+}
+
+// volumeMountpoint resolves the first mountpoint of the lv device on
+// the node, freezing one bind mount freezes the filesystem for all.
+func volumeMountpoint(volumeID string) (string, error) {
+	devicePath := filepath.Join("/dev", types.VGName, volumeID)
+	out, err := utils.Run(fmt.Sprintf("%s findmnt -rn -o TARGET -S %s", types.NsenterCmd, devicePath))
+	if err != nil {
+		return "", fmt.Errorf("find mountpoint of %s failed: %s", devicePath, err.Error())
+	}
+	mountpoint := strings.TrimSpace(strings.SplitN(out, "\n", 2)[0])
+	if mountpoint == "" {
+		return "", fmt.Errorf("volume %s is not mounted", volumeID)
+	}
+	return mountpoint, nil
+}
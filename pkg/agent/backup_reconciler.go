@@ -0,0 +1,175 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	listerv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/lvm"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/utils"
+)
+
+const (
+	// ReconcilerName is the name of the reconciler
+	BackupReconcilerName = "BackupSession"
+)
+
+type BackupReconciler struct {
+	nodeID        string
+	kubeClient    kubernetes.Interface
+	restConfig    *rest.Config
+	dynamicClient dynamic.Interface
+	pvcLister     listerv1.PersistentVolumeClaimLister
+	pvLister      listerv1.PersistentVolumeLister
+	snapshotSize  uint64
+}
+
+func (r *BackupReconciler) Reconcile(ctx context.Context, key string) error {
+	logger := logging.FromContext(ctx)
+
+	if *dryRun {
+		logger.Infof("dry-run :: backup controller would process %s, skipping", key)
+		return nil
+	}
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logger.Errorw("Invalid resource key", zap.Error(err))
+		return nil
+	}
+
+	pvc, err := r.pvcLister.PersistentVolumeClaims(namespace).Get(name)
+	if err != nil && errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	requested := pvc.Annotations[types.BackupRequestedAnnotation]
+	session := pvc.Annotations[types.BackupSessionAnnotation]
+	if requested == session {
+		return nil
+	}
+
+	// only the agent on the node of the volume acts
+	volumeID := pvc.Spec.VolumeName
+	if volumeID == "" {
+		return nil
+	}
+	pv, err := r.pvLister.Get(volumeID)
+	if err != nil {
+		return err
+	}
+	if !types.IsPVInMyNode(pv, r.nodeID) {
+		return nil
+	}
+
+	if requested == "" {
+		return r.endSession(pvc, volumeID)
+	}
+	return r.takeSnapshot(pvc, volumeID, requested, session)
+}
+
+// takeSnapshot runs the freeze commands of the claim around a cow
+// snapshot of the lv and publishes the snapshot device on the pvc. A
+// VolumeSnapshotClass named on the claim adds application consistency:
+// its pre and post hooks run in the pod mounting the claim and the
+// filesystem is frozen for the instant of the snapshot.
+func (r *BackupReconciler) takeSnapshot(pvc *corev1.PersistentVolumeClaim, volumeID, requested, session string) error {
+	logger := logging.GetLogger()
+	snapName := backupSnapshotName(volumeID)
+	params := r.snapshotParameters(pvc)
+
+	// a stale snapshot of an earlier session goes first
+	if session != "" {
+		if err := lvm.RemoveSnapshot(types.VGName, snapName); err != nil {
+			logger.Warnf("Backup Controller remove stale snapshot %s error = %s", snapName, err.Error())
+		}
+	}
+
+	if pre := params[types.SnapshotParameterPreHook]; pre != "" {
+		if err := r.execHook(pvc, params, pre); err != nil {
+			return fmt.Errorf("pre snapshot hook failed: %s", err.Error())
+		}
+	}
+	if pre := pvc.Annotations[types.BackupPreFreezeAnnotation]; pre != "" {
+		if _, err := utils.Run(fmt.Sprintf("%s %s", types.NsenterCmd, pre)); err != nil {
+			return fmt.Errorf("pre freeze command failed: %s", err.Error())
+		}
+	}
+
+	frozen := false
+	if params[types.SnapshotParameterFSFreeze] == "true" {
+		if err := freezeVolume(volumeID); err != nil {
+			return err
+		}
+		frozen = true
+	}
+
+	snapErr := lvm.CreateSnapshot(types.VGName, volumeID, snapName, r.snapshotSize)
+
+	// the filesystem thaws and the application unfreezes even when the
+	// snapshot failed
+	if frozen {
+		if err := unfreezeVolume(volumeID); err != nil {
+			logger.Errorf("Backup Controller unfreeze volume %s error = %s", volumeID, err.Error())
+		}
+	}
+	if post := pvc.Annotations[types.BackupPostFreezeAnnotation]; post != "" {
+		if _, err := utils.Run(fmt.Sprintf("%s %s", types.NsenterCmd, post)); err != nil {
+			logger.Errorf("Backup Controller post freeze command of claim %s/%s error = %s", pvc.Namespace, pvc.Name, err.Error())
+		}
+	}
+	if post := params[types.SnapshotParameterPostHook]; post != "" {
+		if err := r.execHook(pvc, params, post); err != nil {
+			logger.Errorf("Backup Controller post snapshot hook of claim %s/%s error = %s", pvc.Namespace, pvc.Name, err.Error())
+		}
+	}
+	if snapErr != nil {
+		return snapErr
+	}
+
+	clone := pvc.DeepCopy()
+	clone.Annotations[types.BackupSessionAnnotation] = requested
+	clone.Annotations[types.BackupDeviceAnnotation] = fmt.Sprintf("/dev/%s/%s", types.VGName, snapName)
+	if _, err := r.kubeClient.CoreV1().PersistentVolumeClaims(clone.Namespace).Update(clone); err != nil {
+		return err
+	}
+
+	logger.Infof("Backup Controller snapshot of claim %s/%s ready for session %s", pvc.Namespace, pvc.Name, requested)
+	return nil
+}
+
+// endSession drops the snapshot after the request annotation went away.
+func (r *BackupReconciler) endSession(pvc *corev1.PersistentVolumeClaim, volumeID string) error {
+	logger := logging.GetLogger()
+	snapName := backupSnapshotName(volumeID)
+
+	if err := lvm.RemoveSnapshot(types.VGName, snapName); err != nil {
+		logger.Warnf("Backup Controller remove snapshot %s error = %s", snapName, err.Error())
+	}
+
+	clone := pvc.DeepCopy()
+	delete(clone.Annotations, types.BackupSessionAnnotation)
+	delete(clone.Annotations, types.BackupDeviceAnnotation)
+	if _, err := r.kubeClient.CoreV1().PersistentVolumeClaims(clone.Namespace).Update(clone); err != nil {
+		return err
+	}
+
+	logger.Infof("Backup Controller ended backup session of claim %s/%s", pvc.Namespace, pvc.Name)
+	return nil
+}
+
+func backupSnapshotName(volumeID string) string {
+	return "backup-" + volumeID
+}
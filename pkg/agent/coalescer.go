@@ -0,0 +1,107 @@
+package agent
+
+import (
+	"flag"
+	"sync"
+	"time"
+)
+
+var (
+	statusWriteInterval = flag.Duration("status-write-interval", 5*time.Second,
+		"Minimum delay between two capacity status writes of the same object, 0 disables coalescing")
+	statusWriteDeltaGB = flag.Uint64("status-write-delta-gb", 10,
+		"Capacity change in GB that bypasses the status write interval")
+)
+
+// writeCoalescer batches bursts of capacity-only status writes, a busy
+// node creating and deleting volumes back to back otherwise floods the
+// api server with updates nobody needs at that granularity. Large
+// capacity jumps bypass the interval so the scheduler never plans with
+// badly stale free sizes.
+type writeCoalescer struct {
+	interval time.Duration
+	deltaGB  uint64
+
+	mu        sync.Mutex
+	lastWrite map[string]coalescedWrite
+}
+
+type coalescedWrite struct {
+	at        time.Time
+	totalSize uint64
+	freeSize  uint64
+}
+
+func newWriteCoalescer(interval time.Duration, deltaGB uint64) *writeCoalescer {
+	return &writeCoalescer{
+		interval:  interval,
+		deltaGB:   deltaGB,
+		lastWrite: make(map[string]coalescedWrite),
+	}
+}
+
+// allow reports whether a status write with the given sizes may go out
+// now. The first write of an object, writes after the interval and
+// capacity deltas of at least deltaGB always may.
+func (c *writeCoalescer) allow(name string, totalSize, freeSize uint64) bool {
+	if c.interval <= 0 {
+		return true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	last, ok := c.lastWrite[name]
+	if !ok {
+		return true
+	}
+	if time.Since(last.at) >= c.interval {
+		return true
+	}
+	if absDelta(last.totalSize, totalSize) >= c.deltaGB {
+		return true
+	}
+	if absDelta(last.freeSize, freeSize) >= c.deltaGB {
+		return true
+	}
+	return false
+}
+
+// wrote records a successful status write of name.
+func (c *writeCoalescer) wrote(name string, totalSize, freeSize uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastWrite[name] = coalescedWrite{at: time.Now(), totalSize: totalSize, freeSize: freeSize}
+}
+
+// forget drops the write record of name, the next write of the object
+// passes regardless of the interval. The shutdown checkpoint uses it.
+func (c *writeCoalescer) forget(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.lastWrite, name)
+}
+
+// delay returns how long a deferred write of name has to wait until the
+// interval has passed.
+func (c *writeCoalescer) delay(name string) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	last, ok := c.lastWrite[name]
+	if !ok {
+		return 0
+	}
+	remaining := c.interval - time.Since(last.at)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+func absDelta(a, b uint64) uint64 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
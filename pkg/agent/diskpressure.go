@@ -0,0 +1,89 @@
+package agent
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/apis/storage/v1alpha1"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/injection/informers/storage/v1alpha1/localvolume"
+	kubeclient "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/client"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/controller"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+)
+
+var (
+	diskPressureFreePercent = flag.Uint64("disk-pressure-free-percent", 10,
+		"Free percentage of the vg below which the node is tainted, 0 disables the capacity check")
+	diskPressureTaint = flag.String("disk-pressure-taint", types.DiskPressureTaintKey+":NoSchedule",
+		"Taint put on a node under disk pressure, key or key:effect")
+)
+
+// NewDiskPressure watches the local volume storage of the node and
+// taints the node when the vg runs critically full or the disk is
+// failing, descheduler and autoscaler policies can then move workloads
+// off before the disk fills or dies.
+func NewDiskPressure(
+	ctx context.Context,
+) *controller.Impl {
+	logger := logging.FromContext(ctx)
+	lvInformer := localvolume.Get(ctx)
+
+	taintKey, taintEffect, err := parseDiskPressureTaint(*diskPressureTaint)
+	if err != nil {
+		logger.Fatalf("Disk pressure taint config error = %s", err.Error())
+	}
+
+	r := &DiskPressureReconciler{
+		nodeID:      *nodeID,
+		kubeClient:  kubeclient.Get(ctx),
+		lvLister:    lvInformer.Lister(),
+		taintKey:    taintKey,
+		taintEffect: taintEffect,
+		freePercent: *diskPressureFreePercent,
+	}
+
+	impl := controller.NewImpl(r, logger, DiskPressureReconcilerName)
+
+	lvInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: diskPressureFilter(*nodeID),
+		Handler:    controller.HandleAll(impl.Enqueue),
+	})
+
+	logger.Info("Disk Pressure Controller Started")
+	return impl
+}
+
+func diskPressureFilter(nodeID string) func(obj interface{}) bool {
+	return func(obj interface{}) bool {
+		lv, ok := obj.(*v1alpha1.LocalVolume)
+		if !ok {
+			return false
+		}
+		return lv.Name == nodeID
+	}
+}
+
+// parseDiskPressureTaint splits a key or key:effect spec, the effect
+// defaults to NoSchedule.
+func parseDiskPressureTaint(spec string) (string, corev1.TaintEffect, error) {
+	parts := strings.SplitN(spec, ":", 2)
+	if parts[0] == "" {
+		return "", "", fmt.Errorf("disk pressure taint %q has no key", spec)
+	}
+	effect := corev1.TaintEffectNoSchedule
+	if len(parts) == 2 {
+		switch corev1.TaintEffect(parts[1]) {
+		case corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+			effect = corev1.TaintEffect(parts[1])
+		default:
+			return "", "", fmt.Errorf("disk pressure taint effect %q is not valid", parts[1])
+		}
+	}
+	return parts[0], effect, nil
+}
@@ -0,0 +1,172 @@
+package agent
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/apis/storage/v1alpha1"
+	nlvslisters "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/listers/storage/v1alpha1"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+)
+
+const (
+	// ReconcilerName is the name of the reconciler
+	DiskPressureReconcilerName = "DiskPressureDetector"
+)
+
+type DiskPressureReconciler struct {
+	nodeID      string
+	kubeClient  kubernetes.Interface
+	lvLister    nlvslisters.LocalVolumeLister
+	taintKey    string
+	taintEffect corev1.TaintEffect
+	freePercent uint64
+}
+
+func (r *DiskPressureReconciler) Reconcile(ctx context.Context, key string) error {
+	logger := logging.FromContext(ctx)
+
+	_, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logger.Errorw("Invalid resource key", zap.Error(err))
+		return nil
+	}
+	if name != r.nodeID {
+		return nil
+	}
+
+	lv, err := r.lvLister.LocalVolumes(corev1.NamespaceDefault).Get(name)
+	if err != nil && errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if r.underPressure(lv) {
+		if err := r.setNodeTaint(true); err != nil {
+			return err
+		}
+		return r.setPodAnnotations(true)
+	}
+	if err := r.setNodeTaint(false); err != nil {
+		return err
+	}
+	return r.setPodAnnotations(false)
+}
+
+// underPressure reports whether the node should be drained, either the
+// disk is failing or the vg dropped below the free threshold.
+func (r *DiskPressureReconciler) underPressure(lv *v1alpha1.LocalVolume) bool {
+	for _, condition := range lv.Status.Conditions {
+		if condition.Type == v1alpha1.LocalVolumeConditionSlowDisk && condition.Status == v1alpha1.ConditionTrue {
+			return true
+		}
+	}
+	if r.freePercent == 0 || lv.Status.TotalSize == 0 {
+		return false
+	}
+	return lv.Status.FreeSize*100 < lv.Status.TotalSize*r.freePercent
+}
+
+// setNodeTaint adds or removes the disk pressure taint, a node already
+// in the wanted state is left untouched.
+func (r *DiskPressureReconciler) setNodeTaint(pressure bool) error {
+	logger := logging.GetLogger()
+
+	node, err := r.kubeClient.CoreV1().Nodes().Get(r.nodeID, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	tainted := false
+	taints := make([]corev1.Taint, 0, len(node.Spec.Taints))
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == r.taintKey {
+			tainted = true
+			continue
+		}
+		taints = append(taints, taint)
+	}
+	if tainted == pressure {
+		return nil
+	}
+
+	if pressure {
+		taints = append(taints, corev1.Taint{Key: r.taintKey, Effect: r.taintEffect})
+	}
+	node.Spec.Taints = taints
+	if _, err := r.kubeClient.CoreV1().Nodes().Update(node); err != nil {
+		logger.Errorf("Disk Pressure Controller update node(%s) taints error = %s", r.nodeID, err.Error())
+		return err
+	}
+	if pressure {
+		logger.Warnf("Disk Pressure Controller tainted node(%s) with %s:%s", r.nodeID, r.taintKey, r.taintEffect)
+	} else {
+		logger.Infof("Disk Pressure Controller removed taint %s of node(%s)", r.taintKey, r.nodeID)
+	}
+	return nil
+}
+
+// setPodAnnotations marks the pods with local volumes on the node so
+// descheduler policies can select them, the mark goes away with the
+// pressure.
+func (r *DiskPressureReconciler) setPodAnnotations(pressure bool) error {
+	pods, err := r.kubeClient.CoreV1().Pods(metav1.NamespaceAll).List(metav1.ListOptions{
+		FieldSelector: "spec.nodeName=" + r.nodeID,
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if !r.usesLocalVolume(pod) {
+			continue
+		}
+		_, marked := pod.Annotations[types.DiskPressureAnnotation]
+		if marked == pressure {
+			continue
+		}
+		clone := pod.DeepCopy()
+		if pressure {
+			if clone.Annotations == nil {
+				clone.Annotations = make(map[string]string)
+			}
+			clone.Annotations[types.DiskPressureAnnotation] = "true"
+		} else {
+			delete(clone.Annotations, types.DiskPressureAnnotation)
+		}
+		if _, err := r.kubeClient.CoreV1().Pods(clone.Namespace).Update(clone); err != nil {
+			logging.GetLogger().Errorf("Disk Pressure Controller update pod(%s/%s) error = %s", clone.Namespace, clone.Name, err.Error())
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *DiskPressureReconciler) usesLocalVolume(pod *corev1.Pod) bool {
+	for _, volume := range pod.Spec.Volumes {
+		if volume.PersistentVolumeClaim == nil {
+			continue
+		}
+		pvc, err := r.kubeClient.CoreV1().PersistentVolumeClaims(pod.Namespace).Get(volume.PersistentVolumeClaim.ClaimName, metav1.GetOptions{})
+		if err != nil || pvc.Spec.StorageClassName == nil {
+			continue
+		}
+		sc, err := r.kubeClient.StorageV1().StorageClasses().Get(*pvc.Spec.StorageClassName, metav1.GetOptions{})
+		if err != nil {
+			continue
+		}
+		if sc.Provisioner == types.DriverName {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package agent
+
+import (
+	"context"
+	"flag"
+
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/apis/storage/v1alpha1"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/injection/informers/storage/v1alpha1/localvolume"
+	kubeclient "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/client"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/informers/core/v1/persistentvolume"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/informers/core/v1/persistentvolumeclaim"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/controller"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/events"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+)
+
+var fenceReadOnly = flag.Bool("fence-read-only", false,
+	"Remount the local volumes of the node read-only while its disk is unhealthy, write errors beat silent corruption")
+
+// NewFencing remounts the mounted local volumes of the node read-only
+// while the disk health conditions fire and back read-write once they
+// clear, the claim conditions and events make the fence observable to
+// workloads.
+func NewFencing(
+	ctx context.Context,
+) *controller.Impl {
+	logger := logging.FromContext(ctx)
+	lvInformer := localvolume.Get(ctx)
+	pvcInformer := persistentvolumeclaim.Get(ctx)
+	pvInformer := persistentvolume.Get(ctx)
+
+	r := &FencingReconciler{
+		nodeID:     *nodeID,
+		enabled:    *fenceReadOnly,
+		kubeClient: kubeclient.Get(ctx),
+		lvLister:   lvInformer.Lister(),
+		pvcLister:  pvcInformer.Lister(),
+		pvLister:   pvInformer.Lister(),
+		recorder:   events.NewDedupRecorder(events.NewRecorder(kubeclient.Get(ctx), FencingReconcilerName), events.DefaultDedupWindow),
+	}
+
+	impl := controller.NewImpl(r, logger, FencingReconcilerName)
+
+	lvInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: fencingFilter(*nodeID),
+		Handler:    controller.HandleAll(impl.Enqueue),
+	})
+
+	logger.Info("Fencing Controller Started")
+	return impl
+}
+
+func fencingFilter(nodeID string) func(obj interface{}) bool {
+	return func(obj interface{}) bool {
+		lv, ok := obj.(*v1alpha1.LocalVolume)
+		if !ok {
+			return false
+		}
+		return lv.Name == nodeID
+	}
+}
@@ -0,0 +1,160 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	listerv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/apis/storage/v1alpha1"
+	nlvslisters "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/listers/storage/v1alpha1"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/events"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/utils"
+)
+
+const (
+	// ReconcilerName is the name of the reconciler
+	FencingReconcilerName = "Fencing"
+)
+
+type FencingReconciler struct {
+	nodeID     string
+	enabled    bool
+	kubeClient kubernetes.Interface
+	lvLister   nlvslisters.LocalVolumeLister
+	pvcLister  listerv1.PersistentVolumeClaimLister
+	pvLister   listerv1.PersistentVolumeLister
+	recorder   *events.DedupRecorder
+}
+
+func (r *FencingReconciler) Reconcile(ctx context.Context, key string) error {
+	logger := logging.FromContext(ctx)
+
+	if !r.enabled {
+		return nil
+	}
+
+	_, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logger.Errorw("Invalid resource key", zap.Error(err))
+		return nil
+	}
+	if name != r.nodeID {
+		return nil
+	}
+
+	lv, err := r.lvLister.LocalVolumes(corev1.NamespaceDefault).Get(name)
+	if err != nil && errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	fence := false
+	for _, condition := range lv.Status.Conditions {
+		if condition.Type == v1alpha1.LocalVolumeConditionSlowDisk && condition.Status == v1alpha1.ConditionTrue {
+			fence = true
+		}
+	}
+
+	return r.applyFence(fence)
+}
+
+// applyFence moves every mounted local volume of the node into the
+// wanted mount state, claims already there are left alone. The claim
+// condition flips together with the mount so workloads see why their
+// writes fail.
+func (r *FencingReconciler) applyFence(fence bool) error {
+	claims, err := r.pvcLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	for _, pvc := range claims {
+		volumeID := pvc.Spec.VolumeName
+		if volumeID == "" {
+			continue
+		}
+		pv, err := r.pvLister.Get(volumeID)
+		if err != nil || !types.IsPVInMyNode(pv, r.nodeID) {
+			continue
+		}
+		if isFenced(pvc) == fence {
+			continue
+		}
+
+		mountpoint, err := volumeMountpoint(volumeID)
+		if err != nil {
+			// an unmounted volume has nothing to fence
+			continue
+		}
+
+		if fence {
+			if _, err := utils.Run(fmt.Sprintf("%s mount -o remount,ro %s", types.NsenterCmd, mountpoint)); err != nil {
+				logging.GetLogger().Errorf("Fencing Controller remount volume %s read-only error = %s", volumeID, err.Error())
+				return err
+			}
+		} else {
+			if _, err := utils.Run(fmt.Sprintf("%s mount -o remount,rw %s", types.NsenterCmd, mountpoint)); err != nil {
+				logging.GetLogger().Errorf("Fencing Controller remount volume %s read-write error = %s", volumeID, err.Error())
+				return err
+			}
+		}
+
+		if err := r.setFencedCondition(pvc, fence); err != nil {
+			return err
+		}
+		if fence {
+			r.recorder.Eventf(pvc, corev1.EventTypeWarning, "VolumeFenced",
+				"Volume %s was remounted read-only, the disk of node %s is unhealthy", volumeID, r.nodeID)
+			logging.GetLogger().Warnf("Fencing Controller fenced volume %s of claim %s/%s", volumeID, pvc.Namespace, pvc.Name)
+		} else {
+			r.recorder.Eventf(pvc, corev1.EventTypeNormal, "VolumeUnfenced",
+				"Volume %s is read-write again, the disk of node %s recovered", volumeID, r.nodeID)
+		}
+	}
+	return nil
+}
+
+// setFencedCondition keeps the fenced condition of the claim in line
+// with the mount state.
+func (r *FencingReconciler) setFencedCondition(pvc *corev1.PersistentVolumeClaim, fence bool) error {
+	clone := pvc.DeepCopy()
+	conditions := make([]corev1.PersistentVolumeClaimCondition, 0, len(clone.Status.Conditions)+1)
+	for _, condition := range clone.Status.Conditions {
+		if condition.Type == types.PVCFencedCondition {
+			continue
+		}
+		conditions = append(conditions, condition)
+	}
+	if fence {
+		conditions = append(conditions, corev1.PersistentVolumeClaimCondition{
+			Type:               types.PVCFencedCondition,
+			Status:             corev1.ConditionTrue,
+			LastTransitionTime: metav1.Now(),
+			Reason:             "DiskUnhealthy",
+			Message:            fmt.Sprintf("volume is read-only while the disk of node %s is unhealthy", r.nodeID),
+		})
+	}
+	clone.Status.Conditions = conditions
+	_, err := r.kubeClient.CoreV1().PersistentVolumeClaims(clone.Namespace).UpdateStatus(clone)
+	return err
+}
+
+func isFenced(pvc *corev1.PersistentVolumeClaim) bool {
+	for _, condition := range pvc.Status.Conditions {
+		if condition.Type == types.PVCFencedCondition && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
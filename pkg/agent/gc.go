@@ -2,17 +2,28 @@ package agent
 
 import (
 	"context"
+	"flag"
 
 	"k8s.io/api/core/v1"
 	"k8s.io/client-go/tools/cache"
 
+	lvclient "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/injection/client"
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/client"
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/informers/core/v1/persistentvolume"
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/controller"
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/state"
 	internaltypes "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
 )
 
+var (
+	stateFile = flag.String("state-file", "/var/lib/local-volume-csi/agent-state.json",
+		"Path of the journal of volume operations in flight, replayed after a restart")
+
+	discardOnDelete = flag.Bool("discard-on-delete", false,
+		"Run blkdiscard on the lv before removing it, returns the blocks to an ssd and scrubs the old data")
+)
+
 func NewGC(
 	ctx context.Context,
 ) *controller.Impl {
@@ -20,12 +31,20 @@ func NewGC(
 	client := client.Get(ctx)
 	pvInformer := persistentvolume.Get(ctx)
 
+	store, err := state.Open(*stateFile)
+	if err != nil {
+		logger.Fatalf("Error opening state journal %s: %s", *stateFile, err.Error())
+	}
+
 	r := &GCReconciler{
 		nodeID:     *nodeID,
 		client:     client,
+		lvClient:   lvclient.Get(ctx),
 		pvInformer: pvInformer,
 		pvLister:   pvInformer.Lister(),
+		state:      store,
 	}
+	r.recoverState()
 
 	impl := controller.NewImpl(r, logger, GCReconcilerName)
 
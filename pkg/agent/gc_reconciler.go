@@ -5,16 +5,24 @@ import (
 	"fmt"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
 	listerv1 "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 
+	nlvsv1alpha1 "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/apis/storage/v1alpha1"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/clientset/versioned"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/chaos"
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/metrics"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/state"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/status"
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/utils"
 )
@@ -31,8 +39,10 @@ var (
 type GCReconciler struct {
 	nodeID     string
 	client     kubernetes.Interface
+	lvClient   versioned.Interface
 	pvInformer v1.PersistentVolumeInformer
 	pvLister   listerv1.PersistentVolumeLister
+	state      *state.Store
 }
 
 func (r *GCReconciler) Reconcile(ctx context.Context, key string) error {
@@ -61,10 +71,21 @@ func (r *GCReconciler) Reconcile(ctx context.Context, key string) error {
 func (r *GCReconciler) reconciler(pv *corev1.PersistentVolume) error {
 	logger := logging.GetLogger()
 
-	if pv.Status.Phase == corev1.VolumeReleased &&
-		pv.Spec.PersistentVolumeReclaimPolicy == corev1.PersistentVolumeReclaimDelete &&
-		utils.SliceContainsString(pv.Finalizers, types.LocalVolumeGCTag) {
+	if pv.Status.Phase != corev1.VolumeReleased ||
+		!utils.SliceContainsString(pv.Finalizers, types.LocalVolumeGCTag) {
+		return nil
+	}
 
+	// report instead of act, the pv and its finalizer stay untouched so
+	// a real run can still clean up later
+	if *dryRun {
+		logger.Infof("dry-run :: GC Controller would reclaim lv of pv(%s) with policy %s",
+			pv.Name, pv.Spec.PersistentVolumeReclaimPolicy)
+		return nil
+	}
+
+	switch pv.Spec.PersistentVolumeReclaimPolicy {
+	case corev1.PersistentVolumeReclaimDelete:
 		if err := r.deleteVolume(pv); err == nil {
 			pv.Finalizers = utils.SliceRemoveString(pv.Finalizers, types.LocalVolumeGCTag)
 			if _, err := r.client.CoreV1().PersistentVolumes().Update(pv); err != nil {
@@ -72,27 +93,136 @@ func (r *GCReconciler) reconciler(pv *corev1.PersistentVolume) error {
 				return err
 			} else {
 				logger.Infof("GC Controller delete %s success", pv.Name)
+				r.logRelease(pv)
 			}
 		}
+
+	case corev1.PersistentVolumeReclaimRetain:
+		// the lv stays on the node, mark it retained so it can be found
+		// for re-adoption and let go of the pv object
+		if err := r.retainVolume(pv); err != nil {
+			logger.Errorf("GC Controller retain %s error : %+v", pv.Name, err)
+			return err
+		}
 	}
 	return nil
 }
 
+// retainVolume records the logical volume of a released Retain pv in
+// the retained map of the node and drops the gc finalizer, deleting the
+// pv object afterwards keeps the data.
+func (r *GCReconciler) retainVolume(pv *corev1.PersistentVolume) error {
+	logger := logging.GetLogger()
+
+	claim := ""
+	if pv.Spec.ClaimRef != nil {
+		claim = types.MakePVCKey(pv.Spec.ClaimRef.Namespace, pv.Spec.ClaimRef.Name)
+	}
+	if err := status.AddRetained(r.lvClient, r.nodeID, map[string]string{pv.Name: claim}); err != nil {
+		return err
+	}
+
+	pv.Finalizers = utils.SliceRemoveString(pv.Finalizers, types.LocalVolumeGCTag)
+	if _, err := r.client.CoreV1().PersistentVolumes().Update(pv); err != nil {
+		return err
+	}
+	logger.Infof("GC Controller retained %s, last claim %s", pv.Name, claim)
+	return nil
+}
+
+// logRelease appends a release record to the allocation log of the
+// node, best effort like the other writers of the log.
+func (r *GCReconciler) logRelease(pv *corev1.PersistentVolume) {
+	claim := ""
+	if pv.Spec.ClaimRef != nil {
+		claim = types.MakePVCKey(pv.Spec.ClaimRef.Namespace, pv.Spec.ClaimRef.Name)
+	}
+	record := nlvsv1alpha1.AllocationRecord{
+		Op:    nlvsv1alpha1.AllocationOpRelease,
+		PVC:   claim,
+		Size:  pvSizeGB(pv),
+		Time:  metav1.Now(),
+		Actor: status.AgentFieldManager,
+	}
+	if err := status.AppendAllocations(r.lvClient, r.nodeID, record); err != nil {
+		logging.GetLogger().Warnf("append allocation log of node(%s) error = %s", r.nodeID, err.Error())
+	}
+}
+
 func (r *GCReconciler) deleteVolume(pv *corev1.PersistentVolume) error {
 	logger := logging.GetLogger()
-	devicePath := filepath.Join("/dev/", types.VGName, "/", pv.Name)
+
+	// journal the delete, a crash mid-remove retries it on restart
+	if r.state != nil {
+		if err := r.state.Begin(pv.Name, state.OpDeleting); err != nil {
+			return err
+		}
+	}
+
+	if err := r.removeLV(pv.Name); err != nil {
+		logger.Errorf("GC Controller Delete LVM volume fail, err:%v", err.Error())
+		return err
+	}
+
+	if r.state != nil {
+		if err := r.state.Finish(pv.Name); err != nil {
+			return err
+		}
+	}
+
+	logger.Infof("GC Controller delete LVM volume of PV(%s) success", pv.Name)
+
+	return nil
+}
+
+func (r *GCReconciler) removeLV(name string) error {
+	devicePath := filepath.Join("/dev/", types.VGName, "/", name)
+
+	if err := chaos.Hook(chaos.OpLVRemove); err != nil {
+		return err
+	}
+
+	// trim the extents before they go back to the vg, the next lv on
+	// them starts on discarded blocks
+	if *discardOnDelete {
+		discardCmd := fmt.Sprintf("%s blkdiscard %s", types.NsenterCmd, devicePath)
+		if _, err := utils.Run(discardCmd); err != nil {
+			// not every device supports discard, the remove still works
+			logging.GetLogger().Warnf("GC Controller blkdiscard of %s error = %s", name, err.Error())
+		}
+	}
 
 	cmd := fmt.Sprintf("%s lvremove -f %s ", types.NsenterCmd, devicePath)
+	start := time.Now()
 	_, err := utils.Run(cmd)
+	// a partial rule reports the remove as failed after it ran
+	err = chaos.HookResult(chaos.OpLVRemove, err)
+	metrics.RecordOperation(metrics.OperationDeleteVolume, start, err)
 	if err != nil {
 		if strings.Contains(err.Error(), LVNotFoundString) {
 			return nil
 		}
-		logger.Errorf("GC Controller Delete LVM volume fail, err:%v", err.Error())
 		return err
 	}
-
-	logger.Infof("GC Controller delete LVM volume: %s, PV(%s) success", devicePath, pv.Name)
-
 	return nil
 }
+
+// recoverState replays the journal after a restart, a volume that was
+// mid-deletion when the agent died is rolled forward by retrying the
+// remove.
+func (r *GCReconciler) recoverState() {
+	logger := logging.GetLogger()
+	for name, op := range r.state.Pending() {
+		if op != state.OpDeleting {
+			continue
+		}
+		logger.Infof("GC Controller recover :: retrying delete of volume %s", name)
+		if err := r.removeLV(name); err != nil {
+			logger.Errorf("GC Controller recover :: delete volume %s error = %s", name, err.Error())
+			continue
+		}
+		if err := r.state.Finish(name); err != nil {
+			logger.Errorf("GC Controller recover :: clear journal entry of volume %s error = %s", name, err.Error())
+		}
+	}
+}
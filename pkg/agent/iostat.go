@@ -0,0 +1,139 @@
+package agent
+
+import (
+	"context"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	listerv1 "k8s.io/client-go/listers/core/v1"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/metrics"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+)
+
+var (
+	iostatInterval = flag.Duration("iostat-interval", 30*time.Second,
+		"Interval of the diskstats collection over the lvs of the vg, feeds the local_volume_read/write io metrics")
+)
+
+const diskstatsSectorSize = 512
+
+// ioStatCollector periodically reads the diskstats counters of every lv
+// in the vg and exports them labeled with the claim the lv belongs to,
+// so a node wide io spike can be traced to the workload causing it.
+type ioStatCollector struct {
+	nodeID   string
+	pvLister listerv1.PersistentVolumeLister
+}
+
+func newIOStatCollector(nodeID string, pvLister listerv1.PersistentVolumeLister) *ioStatCollector {
+	return &ioStatCollector{
+		nodeID:   nodeID,
+		pvLister: pvLister,
+	}
+}
+
+func (c *ioStatCollector) run(ctx context.Context) {
+	wait.Until(c.collect, *iostatInterval, ctx.Done())
+}
+
+func (c *ioStatCollector) collect() {
+	stats := readDiskstats()
+	if len(stats) == 0 {
+		return
+	}
+
+	metrics.ResetVolumeIO()
+	for lv, device := range dmDevicesOfVG(types.VGName) {
+		s, ok := stats[device]
+		if !ok {
+			continue
+		}
+		// warm pool lvs and retained volumes have no claim, they are
+		// not attributable to a workload
+		namespace, pvc := c.claimOf(lv)
+		if pvc == "" {
+			continue
+		}
+		metrics.RecordVolumeIO(c.nodeID, namespace, pvc, s)
+	}
+}
+
+// claimOf resolves the lv name, which equals the pv name, to the bound
+// claim of the pv.
+func (c *ioStatCollector) claimOf(lv string) (namespace, pvc string) {
+	pv, err := c.pvLister.Get(lv)
+	if err != nil || pv.Spec.ClaimRef == nil {
+		return "", ""
+	}
+	return pv.Spec.ClaimRef.Namespace, pv.Spec.ClaimRef.Name
+}
+
+// readDiskstats parses /proc/diskstats into the cumulative counters per
+// kernel device name. The file is global to the host, not namespaced,
+// so the agent container sees the real node devices.
+func readDiskstats() map[string]metrics.VolumeIOStats {
+	result := make(map[string]metrics.VolumeIOStats)
+
+	data, err := ioutil.ReadFile("/proc/diskstats")
+	if err != nil {
+		return result
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 11 {
+			continue
+		}
+		result[fields[2]] = metrics.VolumeIOStats{
+			ReadOps:      diskstatsField(fields, 3),
+			ReadBytes:    diskstatsField(fields, 5) * diskstatsSectorSize,
+			ReadSeconds:  diskstatsField(fields, 6) / 1000,
+			WriteOps:     diskstatsField(fields, 7),
+			WriteBytes:   diskstatsField(fields, 9) * diskstatsSectorSize,
+			WriteSeconds: diskstatsField(fields, 10) / 1000,
+		}
+	}
+	return result
+}
+
+func diskstatsField(fields []string, index int) float64 {
+	value, err := strconv.ParseFloat(fields[index], 64)
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
+// dmDevicesOfVG maps the lv names of the vg to their dm-N kernel device
+// names by walking /sys/block. Device mapper escapes every dash of a vg
+// or lv name as a double dash and joins the two with a single one.
+func dmDevicesOfVG(vgName string) map[string]string {
+	result := make(map[string]string)
+
+	entries, err := ioutil.ReadDir("/sys/block")
+	if err != nil {
+		return result
+	}
+	prefix := strings.Replace(vgName, "-", "--", -1) + "-"
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), "dm-") {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join("/sys/block", entry.Name(), "dm", "name"))
+		if err != nil {
+			continue
+		}
+		dmName := strings.TrimSpace(string(data))
+		if !strings.HasPrefix(dmName, prefix) {
+			continue
+		}
+		lv := strings.Replace(strings.TrimPrefix(dmName, prefix), "--", "-", -1)
+		result[lv] = entry.Name()
+	}
+	return result
+}
@@ -0,0 +1,42 @@
+package agent
+
+import (
+	"context"
+	"flag"
+	"time"
+
+	kubeclient "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/client"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/injection/informers/storage/v1alpha1/localvolume"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/controller"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/events"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+)
+
+var livenessStaleWindow = flag.Duration("heartbeat-stale-window", 3*time.Minute,
+	"Heartbeats older than this raise an agent down event on the LocalVolume, 0 disables the liveness controller")
+
+// NewLiveness watches the heartbeats of all LocalVolumes and raises an
+// event when one goes stale, the agent of that node cannot report its
+// own death. Every node runs this controller, the dedup window keeps
+// the event volume down.
+func NewLiveness(
+	ctx context.Context,
+) *controller.Impl {
+	logger := logging.FromContext(ctx)
+	lvInformer := localvolume.Get(ctx)
+
+	r := &LivenessReconciler{
+		lvLister:    lvInformer.Lister(),
+		staleWindow: *livenessStaleWindow,
+		recorder:    events.NewDedupRecorder(events.NewRecorder(kubeclient.Get(ctx), LivenessReconcilerName), events.DefaultDedupWindow),
+	}
+
+	impl := controller.NewImpl(r, logger, LivenessReconcilerName)
+	r.enqueueAfter = impl.EnqueueKeyAfter
+
+	lvInformer.Informer().AddEventHandler(controller.HandleAll(impl.Enqueue))
+
+	logger.Info("Liveness Controller Started")
+	return impl
+}
@@ -0,0 +1,69 @@
+package agent
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+
+	nlvslisters "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/listers/storage/v1alpha1"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/events"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+)
+
+const (
+	// ReconcilerName is the name of the reconciler
+	LivenessReconcilerName = "Liveness"
+)
+
+type LivenessReconciler struct {
+	lvLister     nlvslisters.LocalVolumeLister
+	staleWindow  time.Duration
+	recorder     *events.DedupRecorder
+	enqueueAfter func(key k8stypes.NamespacedName, delay time.Duration)
+}
+
+func (r *LivenessReconciler) Reconcile(ctx context.Context, key string) error {
+	logger := logging.FromContext(ctx)
+
+	if r.staleWindow <= 0 {
+		return nil
+	}
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logger.Errorw("Invalid resource key", zap.Error(err))
+		return nil
+	}
+
+	lv, err := r.lvLister.LocalVolumes(namespace).Get(name)
+	if err != nil && errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// nodes without a heartbeat run an agent that predates the field
+	if lv.Status.Heartbeat == nil {
+		return nil
+	}
+
+	age := time.Since(lv.Status.Heartbeat.Time)
+	if age > r.staleWindow {
+		logger.Infof("local volume agent of node(%s) last heartbeat %v ago exceeds the staleness window %v",
+			name, age.Round(time.Second), r.staleWindow)
+		r.recorder.Eventf(lv, corev1.EventTypeWarning, "AgentHeartbeatStale",
+			"no agent heartbeat for %v, the agent is down or the node is partitioned", age.Round(time.Second))
+	}
+
+	// a fresh heartbeat only goes stale by the passage of time, check
+	// again once the window would elapse
+	if r.enqueueAfter != nil {
+		r.enqueueAfter(k8stypes.NamespacedName{Namespace: namespace, Name: name}, r.staleWindow)
+	}
+	return nil
+}
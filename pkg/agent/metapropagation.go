@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"context"
+	"flag"
+	"strings"
+
+	"k8s.io/client-go/tools/cache"
+
+	kubeclient "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/client"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/informers/core/v1/persistentvolume"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/informers/core/v1/persistentvolumeclaim"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/controller"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+)
+
+var (
+	propagateLabels = flag.String("propagate-labels", "",
+		"Comma separated allowlist of pvc label keys copied onto the pv, a key ending in * matches by prefix")
+	propagateAnnotations = flag.String("propagate-annotations", "",
+		"Comma separated allowlist of pvc annotation keys copied onto the pv, a key ending in * matches by prefix")
+)
+
+// NewMetaPropagation copies allowlisted labels and annotations of a
+// claim onto its volume, so the pv as the allocation record carries the
+// team and cost attribution of the application and chargeback reports
+// can bill local storage by owner.
+func NewMetaPropagation(
+	ctx context.Context,
+) *controller.Impl {
+	logger := logging.FromContext(ctx)
+	pvcInformer := persistentvolumeclaim.Get(ctx)
+	pvInformer := persistentvolume.Get(ctx)
+
+	r := &MetaPropagationReconciler{
+		nodeID:      *nodeID,
+		kubeClient:  kubeclient.Get(ctx),
+		pvcLister:   pvcInformer.Lister(),
+		pvLister:    pvInformer.Lister(),
+		labels:      parseAllowlist(*propagateLabels),
+		annotations: parseAllowlist(*propagateAnnotations),
+	}
+
+	impl := controller.NewImpl(r, logger, MetaPropagationReconcilerName)
+
+	if len(r.labels) == 0 && len(r.annotations) == 0 {
+		logger.Info("Meta Propagation Controller disabled, set -propagate-labels or -propagate-annotations to enable it")
+		return impl
+	}
+
+	pvcInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: boundClaimFilter,
+		Handler:    controller.HandleAll(impl.Enqueue),
+	})
+
+	logger.Info("Meta Propagation Controller Started")
+	return impl
+}
+
+func parseAllowlist(list string) []string {
+	var keys []string
+	for _, key := range strings.Split(list, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,117 @@
+package agent
+
+import (
+	"context"
+	"strings"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes"
+	listerv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+)
+
+const (
+	// ReconcilerName is the name of the reconciler
+	MetaPropagationReconcilerName = "MetaPropagation"
+)
+
+type MetaPropagationReconciler struct {
+	nodeID      string
+	kubeClient  kubernetes.Interface
+	pvcLister   listerv1.PersistentVolumeClaimLister
+	pvLister    listerv1.PersistentVolumeLister
+	labels      []string
+	annotations []string
+}
+
+func (r *MetaPropagationReconciler) Reconcile(ctx context.Context, key string) error {
+	logger := logging.FromContext(ctx)
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logger.Errorw("Invalid resource key", zap.Error(err))
+		return nil
+	}
+
+	pvc, err := r.pvcLister.PersistentVolumeClaims(namespace).Get(name)
+	if err != nil && errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// only the agent on the node of the volume writes the pv
+	volumeID := pvc.Spec.VolumeName
+	if volumeID == "" {
+		return nil
+	}
+	pv, err := r.pvLister.Get(volumeID)
+	if err != nil && errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if !types.IsPVInMyNode(pv, r.nodeID) {
+		return nil
+	}
+
+	wantLabels := pickAllowed(pvc.Labels, r.labels)
+	wantAnnotations := pickAllowed(pvc.Annotations, r.annotations)
+	if mapsContain(pv.Labels, wantLabels) && mapsContain(pv.Annotations, wantAnnotations) {
+		return nil
+	}
+
+	clone := pv.DeepCopy()
+	if clone.Labels == nil && len(wantLabels) > 0 {
+		clone.Labels = make(map[string]string)
+	}
+	for k, v := range wantLabels {
+		clone.Labels[k] = v
+	}
+	if clone.Annotations == nil && len(wantAnnotations) > 0 {
+		clone.Annotations = make(map[string]string)
+	}
+	for k, v := range wantAnnotations {
+		clone.Annotations[k] = v
+	}
+	if _, err := r.kubeClient.CoreV1().PersistentVolumes().Update(clone); err != nil {
+		return err
+	}
+	logger.Infof("Meta Propagation Controller synced %d labels and %d annotations of claim %s/%s onto pv %s",
+		len(wantLabels), len(wantAnnotations), pvc.Namespace, pvc.Name, volumeID)
+	return nil
+}
+
+// pickAllowed returns the entries of the map the allowlist selects, a
+// key ending in * selects by prefix.
+func pickAllowed(entries map[string]string, allowlist []string) map[string]string {
+	picked := make(map[string]string)
+	for key, value := range entries {
+		for _, allowed := range allowlist {
+			if strings.HasSuffix(allowed, "*") {
+				if strings.HasPrefix(key, strings.TrimSuffix(allowed, "*")) {
+					picked[key] = value
+					break
+				}
+			} else if key == allowed {
+				picked[key] = value
+				break
+			}
+		}
+	}
+	return picked
+}
+
+// mapsContain reports whether every wanted entry is already present.
+func mapsContain(current, want map[string]string) bool {
+	for key, value := range want {
+		if current[key] != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"context"
+	"flag"
+
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/apis/storage/v1alpha1"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/injection/client"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/injection/informers/storage/v1alpha1/localvolumemigration"
+	kubeclient "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/client"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/controller"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+)
+
+var (
+	migrationImage = flag.String("migration-image", "busybox:1.31",
+		"Image of the data copy job of local volume migrations")
+)
+
+// NewMigration drives the LocalVolumeMigration objects targeting this
+// node: a new volume is provisioned here, a snapshot of the source lv is
+// streamed over from its node and the new volume takes over the original
+// claim name, so nodes can be decommissioned without manual data
+// shuffling.
+func NewMigration(
+	ctx context.Context,
+) *controller.Impl {
+	logger := logging.FromContext(ctx)
+	migrationInformer := localvolumemigration.Get(ctx)
+
+	r := &MigrationReconciler{
+		nodeID:     *nodeID,
+		client:     client.Get(ctx),
+		kubeClient: kubeclient.Get(ctx),
+		lister:     migrationInformer.Lister(),
+		image:      *migrationImage,
+	}
+
+	impl := controller.NewImpl(r, logger, MigrationReconcilerName)
+	r.enqueueAfter = impl.EnqueueKeyAfter
+
+	migrationInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: migrationFilter(*nodeID),
+		Handler:    controller.HandleAll(impl.Enqueue),
+	})
+
+	logger.Info("Migration Controller Started")
+	return impl
+}
+
+func migrationFilter(nodeID string) func(obj interface{}) bool {
+	return func(obj interface{}) bool {
+		m, ok := obj.(*v1alpha1.LocalVolumeMigration)
+		if !ok {
+			return false
+		}
+		return m.Spec.TargetNode == nodeID
+	}
+}
@@ -0,0 +1,532 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/apis/storage/v1alpha1"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/clientset/versioned"
+	nlvslisters "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/listers/storage/v1alpha1"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+)
+
+const (
+	// ReconcilerName is the name of the reconciler
+	MigrationReconcilerName = "LocalVolumeMigration"
+
+	// migrationCopyPollInterval is the requeue delay while a migration
+	// waits on the snapshot, the copy job or the claim handover.
+	migrationCopyPollInterval = 10 * time.Second
+
+	// migrationDataPort is where the sender pod on the source node serves
+	// the tar stream of the snapshot.
+	migrationDataPort = 9876
+)
+
+type MigrationReconciler struct {
+	nodeID       string
+	client       versioned.Interface
+	kubeClient   kubernetes.Interface
+	lister       nlvslisters.LocalVolumeMigrationLister
+	image        string
+	enqueueAfter func(key k8stypes.NamespacedName, delay time.Duration)
+}
+
+func (r *MigrationReconciler) Reconcile(ctx context.Context, key string) error {
+	logger := logging.FromContext(ctx)
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logger.Errorw("Invalid resource key", zap.Error(err))
+		return nil
+	}
+
+	original, err := r.lister.LocalVolumeMigrations(namespace).Get(name)
+	if err != nil && errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if original.Spec.TargetNode != r.nodeID {
+		return nil
+	}
+	m := original.DeepCopy()
+
+	switch m.Status.Phase {
+	case "", v1alpha1.MigrationPhasePending:
+		return r.requestSnapshot(m)
+	case v1alpha1.MigrationPhaseSnapshotting:
+		return r.startCopy(m)
+	case v1alpha1.MigrationPhaseCopying:
+		return r.checkCopy(m)
+	case v1alpha1.MigrationPhaseRebinding:
+		return r.rebind(m)
+	case v1alpha1.MigrationPhaseSucceeded:
+		return r.finishHandover(m)
+	}
+	return nil
+}
+
+// requestSnapshot provisions the staging claim and asks the agent on the
+// source node for a snapshot of the live lv through the backup session
+// annotations, the copy later reads the frozen snapshot instead of the
+// moving filesystem under the workload.
+func (r *MigrationReconciler) requestSnapshot(m *v1alpha1.LocalVolumeMigration) error {
+	logger := logging.GetLogger()
+
+	source, err := r.kubeClient.CoreV1().PersistentVolumeClaims(m.Namespace).Get(m.Spec.PVCName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return r.updateStatus(m, v1alpha1.MigrationPhaseFailed,
+				fmt.Sprintf("source claim %s not found", m.Spec.PVCName))
+		}
+		return err
+	}
+	if source.Spec.VolumeName == "" {
+		return r.updateStatus(m, v1alpha1.MigrationPhaseFailed,
+			fmt.Sprintf("source claim %s is not bound, there is no data to move", m.Spec.PVCName))
+	}
+
+	destName := migrationDestPVCName(m)
+	dest := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            destName,
+			Namespace:       m.Namespace,
+			OwnerReferences: []metav1.OwnerReference{migrationOwnerRef(m)},
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			StorageClassName: source.Spec.StorageClassName,
+			AccessModes:      source.Spec.AccessModes,
+			Resources:        source.Spec.Resources,
+		},
+	}
+	if _, err := r.kubeClient.CoreV1().PersistentVolumeClaims(m.Namespace).Create(dest); err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+
+	if requested := source.Annotations[types.BackupRequestedAnnotation]; requested != migrationSession(m) {
+		// a running backup session owns the snapshot slot of the volume,
+		// wait for it to end instead of stealing the device
+		if requested != "" {
+			r.requeue(m)
+			return nil
+		}
+		clone := source.DeepCopy()
+		if clone.Annotations == nil {
+			clone.Annotations = make(map[string]string)
+		}
+		clone.Annotations[types.BackupRequestedAnnotation] = migrationSession(m)
+		if _, err := r.kubeClient.CoreV1().PersistentVolumeClaims(clone.Namespace).Update(clone); err != nil {
+			return err
+		}
+	}
+
+	m.Status.DestPVCName = destName
+	logger.Infof("Migration Controller requested snapshot of claim %s/%s for migration to node(%s)",
+		m.Namespace, m.Spec.PVCName, m.Spec.TargetNode)
+	return r.updateStatus(m, v1alpha1.MigrationPhaseSnapshotting, "")
+}
+
+// startCopy waits for the source agent to publish the snapshot device
+// and then wires up the copy: a sender pod on the source node mounts the
+// snapshot and serves it as one tar stream, a receiver job on this node
+// unpacks the stream into the staging claim, a service between them
+// carries the data across nodes. No single pod could mount both volumes,
+// their node affinities pin them to different nodes.
+func (r *MigrationReconciler) startCopy(m *v1alpha1.LocalVolumeMigration) error {
+	logger := logging.GetLogger()
+
+	source, err := r.kubeClient.CoreV1().PersistentVolumeClaims(m.Namespace).Get(m.Spec.PVCName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	device := source.Annotations[types.BackupDeviceAnnotation]
+	if source.Annotations[types.BackupSessionAnnotation] != migrationSession(m) || device == "" {
+		// the source agent has not snapshotted yet
+		r.requeue(m)
+		return nil
+	}
+
+	pv, err := r.kubeClient.CoreV1().PersistentVolumes().Get(source.Spec.VolumeName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	sourceNode := nodeOfPV(pv)
+	if sourceNode == "" {
+		return r.updateStatus(m, v1alpha1.MigrationPhaseFailed,
+			fmt.Sprintf("volume %s of the source claim carries no node affinity", pv.Name))
+	}
+
+	if _, err := r.kubeClient.CoreV1().Services(m.Namespace).Create(r.dataService(m)); err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+	if _, err := r.kubeClient.CoreV1().Pods(m.Namespace).Create(r.senderPod(m, sourceNode, device)); err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+	if _, err := r.kubeClient.BatchV1().Jobs(m.Namespace).Create(r.receiverJob(m)); err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+
+	logger.Infof("Migration Controller started copy of claim %s/%s from node(%s) to node(%s)",
+		m.Namespace, m.Spec.PVCName, sourceNode, m.Spec.TargetNode)
+	return r.updateStatus(m, v1alpha1.MigrationPhaseCopying, "")
+}
+
+// checkCopy follows the receiver job until it finished either way.
+func (r *MigrationReconciler) checkCopy(m *v1alpha1.LocalVolumeMigration) error {
+	job, err := r.kubeClient.BatchV1().Jobs(m.Namespace).Get(migrationJobName(m), metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return r.updateStatus(m, v1alpha1.MigrationPhaseRebinding, "")
+		case batchv1.JobFailed:
+			return r.updateStatus(m, v1alpha1.MigrationPhaseFailed,
+				fmt.Sprintf("copy job failed: %s", condition.Message))
+		}
+	}
+
+	r.requeue(m)
+	return nil
+}
+
+// rebind parks the new volume for the original claim name: the copy
+// workloads and the snapshot go away, the staging claim is released with
+// its volume retained and pre-bound to the source claim name, and the
+// source claim is deleted. While the workload still runs its claim only
+// goes Terminating, the handover completes after the eviction.
+func (r *MigrationReconciler) rebind(m *v1alpha1.LocalVolumeMigration) error {
+	logger := logging.GetLogger()
+
+	if err := r.dropCopyWorkloads(m); err != nil {
+		return err
+	}
+	if err := r.endSnapshotSession(m); err != nil {
+		return err
+	}
+
+	// the volume name must be durable in status before the staging claim
+	// goes away, it is the only pointer left afterwards
+	if m.Status.DestPVName == "" {
+		dest, err := r.kubeClient.CoreV1().PersistentVolumeClaims(m.Namespace).Get(m.Status.DestPVCName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if dest.Spec.VolumeName == "" {
+			r.requeue(m)
+			return nil
+		}
+		m.Status.DestPVName = dest.Spec.VolumeName
+		if err := r.updateStatus(m, v1alpha1.MigrationPhaseRebinding, ""); err != nil {
+			return err
+		}
+	}
+
+	pv, err := r.kubeClient.CoreV1().PersistentVolumes().Get(m.Status.DestPVName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	if pv.Spec.ClaimRef == nil || pv.Spec.ClaimRef.Name != m.Spec.PVCName {
+		// retain first, releasing the staging claim must not delete the
+		// copied data
+		if pv.Spec.PersistentVolumeReclaimPolicy != corev1.PersistentVolumeReclaimRetain {
+			pv.Spec.PersistentVolumeReclaimPolicy = corev1.PersistentVolumeReclaimRetain
+			if _, err := r.kubeClient.CoreV1().PersistentVolumes().Update(pv); err != nil {
+				return err
+			}
+		}
+		err := r.kubeClient.CoreV1().PersistentVolumeClaims(m.Namespace).Delete(m.Status.DestPVCName, &metav1.DeleteOptions{})
+		if err == nil {
+			// released this round, the claim ref rewrite waits for the
+			// claim to be fully gone
+			r.requeue(m)
+			return nil
+		}
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		// pre-bind the volume to the claim name of the workload, the pv
+		// controller binds it once a claim with that name exists again
+		pv.Spec.ClaimRef = &corev1.ObjectReference{
+			Kind:       "PersistentVolumeClaim",
+			APIVersion: "v1",
+			Namespace:  m.Namespace,
+			Name:       m.Spec.PVCName,
+		}
+		if _, err := r.kubeClient.CoreV1().PersistentVolumes().Update(pv); err != nil {
+			return err
+		}
+	}
+
+	// the source claim retires, its volume and lv follow through the
+	// usual reclaim once the workload is evicted
+	if err := r.kubeClient.CoreV1().PersistentVolumeClaims(m.Namespace).Delete(m.Spec.PVCName, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	logger.Infof("Migration Controller parked volume %s for claim %s/%s, the eviction completes the handover",
+		m.Status.DestPVName, m.Namespace, m.Spec.PVCName)
+	return r.updateStatus(m, v1alpha1.MigrationPhaseSucceeded, "")
+}
+
+// finishHandover recreates the original claim bound to the new volume
+// once the old claim object is finally gone, a workload whose controller
+// does not recreate its claims would otherwise come back clamless.
+func (r *MigrationReconciler) finishHandover(m *v1alpha1.LocalVolumeMigration) error {
+	logger := logging.GetLogger()
+
+	pvc, err := r.kubeClient.CoreV1().PersistentVolumeClaims(m.Namespace).Get(m.Spec.PVCName, metav1.GetOptions{})
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	if err == nil {
+		if pvc.Spec.VolumeName != m.Status.DestPVName {
+			// the old claim lingers in Terminating while the workload runs
+			r.requeue(m)
+			return nil
+		}
+		// rebound, hand the reclaim of the new volume back to the driver
+		return r.restoreReclaimPolicy(m)
+	}
+
+	pv, err := r.kubeClient.CoreV1().PersistentVolumes().Get(m.Status.DestPVName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	replacement := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: m.Spec.PVCName, Namespace: m.Namespace},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			StorageClassName: &pv.Spec.StorageClassName,
+			AccessModes:      pv.Spec.AccessModes,
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: pv.Spec.Capacity[corev1.ResourceStorage]},
+			},
+			VolumeName: m.Status.DestPVName,
+		},
+	}
+	if _, err := r.kubeClient.CoreV1().PersistentVolumeClaims(m.Namespace).Create(replacement); err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+
+	logger.Infof("Migration Controller rebound claim %s/%s to volume %s on node(%s)",
+		m.Namespace, m.Spec.PVCName, m.Status.DestPVName, m.Spec.TargetNode)
+	r.requeue(m)
+	return nil
+}
+
+// restoreReclaimPolicy puts the new volume back on Delete after the
+// handover, Retain was only armor for the rebind.
+func (r *MigrationReconciler) restoreReclaimPolicy(m *v1alpha1.LocalVolumeMigration) error {
+	pv, err := r.kubeClient.CoreV1().PersistentVolumes().Get(m.Status.DestPVName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if pv.Spec.PersistentVolumeReclaimPolicy == corev1.PersistentVolumeReclaimDelete {
+		return nil
+	}
+	pv.Spec.PersistentVolumeReclaimPolicy = corev1.PersistentVolumeReclaimDelete
+	_, err = r.kubeClient.CoreV1().PersistentVolumes().Update(pv)
+	return err
+}
+
+// dropCopyWorkloads removes the sender pod, the data service and the
+// receiver job of the migration.
+func (r *MigrationReconciler) dropCopyWorkloads(m *v1alpha1.LocalVolumeMigration) error {
+	if err := r.kubeClient.CoreV1().Pods(m.Namespace).Delete(migrationSenderName(m), &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if err := r.kubeClient.CoreV1().Services(m.Namespace).Delete(migrationServiceName(m), &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	propagation := metav1.DeletePropagationBackground
+	if err := r.kubeClient.BatchV1().Jobs(m.Namespace).Delete(migrationJobName(m), &metav1.DeleteOptions{PropagationPolicy: &propagation}); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// endSnapshotSession drops the migration snapshot on the source node by
+// withdrawing the backup request from the source claim.
+func (r *MigrationReconciler) endSnapshotSession(m *v1alpha1.LocalVolumeMigration) error {
+	source, err := r.kubeClient.CoreV1().PersistentVolumeClaims(m.Namespace).Get(m.Spec.PVCName, metav1.GetOptions{})
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if source.Annotations[types.BackupRequestedAnnotation] != migrationSession(m) {
+		return nil
+	}
+	clone := source.DeepCopy()
+	delete(clone.Annotations, types.BackupRequestedAnnotation)
+	_, err = r.kubeClient.CoreV1().PersistentVolumeClaims(clone.Namespace).Update(clone)
+	return err
+}
+
+// senderPod mounts the snapshot device on the source node and serves it
+// as a tar stream, one connection at a time, until the rebind removes
+// the pod again.
+func (r *MigrationReconciler) senderPod(m *v1alpha1.LocalVolumeMigration, sourceNode, device string) *corev1.Pod {
+	privileged := true
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            migrationSenderName(m),
+			Namespace:       m.Namespace,
+			Labels:          map[string]string{types.MigrationPodLabel: m.Name},
+			OwnerReferences: []metav1.OwnerReference{migrationOwnerRef(m)},
+		},
+		Spec: corev1.PodSpec{
+			NodeSelector: map[string]string{"kubernetes.io/hostname": sourceNode},
+			Containers: []corev1.Container{{
+				Name:            "send",
+				Image:           r.migrationImage(m),
+				SecurityContext: &corev1.SecurityContext{Privileged: &privileged},
+				Command: []string{"sh", "-c", fmt.Sprintf(
+					"mkdir -p /snapshot && mount -o ro /dev/snapshot /snapshot && while true; do tar -C /snapshot -cf - . | nc -l -p %d; done",
+					migrationDataPort)},
+				VolumeMounts: []corev1.VolumeMount{{Name: "snapshot-device", MountPath: "/dev/snapshot"}},
+			}},
+			Volumes: []corev1.Volume{{
+				Name:         "snapshot-device",
+				VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: device}},
+			}},
+		},
+	}
+}
+
+// dataService carries the tar stream from the sender pod to the receiver
+// job, the nodes of the two have no other shared path to the data.
+func (r *MigrationReconciler) dataService(m *v1alpha1.LocalVolumeMigration) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            migrationServiceName(m),
+			Namespace:       m.Namespace,
+			OwnerReferences: []metav1.OwnerReference{migrationOwnerRef(m)},
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{types.MigrationPodLabel: m.Name},
+			Ports:    []corev1.ServicePort{{Port: migrationDataPort}},
+		},
+	}
+}
+
+// receiverJob unpacks the stream into the staging claim on the target
+// node, the claim binds here because only this pod consumes it. A failed
+// or truncated transfer leaves a nonzero tar, the retry starts over on
+// an emptied volume.
+func (r *MigrationReconciler) receiverJob(m *v1alpha1.LocalVolumeMigration) *batchv1.Job {
+	backoffLimit := int32(3)
+	receive := fmt.Sprintf(
+		"for i in $(seq 1 60); do nc %s.%s %d | tar -xf - -C /destination && exit 0; find /destination -mindepth 1 -delete; sleep 5; done; exit 1",
+		migrationServiceName(m), m.Namespace, migrationDataPort)
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            migrationJobName(m),
+			Namespace:       m.Namespace,
+			OwnerReferences: []metav1.OwnerReference{migrationOwnerRef(m)},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					NodeSelector:  map[string]string{"kubernetes.io/hostname": m.Spec.TargetNode},
+					Containers: []corev1.Container{{
+						Name:         "receive",
+						Image:        r.migrationImage(m),
+						Command:      []string{"sh", "-c", receive},
+						VolumeMounts: []corev1.VolumeMount{{Name: "destination", MountPath: "/destination"}},
+					}},
+					Volumes: []corev1.Volume{{
+						Name: "destination",
+						VolumeSource: corev1.VolumeSource{
+							PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: migrationDestPVCName(m)},
+						},
+					}},
+				},
+			},
+		},
+	}
+}
+
+func (r *MigrationReconciler) migrationImage(m *v1alpha1.LocalVolumeMigration) string {
+	if m.Spec.Image != "" {
+		return m.Spec.Image
+	}
+	return r.image
+}
+
+func (r *MigrationReconciler) requeue(m *v1alpha1.LocalVolumeMigration) {
+	if r.enqueueAfter != nil {
+		r.enqueueAfter(k8stypes.NamespacedName{Namespace: m.Namespace, Name: m.Name}, migrationCopyPollInterval)
+	}
+}
+
+func (r *MigrationReconciler) updateStatus(m *v1alpha1.LocalVolumeMigration, phase v1alpha1.MigrationPhase, message string) error {
+	m.Status.Phase = phase
+	m.Status.Message = message
+	_, err := r.client.LocalV1alpha1().LocalVolumeMigrations(m.Namespace).UpdateStatus(m)
+	return err
+}
+
+// nodeOfPV returns the node the volume is pinned to through its node
+// affinity, the empty string when there is none.
+func nodeOfPV(pv *corev1.PersistentVolume) string {
+	if pv.Spec.NodeAffinity == nil || pv.Spec.NodeAffinity.Required == nil {
+		return ""
+	}
+	for _, term := range pv.Spec.NodeAffinity.Required.NodeSelectorTerms {
+		for _, expression := range term.MatchExpressions {
+			if expression.Key == types.TopologyNodeKey && len(expression.Values) > 0 {
+				return expression.Values[0]
+			}
+		}
+	}
+	return ""
+}
+
+func migrationOwnerRef(m *v1alpha1.LocalVolumeMigration) metav1.OwnerReference {
+	return *metav1.NewControllerRef(m, v1alpha1.SchemeGroupVersion.WithKind("LocalVolumeMigration"))
+}
+
+func migrationSession(m *v1alpha1.LocalVolumeMigration) string {
+	return "migrate-" + m.Name
+}
+
+func migrationDestPVCName(m *v1alpha1.LocalVolumeMigration) string {
+	return m.Spec.PVCName + "-" + m.Name
+}
+
+func migrationJobName(m *v1alpha1.LocalVolumeMigration) string {
+	return "migrate-" + m.Name
+}
+
+func migrationSenderName(m *v1alpha1.LocalVolumeMigration) string {
+	return "migrate-" + m.Name + "-send"
+}
+
+func migrationServiceName(m *v1alpha1.LocalVolumeMigration) string {
+	return "migrate-" + m.Name + "-data"
+}
@@ -0,0 +1,50 @@
+package agent
+
+import (
+	"context"
+
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/apis/storage/v1alpha1"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/injection/informers/storage/v1alpha1/localvolume"
+	kubeclient "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/client"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/controller"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+)
+
+// NewNodeLabels publishes discovered storage properties of the node as
+// node labels, plain node selectors and affinities can then target
+// nodes by their local storage characteristics.
+func NewNodeLabels(
+	ctx context.Context,
+) *controller.Impl {
+	logger := logging.FromContext(ctx)
+	lvInformer := localvolume.Get(ctx)
+
+	r := &NodeLabelsReconciler{
+		nodeID:     *nodeID,
+		kubeClient: kubeclient.Get(ctx),
+		lvLister:   lvInformer.Lister(),
+	}
+
+	impl := controller.NewImpl(r, logger, NodeLabelsReconcilerName)
+
+	// capacity updates of the own LocalVolume pace the label sync
+	lvInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: nodeLabelsFilter(*nodeID),
+		Handler:    controller.HandleAll(impl.Enqueue),
+	})
+
+	logger.Info("Node Labels Controller Started")
+	return impl
+}
+
+func nodeLabelsFilter(nodeID string) func(obj interface{}) bool {
+	return func(obj interface{}) bool {
+		lv, ok := obj.(*v1alpha1.LocalVolume)
+		if !ok {
+			return false
+		}
+		return lv.Name == nodeID
+	}
+}
@@ -0,0 +1,148 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	nlvslisters "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/listers/storage/v1alpha1"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/lvm"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+)
+
+const (
+	// ReconcilerName is the name of the reconciler
+	NodeLabelsReconcilerName = "NodeLabels"
+)
+
+type NodeLabelsReconciler struct {
+	nodeID     string
+	kubeClient kubernetes.Interface
+	lvLister   nlvslisters.LocalVolumeLister
+}
+
+func (r *NodeLabelsReconciler) Reconcile(ctx context.Context, key string) error {
+	logger := logging.FromContext(ctx)
+
+	_, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logger.Errorw("Invalid resource key", zap.Error(err))
+		return nil
+	}
+	if name != r.nodeID {
+		return nil
+	}
+
+	lv, err := r.lvLister.LocalVolumes(corev1.NamespaceDefault).Get(name)
+	if err != nil && errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	wanted := map[string]string{
+		types.NodeLabelHasNVMe:        fmt.Sprintf("%t", hasNVMeDevice()),
+		types.NodeLabelDeviceClass:    deviceClass(),
+		types.NodeLabelVGNames:        types.VGName,
+		types.NodeLabelCapacityBucket: capacityBucket(lv.Status.TotalSize),
+	}
+	return r.syncNodeLabels(wanted)
+}
+
+// syncNodeLabels writes the wanted labels on the node, a node already
+// carrying them is left untouched.
+func (r *NodeLabelsReconciler) syncNodeLabels(wanted map[string]string) error {
+	node, err := r.kubeClient.CoreV1().Nodes().Get(r.nodeID, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	for key, value := range wanted {
+		if node.Labels[key] == value {
+			continue
+		}
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+
+	clone := node.DeepCopy()
+	if clone.Labels == nil {
+		clone.Labels = make(map[string]string)
+	}
+	for key, value := range wanted {
+		clone.Labels[key] = value
+	}
+	if _, err := r.kubeClient.CoreV1().Nodes().Update(clone); err != nil {
+		logging.GetLogger().Errorf("Node Labels Controller update node(%s) error = %s", r.nodeID, err.Error())
+		return err
+	}
+	logging.GetLogger().Infof("Node Labels Controller labeled node(%s) with %v", r.nodeID, wanted)
+	return nil
+}
+
+// hasNVMeDevice reports whether an nvme device backs the vg.
+func hasNVMeDevice() bool {
+	for _, device := range lvm.GetVGDevices(types.VGName) {
+		if strings.Contains(device, "nvme") {
+			return true
+		}
+	}
+	return false
+}
+
+// deviceClass maps the devices of the vg to nvme, ssd or hdd, the
+// fastest class wins when the vg mixes devices.
+func deviceClass() string {
+	class := "unknown"
+	for _, device := range lvm.GetVGDevices(types.VGName) {
+		switch {
+		case strings.Contains(device, "nvme"):
+			return "nvme"
+		case !deviceRotational(device):
+			class = "ssd"
+		case class == "unknown":
+			class = "hdd"
+		}
+	}
+	return class
+}
+
+// deviceRotational reads the rotational flag of the device from sysfs,
+// an unreadable flag counts as rotational.
+func deviceRotational(device string) bool {
+	name := strings.TrimRight(strings.TrimPrefix(device, "/dev/"), "0123456789")
+	data, err := ioutil.ReadFile("/sys/block/" + name + "/queue/rotational")
+	if err != nil {
+		return true
+	}
+	return strings.TrimSpace(string(data)) != "0"
+}
+
+// capacityBucket maps the total vg size in GB into a coarse label
+// value, exact sizes would churn the label on every resize.
+func capacityBucket(totalSize uint64) string {
+	switch {
+	case totalSize == 0:
+		return "none"
+	case totalSize < 100:
+		return "0-100G"
+	case totalSize < 500:
+		return "100-500G"
+	case totalSize < 1000:
+		return "500-1000G"
+	default:
+		return "over-1000G"
+	}
+}
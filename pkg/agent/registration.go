@@ -0,0 +1,148 @@
+package agent
+
+import (
+	"context"
+	"flag"
+	"path/filepath"
+	"time"
+
+	storagev1beta1 "k8s.io/api/storage/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/metrics"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/utils"
+)
+
+var (
+	registrationCheckInterval = flag.Duration("registration-check-interval", 5*time.Minute,
+		"Interval of the csi registration checks on this node, 0 disables them")
+
+	kubeletRootDir = flag.String("kubelet-root-dir", "/var/lib/kubelet",
+		"Root directory of kubelet, the plugin registration socket lives under it")
+)
+
+const (
+	registrationCheckCSIDriver = "csidriver"
+	registrationCheckCSINode   = "csinode"
+	registrationCheckTopology  = "topology"
+	registrationCheckSocket    = "registration-socket"
+)
+
+// registrationChecker verifies at startup and then periodically that the
+// csi plumbing of this node is intact: the cluster wide CSIDriver
+// object, the CSINode entry kubelet writes after plugin registration,
+// the topology label and the registration socket itself. What the agent
+// owns is repaired, what kubelet owns is alerted on, either mismatch
+// silently breaks provisioning on the node otherwise.
+type registrationChecker struct {
+	nodeID     string
+	kubeClient kubernetes.Interface
+}
+
+func newRegistrationChecker(nodeID string, kubeClient kubernetes.Interface) *registrationChecker {
+	return &registrationChecker{
+		nodeID:     nodeID,
+		kubeClient: kubeClient,
+	}
+}
+
+func (c *registrationChecker) run(ctx context.Context) {
+	wait.Until(c.check, *registrationCheckInterval, ctx.Done())
+}
+
+func (c *registrationChecker) check() {
+	metrics.RecordRegistrationCheck(c.nodeID, registrationCheckCSIDriver, c.checkCSIDriver())
+	metrics.RecordRegistrationCheck(c.nodeID, registrationCheckCSINode, c.checkCSINode())
+	metrics.RecordRegistrationCheck(c.nodeID, registrationCheckTopology, c.checkTopologyLabel())
+	metrics.RecordRegistrationCheck(c.nodeID, registrationCheckSocket, c.checkRegistrationSocket())
+}
+
+// checkCSIDriver recreates the cluster wide CSIDriver object when it is
+// gone, without it kubelet rejects every mount of the driver.
+func (c *registrationChecker) checkCSIDriver() bool {
+	logger := logging.GetLogger()
+
+	_, err := c.kubeClient.StorageV1beta1().CSIDrivers().Get(types.DriverName, metav1.GetOptions{})
+	if err == nil {
+		return true
+	}
+	if !errors.IsNotFound(err) {
+		logger.Warnf("registration check get csidriver(%s) error = %s", types.DriverName, err.Error())
+		return false
+	}
+
+	attachRequired := false
+	driver := &storagev1beta1.CSIDriver{
+		ObjectMeta: metav1.ObjectMeta{Name: types.DriverName},
+		Spec:       storagev1beta1.CSIDriverSpec{AttachRequired: &attachRequired},
+	}
+	if _, err := c.kubeClient.StorageV1beta1().CSIDrivers().Create(driver); err != nil && !errors.IsAlreadyExists(err) {
+		logger.Warnf("registration check recreate csidriver(%s) error = %s", types.DriverName, err.Error())
+		return false
+	}
+	logger.Warnf("registration check recreated the missing csidriver(%s) object", types.DriverName)
+	return true
+}
+
+// checkCSINode alerts when kubelet has not recorded the driver on the
+// CSINode of this node, that entry only kubelet can repair.
+func (c *registrationChecker) checkCSINode() bool {
+	logger := logging.GetLogger()
+
+	csiNode, err := c.kubeClient.StorageV1beta1().CSINodes().Get(c.nodeID, metav1.GetOptions{})
+	if err != nil {
+		logger.Warnf("registration check get csinode(%s) error = %s", c.nodeID, err.Error())
+		return false
+	}
+	for _, driver := range csiNode.Spec.Drivers {
+		if driver.Name == types.DriverName {
+			return true
+		}
+	}
+	logger.Warnf("registration check :: csinode(%s) does not list driver(%s), kubelet has not completed plugin registration, provisioning on this node is broken",
+		c.nodeID, types.DriverName)
+	return false
+}
+
+// checkTopologyLabel repairs the topology label the scheduler and the pv
+// node affinities select nodes by.
+func (c *registrationChecker) checkTopologyLabel() bool {
+	logger := logging.GetLogger()
+
+	node, err := c.kubeClient.CoreV1().Nodes().Get(c.nodeID, metav1.GetOptions{})
+	if err != nil {
+		logger.Warnf("registration check get node(%s) error = %s", c.nodeID, err.Error())
+		return false
+	}
+	if node.Labels[types.TopologyNodeKey] == c.nodeID {
+		return true
+	}
+
+	copynode := node.DeepCopy()
+	if copynode.Labels == nil {
+		copynode.Labels = map[string]string{}
+	}
+	copynode.Labels[types.TopologyNodeKey] = c.nodeID
+	if _, err := c.kubeClient.CoreV1().Nodes().Update(copynode); err != nil {
+		logger.Warnf("registration check repair topology label of node(%s) error = %s", c.nodeID, err.Error())
+		return false
+	}
+	logger.Warnf("registration check repaired the topology label of node(%s)", c.nodeID)
+	return true
+}
+
+// checkRegistrationSocket alerts when the registrar sidecar has not
+// exposed the registration socket kubelet watches for.
+func (c *registrationChecker) checkRegistrationSocket() bool {
+	path := filepath.Join(*kubeletRootDir, "plugins_registry", types.DriverName+"-reg.sock")
+	if utils.IsFileExisting(path) {
+		return true
+	}
+	logging.GetLogger().Warnf("registration check :: registration socket(%s) is missing, is the registrar sidecar running?", path)
+	return false
+}
@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"context"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	kubeclient "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/client"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/informers/core/v1/namespace"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/informers/core/v1/persistentvolumeclaim"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/informers/storage/v1/storageclass"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/controller"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+)
+
+// NewSCDefault defaults claims without a storage class to the local
+// volume class the namespace names in its default-storage-class label,
+// teams get local storage by default without touching the cluster wide
+// default class.
+func NewSCDefault(
+	ctx context.Context,
+) *controller.Impl {
+	logger := logging.FromContext(ctx)
+	pvcInformer := persistentvolumeclaim.Get(ctx)
+
+	r := &SCDefaultReconciler{
+		kubeClient:      kubeclient.Get(ctx),
+		pvcLister:       pvcInformer.Lister(),
+		namespaceLister: namespace.Get(ctx).Lister(),
+		scLister:        storageclass.Get(ctx).Lister(),
+	}
+
+	impl := controller.NewImpl(r, logger, SCDefaultReconcilerName)
+
+	pvcInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: classlessClaimFilter,
+		Handler:    controller.HandleAll(impl.Enqueue),
+	})
+
+	logger.Info("SC Default Controller Started")
+	return impl
+}
+
+// classlessClaimFilter passes unbound claims that name no storage class
+// of their own, only those are candidates for the namespace default.
+func classlessClaimFilter(obj interface{}) bool {
+	pvc, ok := obj.(*v1.PersistentVolumeClaim)
+	if !ok {
+		return false
+	}
+	if pvc.Spec.VolumeName != "" {
+		return false
+	}
+	return claimStorageClass(pvc) == ""
+}
+
+// claimStorageClass returns the class of a claim the way the pv
+// controller resolves it, the beta annotation wins over the spec field.
+func claimStorageClass(pvc *v1.PersistentVolumeClaim) string {
+	if class, ok := pvc.Annotations[v1.BetaStorageClassAnnotation]; ok {
+		return class
+	}
+	if pvc.Spec.StorageClassName != nil {
+		return *pvc.Spec.StorageClassName
+	}
+	return ""
+}
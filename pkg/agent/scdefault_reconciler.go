@@ -0,0 +1,87 @@
+package agent
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes"
+	listerv1 "k8s.io/client-go/listers/core/v1"
+	storagelisters "k8s.io/client-go/listers/storage/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"k8s.io/api/core/v1"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+)
+
+const (
+	// ReconcilerName is the name of the reconciler
+	SCDefaultReconcilerName = "SCDefault"
+)
+
+type SCDefaultReconciler struct {
+	kubeClient      kubernetes.Interface
+	pvcLister       listerv1.PersistentVolumeClaimLister
+	namespaceLister listerv1.NamespaceLister
+	scLister        storagelisters.StorageClassLister
+}
+
+func (r *SCDefaultReconciler) Reconcile(ctx context.Context, key string) error {
+	logger := logging.FromContext(ctx)
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logger.Errorw("Invalid resource key", zap.Error(err))
+		return nil
+	}
+
+	pvc, err := r.pvcLister.PersistentVolumeClaims(namespace).Get(name)
+	if err != nil && errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if pvc.Spec.VolumeName != "" || claimStorageClass(pvc) != "" {
+		return nil
+	}
+
+	ns, err := r.namespaceLister.Get(namespace)
+	if err != nil {
+		return err
+	}
+	className := ns.Labels[types.DefaultStorageClassLabel]
+	if className == "" {
+		return nil
+	}
+
+	// only a class of this driver may be defaulted here, anything else on
+	// the label is a configuration mistake worth surfacing
+	sc, err := r.scLister.Get(className)
+	if err != nil && errors.IsNotFound(err) {
+		logger.Warnf("namespace(%s) defaults to storage class(%s) which does not exist", namespace, className)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if sc.Provisioner != types.DriverName {
+		logger.Warnf("namespace(%s) defaults to storage class(%s) of provisioner(%s), only local volume classes may be defaulted",
+			namespace, className, sc.Provisioner)
+		return nil
+	}
+
+	// the spec class field is immutable once the claim exists, the beta
+	// annotation is not and the pv controller resolves it the same way
+	clone := pvc.DeepCopy()
+	if clone.Annotations == nil {
+		clone.Annotations = map[string]string{}
+	}
+	clone.Annotations[v1.BetaStorageClassAnnotation] = className
+	if _, err := r.kubeClient.CoreV1().PersistentVolumeClaims(namespace).Update(clone); err != nil {
+		return err
+	}
+
+	logger.Infof("SC Default Controller defaulted claim %s/%s to storage class(%s) of its namespace", namespace, name, className)
+	return nil
+}
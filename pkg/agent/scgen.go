@@ -0,0 +1,63 @@
+package agent
+
+import (
+	"context"
+	"flag"
+
+	"k8s.io/client-go/tools/cache"
+
+	kubeclient "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/client"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/informers/core/v1/node"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/informers/storage/v1/storageclass"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/controller"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+var autoCreateStorageClasses = flag.Bool("auto-create-storage-classes", false,
+	"Create one local volume StorageClass per device class the agents discover, large heterogeneous fleets skip the manual class setup")
+
+// NewSCGeneration creates a storage class per device class the node
+// labels report across the fleet, a new nvme nodegroup joining the
+// cluster gets its local-volume-nvme class without an administrator in
+// the loop. Existing classes are never touched.
+func NewSCGeneration(
+	ctx context.Context,
+) *controller.Impl {
+	logger := logging.FromContext(ctx)
+	nodeInformer := node.Get(ctx)
+
+	r := &SCGenerationReconciler{
+		kubeClient: kubeclient.Get(ctx),
+		nodeLister: nodeInformer.Lister(),
+		scLister:   storageclass.Get(ctx).Lister(),
+	}
+
+	impl := controller.NewImpl(r, logger, SCGenerationReconcilerName)
+
+	if !*autoCreateStorageClasses {
+		logger.Info("SC Generation Controller disabled, set -auto-create-storage-classes to enable it")
+		return impl
+	}
+
+	nodeInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: classedNodeFilter,
+		Handler:    controller.HandleAll(impl.Enqueue),
+	})
+
+	logger.Info("SC Generation Controller Started")
+	return impl
+}
+
+// classedNodeFilter passes nodes whose agent has published a usable
+// device class label.
+func classedNodeFilter(obj interface{}) bool {
+	n, ok := obj.(*corev1.Node)
+	if !ok {
+		return false
+	}
+	class := n.Labels[types.NodeLabelDeviceClass]
+	return class != "" && class != "unknown"
+}
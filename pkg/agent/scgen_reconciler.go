@@ -0,0 +1,89 @@
+package agent
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	listerv1 "k8s.io/client-go/listers/core/v1"
+	storagelisters "k8s.io/client-go/listers/storage/v1"
+	"k8s.io/client-go/tools/cache"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+)
+
+const (
+	// ReconcilerName is the name of the reconciler
+	SCGenerationReconcilerName = "SCGeneration"
+
+	// GeneratedSCPrefix prefixes the auto created storage classes, the
+	// device class completes the name.
+	GeneratedSCPrefix = "local-volume-"
+)
+
+type SCGenerationReconciler struct {
+	kubeClient kubernetes.Interface
+	nodeLister listerv1.NodeLister
+	scLister   storagelisters.StorageClassLister
+}
+
+func (r *SCGenerationReconciler) Reconcile(ctx context.Context, key string) error {
+	logger := logging.FromContext(ctx)
+
+	_, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logger.Errorw("Invalid resource key", zap.Error(err))
+		return nil
+	}
+
+	node, err := r.nodeLister.Get(name)
+	if err != nil && errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	class := node.Labels[types.NodeLabelDeviceClass]
+	if class == "" || class == "unknown" {
+		return nil
+	}
+
+	scName := GeneratedSCPrefix + class
+	if _, err := r.scLister.Get(scName); err == nil {
+		return nil
+	} else if !errors.IsNotFound(err) {
+		return err
+	}
+
+	// local binding always waits for the consumer pod, the annotation
+	// records which device class the generated class stands for
+	reclaim := corev1.PersistentVolumeReclaimDelete
+	binding := storagev1.VolumeBindingWaitForFirstConsumer
+	expansion := true
+	sc := &storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: scName,
+			Annotations: map[string]string{
+				types.NodeLabelDeviceClass: class,
+			},
+		},
+		Provisioner:          types.DriverName,
+		Parameters:           map[string]string{"fsType": "ext4"},
+		ReclaimPolicy:        &reclaim,
+		VolumeBindingMode:    &binding,
+		AllowVolumeExpansion: &expansion,
+	}
+	if _, err := r.kubeClient.StorageV1().StorageClasses().Create(sc); err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+
+	logger.Infof("SC Generation Controller created storage class(%s) for device class(%s) discovered on node(%s)",
+		scName, class, name)
+	return nil
+}
@@ -0,0 +1,66 @@
+package agent
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/apis/storage/v1alpha1"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/utils"
+)
+
+// shutdown runs as the shutdown hook of the agent. It lets in-flight
+// lvm commands finish within the grace, checkpoints the capacity one
+// last time so a write the coalescer still held back is not lost, and
+// marks the node AgentReady=False so the scheduler stops placing pods
+// until the restarted agent heartbeats again.
+func (r *AgentReconciler) shutdown(ctx context.Context) {
+	logger := logging.GetLogger()
+
+	// killing an lvcreate or mkfs halfway leaves metadata the restart
+	// has to repair, so those drain first
+	grace := 10 * time.Second
+	if deadline, ok := ctx.Deadline(); ok {
+		grace = time.Until(deadline)
+	}
+	if !utils.WaitIdle(grace) {
+		logger.Warn("lvm commands still in flight after the shutdown grace, exiting anyway")
+	}
+
+	lv, err := r.client.LocalV1alpha1().LocalVolumes(v1.NamespaceDefault).Get(r.nodeID, metav1.GetOptions{})
+	if err != nil {
+		logger.Warnf("shutdown get local volume(%s) error = %s", r.nodeID, err.Error())
+		return
+	}
+
+	// flush the capacity state the coalescer may still hold back, the
+	// forget drops the write interval for this final pass
+	r.coalescer.forget(lv.Name)
+	if err := r.reconciler(lv.DeepCopy()); err != nil {
+		logger.Warnf("shutdown capacity checkpoint of node(%s) error = %s", lv.Name, err.Error())
+	}
+
+	if err := r.setAgentReady(v1alpha1.ConditionFalse, "ShuttingDown",
+		"the agent received a termination signal"); err != nil {
+		logger.Warnf("shutdown mark node(%s) not ready error = %s", r.nodeID, err.Error())
+	}
+}
+
+// setAgentReady update the AgentReady condition of this node.
+func (r *AgentReconciler) setAgentReady(condStatus v1alpha1.ConditionStatus, reason, message string) error {
+	lv, err := r.client.LocalV1alpha1().LocalVolumes(v1.NamespaceDefault).Get(r.nodeID, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	copylv := lv.DeepCopy()
+	if !setLocalVolumeCondition(&copylv.Status, v1alpha1.LocalVolumeConditionAgentReady, condStatus, reason, message) {
+		return nil
+	}
+
+	_, err = r.client.LocalV1alpha1().LocalVolumes(v1.NamespaceDefault).UpdateStatus(copylv)
+	return err
+}
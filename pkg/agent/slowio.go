@@ -0,0 +1,132 @@
+package agent
+
+import (
+	"context"
+	"flag"
+	"time"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/apis/storage/v1alpha1"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/clientset/versioned"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/lvm"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/metrics"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+)
+
+var (
+	slowIOProbeInterval = flag.Duration("slow-io-probe-interval", 30*time.Second, "Interval of the io latency probe on the vg devices")
+	slowIOThreshold     = flag.Duration("slow-io-threshold", 500*time.Millisecond, "Probe latency above this threshold marks the node disk as slow")
+)
+
+const (
+	// probe reads one 4KiB block with O_DIRECT, small enough to be
+	// harmless and large enough to hit the disk.
+	slowIOProbeSize = 4096
+)
+
+// slowIODetector run periodic lightweight io latency probes on the
+// devices of the vg and publish a SlowDisk condition on the LocalVolume
+// resource, so the scheduler can deprioritize nodes with failing disks.
+type slowIODetector struct {
+	nodeID    string
+	client    versioned.Interface
+	threshold time.Duration
+}
+
+func newSlowIODetector(nodeID string, client versioned.Interface) *slowIODetector {
+	return &slowIODetector{
+		nodeID:    nodeID,
+		client:    client,
+		threshold: *slowIOThreshold,
+	}
+}
+
+func (d *slowIODetector) run(ctx context.Context) {
+	wait.Until(d.probe, *slowIOProbeInterval, ctx.Done())
+}
+
+func (d *slowIODetector) probe() {
+	logger := logging.GetLogger()
+
+	devices := lvm.GetVGDevices(types.VGName)
+	if len(devices) == 0 {
+		return
+	}
+
+	slow := false
+	worst := time.Duration(0)
+	for _, device := range devices {
+		latency, err := probeDeviceRead(device)
+		if err != nil {
+			logger.Errorf("slow io probe device(%s) error = %s", device, err.Error())
+			continue
+		}
+		metrics.RecordIOProbe(device, latency, latency > d.threshold)
+		if latency > worst {
+			worst = latency
+		}
+		if latency > d.threshold {
+			slow = true
+			logger.Warnf("slow io probe device(%s) latency(%v) exceeds threshold(%v)", device, latency, d.threshold)
+		}
+	}
+
+	if err := d.updateCondition(slow, worst); err != nil {
+		logger.Errorf("slow io update condition error = %s", err.Error())
+	}
+}
+
+func (d *slowIODetector) updateCondition(slow bool, worst time.Duration) error {
+	lv, err := d.client.LocalV1alpha1().LocalVolumes(v1.NamespaceDefault).Get(d.nodeID, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	status := v1alpha1.ConditionFalse
+	reason := "ProbeLatencyNormal"
+	if slow {
+		status = v1alpha1.ConditionTrue
+		reason = "ProbeLatencyHigh"
+	}
+
+	copylv := lv.DeepCopy()
+	if !setLocalVolumeCondition(&copylv.Status, v1alpha1.LocalVolumeConditionSlowDisk, status, reason,
+		"worst probe latency "+worst.String()) {
+		return nil
+	}
+
+	_, err = d.client.LocalV1alpha1().LocalVolumes(v1.NamespaceDefault).UpdateStatus(copylv)
+	return err
+}
+
+// setLocalVolumeCondition update one condition in status, it returns
+// false when nothing changed.
+func setLocalVolumeCondition(status *v1alpha1.LocalVolumeStatus, condType v1alpha1.LocalVolumeConditionType,
+	condStatus v1alpha1.ConditionStatus, reason, message string) bool {
+	for i := range status.Conditions {
+		if status.Conditions[i].Type != condType {
+			continue
+		}
+		if status.Conditions[i].Status == condStatus {
+			return false
+		}
+		status.Conditions[i].Status = condStatus
+		status.Conditions[i].LastTransitionTime = metav1.Now()
+		status.Conditions[i].Reason = reason
+		status.Conditions[i].Message = message
+		return true
+	}
+
+	status.Conditions = append(status.Conditions, v1alpha1.LocalVolumeCondition{
+		Type:               condType,
+		Status:             condStatus,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	})
+	return true
+}
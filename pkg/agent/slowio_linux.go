@@ -0,0 +1,29 @@
+package agent
+
+import (
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// probeDeviceRead read one aligned block from device with O_DIRECT and
+// report how long it took.
+func probeDeviceRead(device string) (time.Duration, error) {
+	fd, err := syscall.Open(device, syscall.O_RDONLY|syscall.O_DIRECT, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer syscall.Close(fd)
+
+	// O_DIRECT needs a block aligned buffer
+	raw := make([]byte, slowIOProbeSize*2)
+	offset := slowIOProbeSize - int(uintptr(unsafe.Pointer(&raw[0]))%slowIOProbeSize)
+	buf := raw[offset : offset+slowIOProbeSize]
+
+	start := time.Now()
+	_, err = syscall.Read(fd, buf)
+	if err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
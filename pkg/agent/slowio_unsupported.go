@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package agent
+
+import (
+	"errors"
+	"time"
+)
+
+// probeDeviceRead needs O_DIRECT, the probe only runs on linux nodes.
+func probeDeviceRead(device string) (time.Duration, error) {
+	return 0, errors.New("slow io probe is only supported on linux")
+}
@@ -0,0 +1,49 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/injection/client"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/injection/informers/storage/v1alpha1/snapshotpolicy"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/informers/core/v1/persistentvolume"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/informers/core/v1/persistentvolumeclaim"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/controller"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/features"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+)
+
+// NewSnapshotPolicy serves the SnapshotPolicy objects: on every firing
+// of the cron schedule the agent snapshots the matching claims on its
+// node and prunes snapshots beyond the retention, so local volumes get
+// automated point in time protection without external tooling. The
+// controller only acts when the Snapshots feature gate is on.
+func NewSnapshotPolicy(
+	ctx context.Context,
+) *controller.Impl {
+	logger := logging.FromContext(ctx)
+	policyInformer := snapshotpolicy.Get(ctx)
+	pvcInformer := persistentvolumeclaim.Get(ctx)
+	pvInformer := persistentvolume.Get(ctx)
+
+	r := &SnapshotPolicyReconciler{
+		nodeID:       *nodeID,
+		client:       client.Get(ctx),
+		policyLister: policyInformer.Lister(),
+		pvcLister:    pvcInformer.Lister(),
+		pvLister:     pvInformer.Lister(),
+		snapshotSize: *backupSnapshotSize,
+	}
+
+	impl := controller.NewImpl(r, logger, SnapshotPolicyReconcilerName)
+	r.enqueueAfter = impl.EnqueueKeyAfter
+
+	if !features.Enabled(features.Snapshots) {
+		logger.Infof("Snapshot Policy Controller disabled, enable the %s feature gate to serve snapshot policies", features.Snapshots)
+		return impl
+	}
+
+	policyInformer.Informer().AddEventHandler(controller.HandleAll(impl.Enqueue))
+
+	logger.Info("Snapshot Policy Controller Started")
+	return impl
+}
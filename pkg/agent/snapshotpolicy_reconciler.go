@@ -0,0 +1,196 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	listerv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/apis/storage/v1alpha1"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/clientset/versioned"
+	nlvslisters "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/listers/storage/v1alpha1"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/lvm"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+)
+
+const (
+	// ReconcilerName is the name of the reconciler
+	SnapshotPolicyReconcilerName = "SnapshotPolicy"
+
+	// scheduledSnapshotPrefix marks the lvs the policy controller owns,
+	// the backup session snapshots use their own prefix.
+	scheduledSnapshotPrefix = "sched-"
+)
+
+type SnapshotPolicyReconciler struct {
+	nodeID       string
+	client       versioned.Interface
+	policyLister nlvslisters.SnapshotPolicyLister
+	pvcLister    listerv1.PersistentVolumeClaimLister
+	pvLister     listerv1.PersistentVolumeLister
+	snapshotSize uint64
+	enqueueAfter func(key k8stypes.NamespacedName, delay time.Duration)
+}
+
+func (r *SnapshotPolicyReconciler) Reconcile(ctx context.Context, key string) error {
+	logger := logging.FromContext(ctx)
+
+	if *dryRun {
+		logger.Infof("dry-run :: snapshot policy controller would process %s, skipping", key)
+		return nil
+	}
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logger.Errorw("Invalid resource key", zap.Error(err))
+		return nil
+	}
+
+	policy, err := r.policyLister.SnapshotPolicies(namespace).Get(name)
+	if err != nil && errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	schedule, err := cron.ParseStandard(policy.Spec.Schedule)
+	if err != nil {
+		return r.updateStatus(policy, fmt.Sprintf("invalid schedule %q: %s", policy.Spec.Schedule, err.Error()))
+	}
+
+	claims, err := r.matchingClaims(policy)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	fired := false
+	for _, pvc := range claims {
+		volumeID := pvc.Spec.VolumeName
+		if volumeID == "" || !r.volumeOnMyNode(volumeID) {
+			continue
+		}
+		taken := scheduledSnapshots(volumeID)
+		if !r.due(policy, schedule, taken, now) {
+			continue
+		}
+		snapName := fmt.Sprintf("%s%s-%d", scheduledSnapshotPrefix, volumeID, now.Unix())
+		if err := lvm.CreateSnapshot(types.VGName, volumeID, snapName, r.snapshotSize); err != nil {
+			logger.Errorf("Snapshot Policy Controller snapshot claim %s/%s error = %s", pvc.Namespace, pvc.Name, err.Error())
+			continue
+		}
+		logger.Infof("Snapshot Policy Controller snapshot %s of claim %s/%s taken by policy %s", snapName, pvc.Namespace, pvc.Name, policy.Name)
+		fired = true
+		r.prune(volumeID, policy.Spec.Retention)
+	}
+
+	if fired {
+		clone := policy.DeepCopy()
+		firedAt := metav1.NewTime(now)
+		clone.Status.LastScheduleTime = &firedAt
+		clone.Status.Message = ""
+		if _, err := r.client.LocalV1alpha1().SnapshotPolicies(clone.Namespace).UpdateStatus(clone); err != nil {
+			return err
+		}
+	}
+
+	// wake up for the next firing, the due check makes early wakeups a
+	// no-op
+	if r.enqueueAfter != nil {
+		r.enqueueAfter(k8stypes.NamespacedName{Namespace: namespace, Name: name}, time.Until(schedule.Next(now))+time.Second)
+	}
+	return nil
+}
+
+// matchingClaims returns the claims of the namespace the selector of
+// the policy matches, a policy without selector matches none.
+func (r *SnapshotPolicyReconciler) matchingClaims(policy *v1alpha1.SnapshotPolicy) ([]*corev1.PersistentVolumeClaim, error) {
+	if policy.Spec.Selector == nil {
+		return nil, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(policy.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	return r.pvcLister.PersistentVolumeClaims(policy.Namespace).List(selector)
+}
+
+func (r *SnapshotPolicyReconciler) volumeOnMyNode(volumeID string) bool {
+	pv, err := r.pvLister.Get(volumeID)
+	if err != nil {
+		return false
+	}
+	return types.IsPVInMyNode(pv, r.nodeID)
+}
+
+// due reports whether the schedule fired since the newest snapshot of
+// the volume, a volume without snapshots measures from the creation of
+// the policy. Deciding per volume keeps the agents of different nodes
+// independent.
+func (r *SnapshotPolicyReconciler) due(policy *v1alpha1.SnapshotPolicy, schedule cron.Schedule, taken []int64, now time.Time) bool {
+	last := policy.CreationTimestamp.Time
+	if len(taken) > 0 {
+		last = time.Unix(taken[len(taken)-1], 0)
+	}
+	return !schedule.Next(last).After(now)
+}
+
+// prune drops the oldest scheduled snapshots of the volume beyond the
+// retention, a retention of zero keeps the newest one.
+func (r *SnapshotPolicyReconciler) prune(volumeID string, retention int32) {
+	keep := int(retention)
+	if keep < 1 {
+		keep = 1
+	}
+	taken := scheduledSnapshots(volumeID)
+	for len(taken) > keep {
+		snapName := fmt.Sprintf("%s%s-%d", scheduledSnapshotPrefix, volumeID, taken[0])
+		if err := lvm.RemoveSnapshot(types.VGName, snapName); err != nil {
+			logging.GetLogger().Errorf("Snapshot Policy Controller prune snapshot %s error = %s", snapName, err.Error())
+			return
+		}
+		logging.GetLogger().Infof("Snapshot Policy Controller pruned snapshot %s", snapName)
+		taken = taken[1:]
+	}
+}
+
+// scheduledSnapshots returns the firing times of the scheduled
+// snapshots of the volume, oldest first.
+func scheduledSnapshots(volumeID string) []int64 {
+	prefix := scheduledSnapshotPrefix + volumeID + "-"
+	var taken []int64
+	for _, name := range lvm.ListLVs(types.VGName) {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		firedAt, err := strconv.ParseInt(strings.TrimPrefix(name, prefix), 10, 64)
+		if err != nil {
+			continue
+		}
+		taken = append(taken, firedAt)
+	}
+	sort.Slice(taken, func(i, j int) bool { return taken[i] < taken[j] })
+	return taken
+}
+
+func (r *SnapshotPolicyReconciler) updateStatus(policy *v1alpha1.SnapshotPolicy, message string) error {
+	if policy.Status.Message == message {
+		return nil
+	}
+	clone := policy.DeepCopy()
+	clone.Status.Message = message
+	_, err := r.client.LocalV1alpha1().SnapshotPolicies(clone.Namespace).UpdateStatus(clone)
+	return err
+}
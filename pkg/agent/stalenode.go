@@ -0,0 +1,46 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/injection/client"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/injection/informers/storage/v1alpha1/localvolume"
+	kubeclient "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/client"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/informers/core/v1/node"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/informers/core/v1/persistentvolume"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/controller"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/events"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+)
+
+// NewStaleNodeCleanup removes the bookkeeping a deleted Node leaves
+// behind: its LocalVolume with the outstanding reservations and the pvs
+// pinned to it, respecting their reclaim policy. Long-lived clusters
+// must not accumulate dead entries.
+func NewStaleNodeCleanup(
+	ctx context.Context,
+) *controller.Impl {
+	logger := logging.FromContext(ctx)
+	nodeInformer := node.Get(ctx)
+	lvInformer := localvolume.Get(ctx)
+	pvInformer := persistentvolume.Get(ctx)
+
+	r := &StaleNodeCleanupReconciler{
+		nodeLister: nodeInformer.Lister(),
+		lvLister:   lvInformer.Lister(),
+		pvLister:   pvInformer.Lister(),
+		lvClient:   client.Get(ctx),
+		kubeClient: kubeclient.Get(ctx),
+		recorder:   events.NewDedupRecorder(events.NewRecorder(kubeclient.Get(ctx), StaleNodeCleanupReconcilerName), events.DefaultDedupWindow),
+	}
+
+	impl := controller.NewImpl(r, logger, StaleNodeCleanupReconcilerName)
+
+	// a node delete triggers the cleanup, the LocalVolume events cover
+	// bookkeeping of nodes that disappeared while no controller ran
+	nodeInformer.Informer().AddEventHandler(controller.HandleAll(impl.Enqueue))
+	lvInformer.Informer().AddEventHandler(controller.HandleAll(impl.Enqueue))
+
+	logger.Info("Stale Node Cleanup Controller Started")
+	return impl
+}
@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	listerv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/clientset/versioned"
+	nlvslisters "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/listers/storage/v1alpha1"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/events"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+)
+
+const (
+	// ReconcilerName is the name of the reconciler
+	StaleNodeCleanupReconcilerName = "StaleNodeCleanup"
+)
+
+type StaleNodeCleanupReconciler struct {
+	nodeLister listerv1.NodeLister
+	lvLister   nlvslisters.LocalVolumeLister
+	pvLister   listerv1.PersistentVolumeLister
+	lvClient   versioned.Interface
+	kubeClient kubernetes.Interface
+	recorder   *events.DedupRecorder
+}
+
+func (r *StaleNodeCleanupReconciler) Reconcile(ctx context.Context, key string) error {
+	logger := logging.FromContext(ctx)
+
+	_, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logger.Errorw("Invalid resource key", zap.Error(err))
+		return nil
+	}
+
+	// a node that still exists keeps all its bookkeeping
+	if _, err := r.nodeLister.Get(name); err == nil {
+		return nil
+	} else if !errors.IsNotFound(err) {
+		return err
+	}
+
+	return r.cleanup(ctx, name)
+}
+
+// cleanup drops the LocalVolume of the gone node, its reservations go
+// with it, and handles the pvs pinned to the node by reclaim policy:
+// Delete removes the pv object, the backing lv died with the node,
+// Retain keeps it for manual recovery or re-adoption.
+func (r *StaleNodeCleanupReconciler) cleanup(ctx context.Context, nodeName string) error {
+	logger := logging.FromContext(ctx)
+
+	if _, err := r.lvLister.LocalVolumes(corev1.NamespaceDefault).Get(nodeName); err == nil {
+		if err := r.lvClient.LocalV1alpha1().LocalVolumes(corev1.NamespaceDefault).Delete(nodeName, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		logger.Infof("deleted local volume of gone node(%s)", nodeName)
+	} else if !errors.IsNotFound(err) {
+		return err
+	}
+
+	pvs, err := r.pvLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, pv := range pvs {
+		if !types.IsPVInMyNode(pv, nodeName) {
+			continue
+		}
+
+		if pv.Spec.PersistentVolumeReclaimPolicy == corev1.PersistentVolumeReclaimRetain {
+			r.recorder.Eventf(pv, corev1.EventTypeWarning, "NodeGone",
+				"node %s of this retained local volume was deleted, the pv is kept for manual recovery", nodeName)
+			continue
+		}
+
+		if err := r.kubeClient.CoreV1().PersistentVolumes().Delete(pv.Name, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		logger.Infof("deleted orphaned pv(%s) of gone node(%s)", pv.Name, nodeName)
+	}
+	return nil
+}
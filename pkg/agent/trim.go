@@ -0,0 +1,55 @@
+package agent
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/lvm"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/utils"
+)
+
+var (
+	fstrimInterval = flag.Duration("fstrim-interval", 0,
+		"Interval of the periodic fstrim over the mounted lvs of the vg, 0 disables it. Keeps ssd backed vgs trimmed for volumes mounted without the discard option")
+)
+
+// trimmer periodically runs fstrim on every mounted lv of the vg. A
+// filesystem mounted without the discard option never tells the ssd
+// which blocks are free, the scheduled trim does it in one batch per
+// interval which is cheaper than continuous discards on write-heavy
+// volumes.
+type trimmer struct{}
+
+func newTrimmer() *trimmer {
+	return &trimmer{}
+}
+
+func (t *trimmer) run(ctx context.Context) {
+	wait.Until(t.trim, *fstrimInterval, ctx.Done())
+}
+
+func (t *trimmer) trim() {
+	logger := logging.GetLogger()
+
+	for _, lv := range lvm.ListLVs(types.VGName) {
+		mountpoint, err := volumeMountpoint(lv)
+		if err != nil {
+			// warm pool lvs and released volumes are not mounted,
+			// nothing to trim there
+			continue
+		}
+		start := time.Now()
+		cmd := fmt.Sprintf("%s fstrim %s", types.NsenterCmd, mountpoint)
+		if _, err := utils.Run(cmd); err != nil {
+			logger.Warnf("fstrim of lv(%s) at %s error = %s", lv, mountpoint, err.Error())
+			continue
+		}
+		logger.Infof("fstrim of lv(%s) at %s done in %v", lv, mountpoint, time.Since(start))
+	}
+}
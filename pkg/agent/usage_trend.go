@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/apis/storage/v1alpha1"
+)
+
+const (
+	// maxUsageSamples is the size of the sample ring kept in status.
+	maxUsageSamples = 48
+	// usageSampleInterval is the minimum distance between two samples.
+	usageSampleInterval = 30 * time.Minute
+)
+
+// recordUsageSample append the current free size to the sample ring in
+// status, it returns true when a sample was added.
+func recordUsageSample(status *v1alpha1.LocalVolumeStatus, freeSize uint64) bool {
+	now := metav1.Now()
+
+	if n := len(status.UsageSamples); n > 0 {
+		last := status.UsageSamples[n-1]
+		if now.Time.Sub(last.Time.Time) < usageSampleInterval {
+			return false
+		}
+	}
+
+	status.UsageSamples = append(status.UsageSamples, v1alpha1.UsageSample{
+		Time:     now,
+		FreeSize: freeSize,
+	})
+	if len(status.UsageSamples) > maxUsageSamples {
+		status.UsageSamples = status.UsageSamples[len(status.UsageSamples)-maxUsageSamples:]
+	}
+	return true
+}
+
+// projectDaysToFull estimate in how many days the vg runs full based on
+// the recorded samples. It returns -1 when usage is not shrinking or not
+// enough samples exist.
+func projectDaysToFull(samples []v1alpha1.UsageSample) float64 {
+	if len(samples) < 2 {
+		return -1
+	}
+
+	first := samples[0]
+	last := samples[len(samples)-1]
+
+	elapsed := last.Time.Time.Sub(first.Time.Time)
+	if elapsed <= 0 || last.FreeSize >= first.FreeSize {
+		return -1
+	}
+
+	consumedPerDay := float64(first.FreeSize-last.FreeSize) / (elapsed.Hours() / 24)
+	if consumedPerDay <= 0 {
+		return -1
+	}
+	return float64(last.FreeSize) / consumedPerDay
+}
@@ -0,0 +1,62 @@
+package agent
+
+import (
+	"context"
+	"flag"
+	"time"
+
+	"k8s.io/client-go/tools/cache"
+
+	kubeclient "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/client"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/informers/core/v1/persistentvolume"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/informers/core/v1/persistentvolumeclaim"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/controller"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/events"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+)
+
+var (
+	usageAlertPercent = flag.Uint64("usage-alert-percent", 80,
+		"Filesystem usage percent above which a claim is marked nearly full, 0 disables the alerts")
+	usageProbeInterval = flag.Duration("usage-probe-interval", time.Minute,
+		"Interval between filesystem usage probes of the local volumes on the node")
+)
+
+// NewUsageAlert watches the filesystem usage of the local volumes on
+// the node: a claim crossing the threshold gets the usage-alert
+// annotation and a warning event, both go away when the usage drops, so
+// applications and operators learn about nearly full volumes before
+// writes fail.
+func NewUsageAlert(
+	ctx context.Context,
+) *controller.Impl {
+	logger := logging.FromContext(ctx)
+	pvcInformer := persistentvolumeclaim.Get(ctx)
+	pvInformer := persistentvolume.Get(ctx)
+
+	r := &UsageAlertReconciler{
+		nodeID:        *nodeID,
+		kubeClient:    kubeclient.Get(ctx),
+		pvcLister:     pvcInformer.Lister(),
+		pvLister:      pvInformer.Lister(),
+		alertPercent:  *usageAlertPercent,
+		probeInterval: *usageProbeInterval,
+		recorder:      events.NewDedupRecorder(events.NewRecorder(kubeclient.Get(ctx), UsageAlertReconcilerName), events.DefaultDedupWindow),
+	}
+
+	impl := controller.NewImpl(r, logger, UsageAlertReconcilerName)
+	r.enqueueAfter = impl.EnqueueKeyAfter
+
+	if r.alertPercent == 0 {
+		logger.Info("Usage Alert Controller disabled, set -usage-alert-percent to enable the alerts")
+		return impl
+	}
+
+	pvcInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: boundClaimFilter,
+		Handler:    controller.HandleAll(impl.Enqueue),
+	})
+
+	logger.Info("Usage Alert Controller Started")
+	return impl
+}
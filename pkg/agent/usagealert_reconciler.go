@@ -0,0 +1,174 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	listerv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/events"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/utils"
+)
+
+const (
+	// ReconcilerName is the name of the reconciler
+	UsageAlertReconcilerName = "UsageAlert"
+)
+
+type UsageAlertReconciler struct {
+	nodeID        string
+	kubeClient    kubernetes.Interface
+	pvcLister     listerv1.PersistentVolumeClaimLister
+	pvLister      listerv1.PersistentVolumeLister
+	alertPercent  uint64
+	probeInterval time.Duration
+	recorder      *events.DedupRecorder
+	enqueueAfter  func(key k8stypes.NamespacedName, delay time.Duration)
+}
+
+func (r *UsageAlertReconciler) Reconcile(ctx context.Context, key string) error {
+	logger := logging.FromContext(ctx)
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logger.Errorw("Invalid resource key", zap.Error(err))
+		return nil
+	}
+
+	pvc, err := r.pvcLister.PersistentVolumeClaims(namespace).Get(name)
+	if err != nil && errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// only the agent on the node of the volume probes it
+	volumeID := pvc.Spec.VolumeName
+	if volumeID == "" {
+		return nil
+	}
+	pv, err := r.pvLister.Get(volumeID)
+	if err != nil && errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if !types.IsPVInMyNode(pv, r.nodeID) {
+		return nil
+	}
+
+	percent, err := volumeUsagePercent(volumeID)
+	if err != nil {
+		// an unmounted volume has no filesystem usage yet, probe again
+		// later
+		logger.Debugf("Usage Alert Controller probe volume %s: %s", volumeID, err.Error())
+		r.requeue(namespace, name)
+		return nil
+	}
+
+	if err := r.setAlert(pvc, percent); err != nil {
+		return err
+	}
+	r.requeue(namespace, name)
+	return nil
+}
+
+func (r *UsageAlertReconciler) requeue(namespace, name string) {
+	if r.enqueueAfter != nil {
+		r.enqueueAfter(k8stypes.NamespacedName{Namespace: namespace, Name: name}, r.probeInterval)
+	}
+}
+
+// setAlert keeps the alert annotation of the claim in line with the
+// usage, events go out on the transitions.
+func (r *UsageAlertReconciler) setAlert(pvc *corev1.PersistentVolumeClaim, percent uint64) error {
+	current, alerted := pvc.Annotations[types.UsageAlertAnnotation]
+	value := strconv.FormatUint(percent, 10)
+
+	if percent >= r.alertPercent {
+		if alerted && current == value {
+			return nil
+		}
+		clone := pvc.DeepCopy()
+		if clone.Annotations == nil {
+			clone.Annotations = make(map[string]string)
+		}
+		clone.Annotations[types.UsageAlertAnnotation] = value
+		if _, err := r.kubeClient.CoreV1().PersistentVolumeClaims(clone.Namespace).Update(clone); err != nil {
+			return err
+		}
+		if !alerted {
+			r.recorder.Eventf(pvc, corev1.EventTypeWarning, "VolumeNearlyFull",
+				"Filesystem of volume %s is %d%% full, threshold is %d%%", pvc.Spec.VolumeName, percent, r.alertPercent)
+			logging.GetLogger().Warnf("Usage Alert Controller claim %s/%s is %d%% full", pvc.Namespace, pvc.Name, percent)
+		}
+		return nil
+	}
+
+	if !alerted {
+		return nil
+	}
+	clone := pvc.DeepCopy()
+	delete(clone.Annotations, types.UsageAlertAnnotation)
+	if _, err := r.kubeClient.CoreV1().PersistentVolumeClaims(clone.Namespace).Update(clone); err != nil {
+		return err
+	}
+	r.recorder.Eventf(pvc, corev1.EventTypeNormal, "VolumeUsageNormal",
+		"Filesystem of volume %s dropped to %d%% full", pvc.Spec.VolumeName, percent)
+	return nil
+}
+
+// volumeUsage probes the filesystem of the lv on the node, the same
+// numbers NodeGetVolumeStats reports to the kubelet.
+func volumeUsage(volumeID string) (size, used uint64, err error) {
+	mountpoint, err := volumeMountpoint(volumeID)
+	if err != nil {
+		return 0, 0, err
+	}
+	out, err := utils.Run(fmt.Sprintf("%s df -B1 --output=size,used %s | tail -1", types.NsenterCmd, mountpoint))
+	if err != nil {
+		return 0, 0, fmt.Errorf("probe filesystem of volume %s failed: %s", volumeID, err.Error())
+	}
+	fields := strings.Fields(out)
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("unexpected df output of volume %s: %q", volumeID, out)
+	}
+	if size, err = strconv.ParseUint(fields[0], 10, 64); err != nil {
+		return 0, 0, err
+	}
+	if used, err = strconv.ParseUint(fields[1], 10, 64); err != nil {
+		return 0, 0, err
+	}
+	if size == 0 {
+		return 0, 0, fmt.Errorf("filesystem of volume %s reports zero size", volumeID)
+	}
+	return size, used, nil
+}
+
+func volumeUsagePercent(volumeID string) (uint64, error) {
+	size, used, err := volumeUsage(volumeID)
+	if err != nil {
+		return 0, err
+	}
+	return used * 100 / size, nil
+}
+
+// boundClaimFilter selects the claims that are bound to a volume.
+func boundClaimFilter(obj interface{}) bool {
+	pvc, ok := obj.(*corev1.PersistentVolumeClaim)
+	if !ok {
+		return false
+	}
+	return pvc.Spec.VolumeName != ""
+}
@@ -0,0 +1,165 @@
+package agent
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/lvm"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/utils"
+)
+
+var (
+	warmPoolSpec = flag.String("warm-pool", "",
+		"Comma separated sizeGB:count pairs of pre-provisioned formatted lvs, e.g. 10:2,50:1, empty disables the pool")
+	warmPoolInterval = flag.Duration("warm-pool-interval", time.Minute, "Interval of the warm pool reconciliation")
+	warmPoolFsType   = flag.String("warm-pool-fstype", "ext4", "Filesystem the pooled lvs are pre-formatted with")
+)
+
+// warmPool pre-creates and pre-formats lvs of the configured sizes, the
+// driver hands one out with a rename when a volume of a matching size
+// is requested, dropping provisioning latency to the cost of a
+// lvrename.
+type warmPool struct {
+	// desired count per size in GB
+	desired map[uint64]int
+	fsType  string
+}
+
+// parseWarmPoolSpec parses the sizeGB:count pairs of the -warm-pool
+// flag, a nil result means the pool is disabled.
+func parseWarmPoolSpec(spec string) (map[uint64]int, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	desired := make(map[uint64]int)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kv := strings.SplitN(entry, ":", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("malformed warm pool entry(%s), expect sizeGB:count", entry)
+		}
+		size, err := strconv.ParseUint(strings.TrimSpace(kv[0]), 10, 64)
+		if err != nil || size == 0 {
+			return nil, fmt.Errorf("malformed warm pool size(%s)", kv[0])
+		}
+		count, err := strconv.Atoi(strings.TrimSpace(kv[1]))
+		if err != nil || count < 0 {
+			return nil, fmt.Errorf("malformed warm pool count(%s)", kv[1])
+		}
+		desired[size] = count
+	}
+	return desired, nil
+}
+
+func newWarmPool() (*warmPool, error) {
+	desired, err := parseWarmPoolSpec(*warmPoolSpec)
+	if err != nil {
+		return nil, err
+	}
+	if desired == nil {
+		return nil, nil
+	}
+	return &warmPool{desired: desired, fsType: *warmPoolFsType}, nil
+}
+
+func (p *warmPool) run(ctx context.Context) {
+	wait.Until(p.reconcile, *warmPoolInterval, ctx.Done())
+}
+
+func (p *warmPool) reconcile() {
+	logger := logging.GetLogger()
+
+	// count the existing pooled lvs per size
+	existing := make(map[uint64][]string)
+	used := make(map[string]bool)
+	for _, name := range lvm.ListLVs(types.VGName) {
+		used[name] = true
+		if size, ok := types.WarmPoolLVSize(name); ok {
+			existing[size] = append(existing[size], name)
+		}
+	}
+
+	// remove surplus lvs of sizes no longer configured
+	for size, names := range existing {
+		sort.Strings(names)
+		for len(names) > p.desired[size] {
+			name := names[len(names)-1]
+			names = names[:len(names)-1]
+			if *dryRun {
+				logger.Infof("dry-run :: warm pool would remove surplus lv(%s)", name)
+				continue
+			}
+			cmd := fmt.Sprintf("%s lvremove -f /dev/%s/%s", types.NsenterCmd, types.VGName, name)
+			if _, err := utils.Run(cmd); err != nil {
+				logger.Errorf("warm pool remove lv(%s) error = %s", name, err.Error())
+				break
+			}
+			logger.Infof("warm pool removed surplus lv(%s)", name)
+		}
+	}
+
+	// create and format the missing ones, stop when the vg runs short
+	for size, count := range p.desired {
+		missing := count - len(existing[size])
+		for i := 0; i < missing; i++ {
+			if !p.provision(size, used) {
+				return
+			}
+		}
+	}
+}
+
+// provision creates and formats one lv of the given size, it returns
+// false when the vg has no room left.
+func (p *warmPool) provision(sizeGB uint64, used map[string]bool) bool {
+	logger := logging.GetLogger()
+
+	vgInfo := lvm.GetVGInfo(types.VGName)
+	if vgInfo == nil || uint64(vgInfo.VgFree/1024) <= sizeGB {
+		logger.Infof("warm pool skip provisioning %dg lv, vg is short on space", sizeGB)
+		return false
+	}
+
+	// pick a free index for the name
+	name := ""
+	for idx := 1; ; idx++ {
+		candidate := types.MakeWarmPoolLVName(sizeGB, idx)
+		if !used[candidate] {
+			name = candidate
+			used[candidate] = true
+			break
+		}
+	}
+
+	if *dryRun {
+		logger.Infof("dry-run :: warm pool would provision and format lv(%s)", name)
+		return true
+	}
+
+	cmd := fmt.Sprintf("%s lvcreate -n %s -L %dg %s", types.NsenterCmd, name, sizeGB, types.VGName)
+	if _, err := utils.Run(cmd); err != nil {
+		logger.Errorf("warm pool create lv(%s) error = %s", name, err.Error())
+		return false
+	}
+
+	formatCmd := fmt.Sprintf("%s mkfs.%s /dev/%s/%s", types.NsenterCmd, p.fsType, types.VGName, name)
+	if _, err := utils.Run(formatCmd); err != nil {
+		logger.Errorf("warm pool format lv(%s) error = %s", name, err.Error())
+		return false
+	}
+
+	logger.Infof("warm pool provisioned lv(%s)", name)
+	return true
+}
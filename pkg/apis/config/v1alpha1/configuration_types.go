@@ -0,0 +1,101 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ConfigAPIVersion is the apiVersion a configuration file must carry.
+	ConfigAPIVersion = "config.local.volume.csi.kubernetes.io/v1alpha1"
+	// ConfigKind is the kind a configuration file must carry.
+	ConfigKind = "LocalVolumeConfiguration"
+)
+
+// LocalVolumeConfiguration configures the driver, agent and scheduler
+// binaries from one versioned YAML file passed with -config, replacing
+// the scattering of flags. Values set explicitly on the command line
+// still win over the file.
+type LocalVolumeConfiguration struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Driver configures the CSI plugin binary.
+	Driver DriverConfiguration `json:"driver,omitempty"`
+	// Agent configures the node agent binary.
+	Agent AgentConfiguration `json:"agent,omitempty"`
+	// Scheduler configures the scheduler extender binary.
+	Scheduler SchedulerConfiguration `json:"scheduler,omitempty"`
+	// ClientConnection tunes the api clients of all binaries.
+	ClientConnection ClientConnectionConfiguration `json:"clientConnection,omitempty"`
+
+	// FeatureGates toggles optional features by name.
+	FeatureGates map[string]bool `json:"featureGates,omitempty"`
+}
+
+// ClientConnectionConfiguration tunes the kubernetes api clients, zero
+// values keep the client-go defaults.
+type ClientConnectionConfiguration struct {
+	// QPS is the sustained request rate against the api server.
+	QPS float64 `json:"qps,omitempty"`
+	// Burst is the request burst allowed over QPS.
+	Burst int `json:"burst,omitempty"`
+	// Timeout bounds single api requests.
+	Timeout metav1.Duration `json:"timeout,omitempty"`
+}
+
+// DriverConfiguration configures the CSI plugin.
+type DriverConfiguration struct {
+	// Endpoint is the CSI endpoint the plugin listens on.
+	Endpoint string `json:"endpoint,omitempty"`
+	// MetricsAddress is the prometheus metrics listen address.
+	MetricsAddress string `json:"metricsAddress,omitempty"`
+}
+
+// AgentConfiguration configures the node agent.
+type AgentConfiguration struct {
+	// MetricsAddress is the prometheus metrics listen address.
+	MetricsAddress string `json:"metricsAddress,omitempty"`
+	// SlowIOProbeInterval is the interval of the slow disk read probe.
+	SlowIOProbeInterval metav1.Duration `json:"slowIOProbeInterval,omitempty"`
+	// SlowIOThreshold is the probe latency above which a disk is
+	// reported slow.
+	SlowIOThreshold metav1.Duration `json:"slowIOThreshold,omitempty"`
+}
+
+// SchedulerConfiguration configures the scheduler extender.
+type SchedulerConfiguration struct {
+	// Address is the http listen address of the extender.
+	Address string `json:"address,omitempty"`
+	// TLSCertFile and TLSKeyFile enable serving with TLS when both are
+	// set.
+	TLSCertFile string `json:"tlsCertFile,omitempty"`
+	TLSKeyFile  string `json:"tlsKeyFile,omitempty"`
+	// CaptureDir records sanitized request/response pairs when set.
+	CaptureDir string `json:"captureDir,omitempty"`
+	// CaptureRingSize is the maximum number of capture files kept.
+	CaptureRingSize int `json:"captureRingSize,omitempty"`
+	// PriorityHeadroomPercent is the percent of the vg kept free for
+	// high priority pods during capacity crunch, 0 disables it.
+	PriorityHeadroomPercent int `json:"priorityHeadroomPercent,omitempty"`
+	// PriorityHeadroomMinPriority is the pod priority at or above which
+	// a pod may consume the headroom.
+	PriorityHeadroomMinPriority int `json:"priorityHeadroomMinPriority,omitempty"`
+	// NoBind leaves binding to the default scheduler, reservations are
+	// taken from pod.spec.nodeName by a controller instead.
+	NoBind bool `json:"noBind,omitempty"`
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SetDefaults fills the unset fields with the defaults of the matching
+// command line flags.
+func SetDefaults(cfg *LocalVolumeConfiguration) {
+	if cfg.Driver.Endpoint == "" {
+		cfg.Driver.Endpoint = "unix://tmp/csi.sock"
+	}
+	if cfg.Driver.MetricsAddress == "" {
+		cfg.Driver.MetricsAddress = ":9100"
+	}
+
+	if cfg.Agent.MetricsAddress == "" {
+		cfg.Agent.MetricsAddress = ":9101"
+	}
+	if cfg.Agent.SlowIOProbeInterval.Duration == 0 {
+		cfg.Agent.SlowIOProbeInterval = metav1.Duration{Duration: 30 * time.Second}
+	}
+	if cfg.Agent.SlowIOThreshold.Duration == 0 {
+		cfg.Agent.SlowIOThreshold = metav1.Duration{Duration: 500 * time.Millisecond}
+	}
+
+	if cfg.Scheduler.Address == "" {
+		cfg.Scheduler.Address = ":80"
+	}
+	if cfg.Scheduler.CaptureRingSize == 0 {
+		cfg.Scheduler.CaptureRingSize = 100
+	}
+	if cfg.Scheduler.PriorityHeadroomMinPriority == 0 {
+		cfg.Scheduler.PriorityHeadroomMinPriority = 1000000
+	}
+}
+
+// Validate rejects configurations the binaries cannot run with, the
+// error lists every problem found, not only the first.
+func (cfg *LocalVolumeConfiguration) Validate() error {
+	problems := cfg.Problems()
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d problem(s) found:\n  - %s", len(problems), strings.Join(problems, "\n  - "))
+}
+
+// Problems collects everything wrong with the configuration, each entry
+// names the offending field and suggests a fix.
+func (cfg *LocalVolumeConfiguration) Problems() []string {
+	problems := make([]string, 0)
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if cfg.APIVersion != ConfigAPIVersion {
+		add("unsupported apiVersion(%s), set it to %s", cfg.APIVersion, ConfigAPIVersion)
+	}
+	if cfg.Kind != ConfigKind {
+		add("unsupported kind(%s), set it to %s", cfg.Kind, ConfigKind)
+	}
+
+	if cfg.Driver.Endpoint != "" && !strings.HasPrefix(cfg.Driver.Endpoint, "unix://") {
+		add("driver.endpoint(%s) is not a unix socket, kubelet talks csi over one, use a unix:// url", cfg.Driver.Endpoint)
+	}
+	if problem := listenAddressProblem("driver.metricsAddress", cfg.Driver.MetricsAddress); problem != "" {
+		add(problem)
+	}
+	if problem := listenAddressProblem("agent.metricsAddress", cfg.Agent.MetricsAddress); problem != "" {
+		add(problem)
+	}
+	if problem := listenAddressProblem("scheduler.address", cfg.Scheduler.Address); problem != "" {
+		add(problem)
+	}
+
+	if cfg.Agent.SlowIOProbeInterval.Duration < 0 {
+		add("agent.slowIOProbeInterval(%s) must not be negative", cfg.Agent.SlowIOProbeInterval.Duration)
+	}
+	if cfg.Agent.SlowIOThreshold.Duration < 0 {
+		add("agent.slowIOThreshold(%s) must not be negative", cfg.Agent.SlowIOThreshold.Duration)
+	}
+	if cfg.Scheduler.CaptureRingSize < 0 {
+		add("scheduler.captureRingSize(%d) must not be negative, 0 keeps the default of 100", cfg.Scheduler.CaptureRingSize)
+	}
+
+	if (cfg.Scheduler.TLSCertFile == "") != (cfg.Scheduler.TLSKeyFile == "") {
+		add("scheduler.tlsCertFile and scheduler.tlsKeyFile must be set together, drop both for plain http")
+	}
+	for _, file := range []struct{ field, path string }{
+		{"scheduler.tlsCertFile", cfg.Scheduler.TLSCertFile},
+		{"scheduler.tlsKeyFile", cfg.Scheduler.TLSKeyFile},
+	} {
+		if file.path == "" {
+			continue
+		}
+		if _, err := os.Stat(file.path); err != nil {
+			add("%s(%s) is not readable: %v, is the secret mounted into the pod?", file.field, file.path, err)
+		}
+	}
+
+	if cfg.Scheduler.PriorityHeadroomPercent < 0 || cfg.Scheduler.PriorityHeadroomPercent > 100 {
+		add("scheduler.priorityHeadroomPercent(%d) must be between 0 and 100", cfg.Scheduler.PriorityHeadroomPercent)
+	}
+	if cfg.ClientConnection.QPS < 0 {
+		add("clientConnection.qps(%v) must not be negative, 0 keeps the client default", cfg.ClientConnection.QPS)
+	}
+	if cfg.ClientConnection.Burst < 0 {
+		add("clientConnection.burst(%d) must not be negative, 0 keeps the client default", cfg.ClientConnection.Burst)
+	}
+	if cfg.ClientConnection.Timeout.Duration < 0 {
+		add("clientConnection.timeout(%s) must not be negative, 0 keeps the client default", cfg.ClientConnection.Timeout.Duration)
+	}
+	return problems
+}
+
+// listenAddressProblem checks a host:port listen address, the empty
+// string passes because defaulting fills it later.
+func listenAddressProblem(field, address string) string {
+	if address == "" {
+		return ""
+	}
+	_, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Sprintf("%s(%s) is not a host:port address: %v", field, address, err)
+	}
+	if number, err := strconv.Atoi(port); err != nil || number < 1 || number > 65535 {
+		return fmt.Sprintf("%s(%s) port must be a number between 1 and 65535", field, address)
+	}
+	return ""
+}
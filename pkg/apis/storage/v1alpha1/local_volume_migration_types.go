@@ -0,0 +1,64 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type LocalVolumeMigration struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	// +optional
+	Spec LocalVolumeMigrationSpec `json:"spec,omitempty"`
+	// +optional
+	Status LocalVolumeMigrationStatus `json:"status,omitempty"`
+}
+
+type LocalVolumeMigrationSpec struct {
+	// PVCName is the claim in the namespace of the migration whose data
+	// moves to a new volume.
+	PVCName string `json:"pvcName"`
+	// TargetNode receives the new volume, the agent on it drives the
+	// migration.
+	TargetNode string `json:"targetNode"`
+	// Image runs the data copy job, the default of the agent is used
+	// when empty.
+	// +optional
+	Image string `json:"image,omitempty"`
+}
+
+type MigrationPhase string
+
+const (
+	MigrationPhasePending      MigrationPhase = "Pending"
+	MigrationPhaseSnapshotting MigrationPhase = "Snapshotting"
+	MigrationPhaseCopying      MigrationPhase = "Copying"
+	MigrationPhaseRebinding    MigrationPhase = "Rebinding"
+	MigrationPhaseSucceeded    MigrationPhase = "Succeeded"
+	MigrationPhaseFailed       MigrationPhase = "Failed"
+)
+
+type LocalVolumeMigrationStatus struct {
+	// +optional
+	Phase MigrationPhase `json:"phase,omitempty"`
+	// DestPVCName is the staging claim the copy lands in, it only lives
+	// until the volume behind it is rebound to the original claim.
+	// +optional
+	DestPVCName string `json:"destPVCName,omitempty"`
+	// DestPVName is the volume on the target node that takes over the
+	// original claim name once the migration succeeded.
+	// +optional
+	DestPVName string `json:"destPVName,omitempty"`
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type LocalVolumeMigrationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []LocalVolumeMigration `json:"items"`
+}
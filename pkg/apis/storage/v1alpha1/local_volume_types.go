@@ -24,8 +24,97 @@ type LocalVolumeStatus struct {
 	TotalSize uint64 `json:"totalSize,omitempty"`
 	// +optional
 	FreeSize uint64 `json:"freeSize,omitempty"`
+	// PreAllocated maps reserved claim keys to the time of their
+	// reservation, entries outliving their ttl without a provisioned
+	// volume are expired by the scheduler.
 	// +optional
 	PreAllocated map[string]string `json:"preAllocated,omitempty"`
+	// +optional
+	Conditions []LocalVolumeCondition `json:"conditions,omitempty"`
+	// UsageSamples is a compact ring of historical free size samples
+	// used for capacity forecasting.
+	// +optional
+	UsageSamples []UsageSample `json:"usageSamples,omitempty"`
+	// Heartbeat is refreshed periodically by the node agent, a stale
+	// timestamp means the agent is down or the node is partitioned.
+	// +optional
+	Heartbeat *metav1.Time `json:"heartbeat,omitempty"`
+	// Retained maps logical volumes kept by a Retain reclaim policy to
+	// the claim they last served, they wait for re-adoption or manual
+	// removal.
+	// +optional
+	Retained map[string]string `json:"retained,omitempty"`
+	// AllocationLog is a bounded list of the most recent capacity
+	// operations on the node, support can reconstruct recent history
+	// from the object alone.
+	// +optional
+	AllocationLog []AllocationRecord `json:"allocationLog,omitempty"`
+}
+
+// AllocationLogLimit bounds the allocation log, older entries fall off.
+const AllocationLogLimit = 20
+
+// Allocation operations recorded in the log.
+const (
+	// AllocationOpReserve is a capacity reservation by the scheduler.
+	AllocationOpReserve = "reserve"
+	// AllocationOpBind is a reservation turning into a bound volume.
+	AllocationOpBind = "bind"
+	// AllocationOpRelease is a volume being reclaimed.
+	AllocationOpRelease = "release"
+)
+
+// AllocationRecord is one entry of the allocation log.
+type AllocationRecord struct {
+	// Op is the operation, one of reserve, bind and release.
+	Op string `json:"op"`
+	// PVC is the namespace/name key of the claim involved.
+	PVC string `json:"pvc"`
+	// Size is the size of the operation in GB.
+	// +optional
+	Size uint64 `json:"size,omitempty"`
+	// Time is when the operation happened.
+	// +optional
+	Time metav1.Time `json:"time,omitempty"`
+	// Actor is the component that performed the operation.
+	// +optional
+	Actor string `json:"actor,omitempty"`
+}
+
+type UsageSample struct {
+	Time metav1.Time `json:"time"`
+	// FreeSize is the free size of the vg in GB at sample time.
+	FreeSize uint64 `json:"freeSize"`
+}
+
+type LocalVolumeConditionType string
+
+const (
+	// LocalVolumeConditionSlowDisk is set when the periodic io probes of
+	// the node agent measure latencies above the threshold.
+	LocalVolumeConditionSlowDisk LocalVolumeConditionType = "SlowDisk"
+
+	// LocalVolumeConditionAgentReady is True while the node agent runs,
+	// the agent sets it False right before a clean shutdown.
+	LocalVolumeConditionAgentReady LocalVolumeConditionType = "AgentReady"
+)
+
+type ConditionStatus string
+
+const (
+	ConditionTrue  ConditionStatus = "True"
+	ConditionFalse ConditionStatus = "False"
+)
+
+type LocalVolumeCondition struct {
+	Type   LocalVolumeConditionType `json:"type"`
+	Status ConditionStatus          `json:"status"`
+	// +optional
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
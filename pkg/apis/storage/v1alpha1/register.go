@@ -31,6 +31,10 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&LocalVolume{},
 		&LocalVolumeList{},
+		&LocalVolumeMigration{},
+		&LocalVolumeMigrationList{},
+		&SnapshotPolicy{},
+		&SnapshotPolicyList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
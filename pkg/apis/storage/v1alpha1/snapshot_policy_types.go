@@ -0,0 +1,46 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type SnapshotPolicy struct {
+	metav1.TypeMeta `json:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	// +optional
+	Spec SnapshotPolicySpec `json:"spec,omitempty"`
+	// +optional
+	Status SnapshotPolicyStatus `json:"status,omitempty"`
+}
+
+type SnapshotPolicySpec struct {
+	// Schedule is a cron expression, every firing snapshots the
+	// matching claims.
+	Schedule string `json:"schedule"`
+	// Retention is the number of snapshots kept per claim, older ones
+	// are pruned after a firing. Zero keeps one snapshot.
+	// +optional
+	Retention int32 `json:"retention,omitempty"`
+	// Selector matches the claims in the namespace of the policy, a nil
+	// selector matches none.
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+}
+
+type SnapshotPolicyStatus struct {
+	// LastScheduleTime is the firing the snapshots were last taken for.
+	// +optional
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type SnapshotPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []SnapshotPolicy `json:"items"`
+}
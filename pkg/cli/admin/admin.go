@@ -0,0 +1,294 @@
+package admin
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/clientset/versioned"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/kubeconfig"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/rbac"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/utils"
+)
+
+type options struct {
+	masterURL  string
+	kubeconfig string
+}
+
+func (o *options) clients() (kubernetes.Interface, versioned.Interface, error) {
+	cfg, err := kubeconfig.GetConfig(o.masterURL, o.kubeconfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	kubeClient, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	lvClient, err := versioned.NewForConfig(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return kubeClient, lvClient, nil
+}
+
+// NewRootCommand build the admin command tree for manual volume
+// operations that otherwise need lvm/etcd surgery.
+func NewRootCommand() *cobra.Command {
+	o := &options{}
+
+	root := &cobra.Command{
+		Use:          "local-volume-admin",
+		Short:        "Manual repair operations for local volume storage",
+		SilenceUsage: true,
+	}
+	root.PersistentFlags().StringVar(&o.masterURL, "master", "", "The address of the Kubernetes API server. Overrides any value in kubeconfig. Only required if out-of-cluster.")
+	root.PersistentFlags().StringVar(&o.kubeconfig, "kubeconfig", "", "Path to a kubeconfig. Only required if out-of-cluster.")
+
+	root.AddCommand(newReleaseReservationCommand(o))
+	root.AddCommand(newDeleteLVCommand())
+	root.AddCommand(newReimportCommand(o))
+	root.AddCommand(newRescanCommand(o))
+	root.AddCommand(newAdoptCommand(o))
+	root.AddCommand(newRBACCommand())
+	return root
+}
+
+func newRBACCommand() *cobra.Command {
+	var namespace string
+	cmd := &cobra.Command{
+		Use:   "rbac [COMPONENT]",
+		Short: "Print the generated rbac manifests of one or all components",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			components := rbac.Components
+			if len(args) == 1 {
+				components = nil
+				for _, c := range rbac.Components {
+					if c.Name == args[0] {
+						components = []rbac.Component{c}
+					}
+				}
+				if components == nil {
+					names := make([]string, 0, len(rbac.Components))
+					for _, c := range rbac.Components {
+						names = append(names, c.Name)
+					}
+					return fmt.Errorf("unknown component %q, have %s", args[0], strings.Join(names, ", "))
+				}
+			}
+
+			for i, c := range components {
+				manifest, err := c.Manifest(namespace)
+				if err != nil {
+					return err
+				}
+				if i > 0 {
+					fmt.Println("---")
+					fmt.Println()
+				}
+				fmt.Print(manifest)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&namespace, "namespace", "kube-system", "Namespace the service accounts live in")
+	return cmd
+}
+
+func newReleaseReservationCommand(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "release-reservation NODE PVC-NAMESPACE/PVC-NAME",
+		Short: "Force release a stuck capacity reservation on a node",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, lvClient, err := o.clients()
+			if err != nil {
+				return err
+			}
+
+			lv, err := lvClient.LocalV1alpha1().LocalVolumes(corev1.NamespaceDefault).Get(args[0], metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			if _, ok := lv.Status.PreAllocated[args[1]]; !ok {
+				return fmt.Errorf("node(%s) has no reservation for pvc(%s)", args[0], args[1])
+			}
+
+			copylv := lv.DeepCopy()
+			delete(copylv.Status.PreAllocated, args[1])
+			if _, err := lvClient.LocalV1alpha1().LocalVolumes(corev1.NamespaceDefault).UpdateStatus(copylv); err != nil {
+				return err
+			}
+
+			fmt.Printf("released reservation of pvc(%s) on node(%s)\n", args[1], args[0])
+			return nil
+		},
+	}
+}
+
+func newDeleteLVCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete-lv LV-NAME",
+		Short: "Force delete an orphan logical volume, must run on the node owning it",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			devicePath := filepath.Join("/dev/", types.VGName, args[0])
+			removeCmd := fmt.Sprintf("%s lvremove -f %s", types.NsenterCmd, devicePath)
+			if _, err := utils.Run(removeCmd); err != nil {
+				return err
+			}
+
+			fmt.Printf("deleted logical volume %s\n", devicePath)
+			return nil
+		},
+	}
+}
+
+func newReimportCommand(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "reimport PV-NAME",
+		Short: "Put a persistent volume back under local volume management",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kubeClient, _, err := o.clients()
+			if err != nil {
+				return err
+			}
+
+			pv, err := kubeClient.CoreV1().PersistentVolumes().Get(args[0], metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+
+			copypv := pv.DeepCopy()
+			if !utils.SliceContainsString(copypv.Finalizers, types.LocalVolumeGCTag) {
+				copypv.Finalizers = append(copypv.Finalizers, types.LocalVolumeGCTag)
+			}
+			if _, err := kubeClient.CoreV1().PersistentVolumes().Update(copypv); err != nil {
+				return err
+			}
+
+			fmt.Printf("reimported pv(%s), gc finalizer restored\n", args[0])
+			return nil
+		},
+	}
+}
+
+func newAdoptCommand(o *options) *cobra.Command {
+	var size, storageClass string
+	cmd := &cobra.Command{
+		Use:   "adopt NODE LV-NAME",
+		Short: "Re-adopt a retained logical volume into a new persistent volume",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kubeClient, lvClient, err := o.clients()
+			if err != nil {
+				return err
+			}
+			nodeName, lvName := args[0], args[1]
+
+			lv, err := lvClient.LocalV1alpha1().LocalVolumes(corev1.NamespaceDefault).Get(nodeName, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			if _, ok := lv.Status.Retained[lvName]; !ok {
+				return fmt.Errorf("node(%s) has no retained volume(%s)", nodeName, lvName)
+			}
+
+			quantity, err := resource.ParseQuantity(size)
+			if err != nil {
+				return fmt.Errorf("invalid size %q: %s", size, err.Error())
+			}
+
+			mode := corev1.PersistentVolumeFilesystem
+			pv := &corev1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       lvName,
+					Finalizers: []string{types.LocalVolumeGCTag},
+				},
+				Spec: corev1.PersistentVolumeSpec{
+					Capacity:                      corev1.ResourceList{corev1.ResourceStorage: quantity},
+					AccessModes:                   []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+					PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimRetain,
+					StorageClassName:              storageClass,
+					VolumeMode:                    &mode,
+					PersistentVolumeSource: corev1.PersistentVolumeSource{
+						CSI: &corev1.CSIPersistentVolumeSource{
+							Driver:       types.DriverName,
+							VolumeHandle: lvName,
+						},
+					},
+					NodeAffinity: &corev1.VolumeNodeAffinity{
+						Required: &corev1.NodeSelector{
+							NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+								MatchExpressions: []corev1.NodeSelectorRequirement{{
+									Key:      types.TopologyNodeKey,
+									Operator: corev1.NodeSelectorOpIn,
+									Values:   []string{nodeName},
+								}},
+							}},
+						},
+					},
+				},
+			}
+			if _, err := kubeClient.CoreV1().PersistentVolumes().Create(pv); err != nil {
+				return err
+			}
+
+			// the volume is under pv management again, drop the mark
+			copylv := lv.DeepCopy()
+			delete(copylv.Status.Retained, lvName)
+			if _, err := lvClient.LocalV1alpha1().LocalVolumes(corev1.NamespaceDefault).UpdateStatus(copylv); err != nil {
+				return err
+			}
+
+			fmt.Printf("adopted retained volume(%s) of node(%s) into pv(%s)\n", lvName, nodeName, lvName)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&size, "size", "", "Capacity of the new pv, e.g. 10Gi (required)")
+	_ = cmd.MarkFlagRequired("size")
+	cmd.Flags().StringVar(&storageClass, "storage-class", types.VGName, "StorageClass of the new pv")
+	return cmd
+}
+
+func newRescanCommand(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "rescan NODE",
+		Short: "Trigger a capacity rescan of the agent on a node",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, lvClient, err := o.clients()
+			if err != nil {
+				return err
+			}
+
+			lv, err := lvClient.LocalV1alpha1().LocalVolumes(corev1.NamespaceDefault).Get(args[0], metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+
+			// touching the resource enqueues a reconcile in the agent
+			copylv := lv.DeepCopy()
+			if copylv.Annotations == nil {
+				copylv.Annotations = make(map[string]string)
+			}
+			copylv.Annotations[types.LocalVolumeRescanAnnotation] = time.Now().Format(time.RFC3339)
+			if _, err := lvClient.LocalV1alpha1().LocalVolumes(corev1.NamespaceDefault).Update(copylv); err != nil {
+				return err
+			}
+
+			fmt.Printf("rescan of node(%s) requested\n", args[0])
+			return nil
+		},
+	}
+}
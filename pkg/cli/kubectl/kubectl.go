@@ -0,0 +1,257 @@
+package kubectl
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/apis/storage/v1alpha1"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/clientset/versioned"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/kubeconfig"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+)
+
+type options struct {
+	masterURL  string
+	kubeconfig string
+	namespace  string
+}
+
+func (o *options) clients() (kubernetes.Interface, versioned.Interface, error) {
+	cfg, err := kubeconfig.GetConfig(o.masterURL, o.kubeconfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	kubeClient, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	lvClient, err := versioned.NewForConfig(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return kubeClient, lvClient, nil
+}
+
+// NewRootCommand build the kubectl local-volume plugin command tree.
+func NewRootCommand() *cobra.Command {
+	o := &options{}
+
+	root := &cobra.Command{
+		Use:          "kubectl-local_volume",
+		Short:        "Inspect and manage local volume storage",
+		SilenceUsage: true,
+	}
+	root.PersistentFlags().StringVar(&o.masterURL, "master", "", "The address of the Kubernetes API server. Overrides any value in kubeconfig. Only required if out-of-cluster.")
+	root.PersistentFlags().StringVar(&o.kubeconfig, "kubeconfig", "", "Path to a kubeconfig. Only required if out-of-cluster.")
+	root.PersistentFlags().StringVarP(&o.namespace, "namespace", "n", corev1.NamespaceDefault, "Namespace of the pod for explain")
+
+	root.AddCommand(newCapacityCommand(o))
+	root.AddCommand(newVolumesCommand(o))
+	root.AddCommand(newExplainCommand(o))
+	root.AddCommand(newCordonCommand(o, true))
+	root.AddCommand(newCordonCommand(o, false))
+	return root
+}
+
+func newCapacityCommand(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "capacity",
+		Short: "List per node local volume capacity",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, lvClient, err := o.clients()
+			if err != nil {
+				return err
+			}
+
+			lvs, err := lvClient.LocalV1alpha1().LocalVolumes(corev1.NamespaceDefault).List(metav1.ListOptions{})
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+			fmt.Fprintln(w, "NODE\tTOTAL(GB)\tFREE(GB)\tRESERVED\tCORDONED\tSLOWDISK")
+			for _, lv := range lvs.Items {
+				fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%v\t%v\n",
+					lv.Name, lv.Status.TotalSize, lv.Status.FreeSize, len(lv.Status.PreAllocated),
+					lv.Annotations[types.LocalVolumeCordonAnnotation] == "true", isSlowDisk(&lv))
+			}
+			return w.Flush()
+		},
+	}
+}
+
+func newVolumesCommand(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "volumes",
+		Short: "Show which pvc consumes which local volume on which node",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kubeClient, _, err := o.clients()
+			if err != nil {
+				return err
+			}
+
+			pvs, err := kubeClient.CoreV1().PersistentVolumes().List(metav1.ListOptions{})
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+			fmt.Fprintln(w, "PV\tNODE\tPVC\tCAPACITY\tPHASE")
+			for _, pv := range pvs.Items {
+				if pv.Spec.CSI == nil || pv.Spec.CSI.Driver != types.DriverName {
+					continue
+				}
+				claim := ""
+				if pv.Spec.ClaimRef != nil {
+					claim = types.MakePVCKey(pv.Spec.ClaimRef.Namespace, pv.Spec.ClaimRef.Name)
+				}
+				capacity := pv.Spec.Capacity[corev1.ResourceStorage]
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+					pv.Name, pvNodeName(&pv), claim, capacity.String(), pv.Status.Phase)
+			}
+			return w.Flush()
+		},
+	}
+}
+
+func newExplainCommand(o *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "explain POD",
+		Short: "Explain why a pending pod fits or does not fit each node",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			kubeClient, lvClient, err := o.clients()
+			if err != nil {
+				return err
+			}
+
+			pod, err := kubeClient.CoreV1().Pods(o.namespace).Get(args[0], metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			requestSize := podLocalVolumeRequestSize(kubeClient, pod)
+
+			lvs, err := lvClient.LocalV1alpha1().LocalVolumes(corev1.NamespaceDefault).List(metav1.ListOptions{})
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("pod %s/%s requests %dGB local volume storage\n", pod.Namespace, pod.Name, requestSize)
+			w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+			fmt.Fprintln(w, "NODE\tFREE(GB)\tFITS\tREASON")
+			for _, lv := range lvs.Items {
+				fits, reason := explainNode(&lv, requestSize)
+				fmt.Fprintf(w, "%s\t%d\t%v\t%s\n", lv.Name, lv.Status.FreeSize, fits, reason)
+			}
+			return w.Flush()
+		},
+	}
+}
+
+func newCordonCommand(o *options, cordon bool) *cobra.Command {
+	use, short := "cordon NODE", "Mark the local volume storage of a node as unschedulable"
+	if !cordon {
+		use, short = "uncordon NODE", "Mark the local volume storage of a node as schedulable again"
+	}
+	return &cobra.Command{
+		Use:   use,
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, lvClient, err := o.clients()
+			if err != nil {
+				return err
+			}
+
+			lv, err := lvClient.LocalV1alpha1().LocalVolumes(corev1.NamespaceDefault).Get(args[0], metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+
+			copylv := lv.DeepCopy()
+			if copylv.Annotations == nil {
+				copylv.Annotations = make(map[string]string)
+			}
+			if cordon {
+				copylv.Annotations[types.LocalVolumeCordonAnnotation] = "true"
+			} else {
+				delete(copylv.Annotations, types.LocalVolumeCordonAnnotation)
+			}
+			if _, err := lvClient.LocalV1alpha1().LocalVolumes(corev1.NamespaceDefault).Update(copylv); err != nil {
+				return err
+			}
+
+			if cordon {
+				fmt.Printf("node %s local volume storage cordoned\n", args[0])
+			} else {
+				fmt.Printf("node %s local volume storage uncordoned\n", args[0])
+			}
+			return nil
+		},
+	}
+}
+
+func explainNode(lv *v1alpha1.LocalVolume, requestSize uint64) (bool, string) {
+	if lv.Annotations[types.LocalVolumeCordonAnnotation] == "true" {
+		return false, "storage is cordoned"
+	}
+	if isSlowDisk(lv) {
+		return false, "disk is marked slow"
+	}
+	if lv.Status.FreeSize < requestSize {
+		return false, fmt.Sprintf("free size %dGB < request %dGB", lv.Status.FreeSize, requestSize)
+	}
+	return true, ""
+}
+
+func isSlowDisk(lv *v1alpha1.LocalVolume) bool {
+	for _, condition := range lv.Status.Conditions {
+		if condition.Type == v1alpha1.LocalVolumeConditionSlowDisk && condition.Status == v1alpha1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+func pvNodeName(pv *corev1.PersistentVolume) string {
+	if pv.Spec.NodeAffinity == nil || pv.Spec.NodeAffinity.Required == nil {
+		return ""
+	}
+	for _, term := range pv.Spec.NodeAffinity.Required.NodeSelectorTerms {
+		for _, expression := range term.MatchExpressions {
+			if expression.Key == types.TopologyNodeKey && len(expression.Values) > 0 {
+				return expression.Values[0]
+			}
+		}
+	}
+	return ""
+}
+
+func podLocalVolumeRequestSize(kubeClient kubernetes.Interface, pod *corev1.Pod) uint64 {
+	var result uint64
+
+	for _, volume := range pod.Spec.Volumes {
+		if volume.PersistentVolumeClaim == nil {
+			continue
+		}
+		pvc, err := kubeClient.CoreV1().PersistentVolumeClaims(pod.Namespace).Get(volume.PersistentVolumeClaim.ClaimName, metav1.GetOptions{})
+		if err != nil || pvc.Spec.StorageClassName == nil {
+			continue
+		}
+		sc, err := kubeClient.StorageV1().StorageClasses().Get(*pvc.Spec.StorageClassName, metav1.GetOptions{})
+		if err != nil || sc.Provisioner != types.DriverName {
+			continue
+		}
+		if size, ok := pvc.Spec.Resources.Requests[corev1.ResourceStorage]; ok {
+			result += uint64(math.Ceil(float64(size.Value()) / 1024 / 1024 / 1024))
+		}
+	}
+	return result
+}
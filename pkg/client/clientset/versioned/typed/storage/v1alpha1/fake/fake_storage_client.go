@@ -32,6 +32,14 @@ func (c *FakeLocalV1alpha1) LocalVolumes(namespace string) v1alpha1.LocalVolumeI
 	return &FakeLocalVolumes{c, namespace}
 }
 
+func (c *FakeLocalV1alpha1) LocalVolumeMigrations(namespace string) v1alpha1.LocalVolumeMigrationInterface {
+	return &FakeLocalVolumeMigrations{c, namespace}
+}
+
+func (c *FakeLocalV1alpha1) SnapshotPolicies(namespace string) v1alpha1.SnapshotPolicyInterface {
+	return &FakeSnapshotPolicies{c, namespace}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeLocalV1alpha1) RESTClient() rest.Interface {
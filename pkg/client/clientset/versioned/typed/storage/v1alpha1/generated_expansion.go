@@ -19,3 +19,7 @@ limitations under the License.
 package v1alpha1
 
 type LocalVolumeExpansion interface{}
+
+type LocalVolumeMigrationExpansion interface{}
+
+type SnapshotPolicyExpansion interface{}
@@ -27,6 +27,8 @@ import (
 type LocalV1alpha1Interface interface {
 	RESTClient() rest.Interface
 	LocalVolumesGetter
+	LocalVolumeMigrationsGetter
+	SnapshotPoliciesGetter
 }
 
 // LocalV1alpha1Client is used to interact with features provided by the local.volume.storage group.
@@ -38,6 +40,14 @@ func (c *LocalV1alpha1Client) LocalVolumes(namespace string) LocalVolumeInterfac
 	return newLocalVolumes(c, namespace)
 }
 
+func (c *LocalV1alpha1Client) LocalVolumeMigrations(namespace string) LocalVolumeMigrationInterface {
+	return newLocalVolumeMigrations(c, namespace)
+}
+
+func (c *LocalV1alpha1Client) SnapshotPolicies(namespace string) SnapshotPolicyInterface {
+	return newSnapshotPolicies(c, namespace)
+}
+
 // NewForConfig creates a new LocalV1alpha1Client for the given config.
 func NewForConfig(c *rest.Config) (*LocalV1alpha1Client, error) {
 	config := *c
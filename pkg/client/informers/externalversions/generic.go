@@ -55,6 +55,10 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 	// Group=local.volume.storage, Version=v1alpha1
 	case v1alpha1.SchemeGroupVersion.WithResource("localvolumes"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Local().V1alpha1().LocalVolumes().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("localvolumemigrations"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Local().V1alpha1().LocalVolumeMigrations().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("snapshotpolicies"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Local().V1alpha1().SnapshotPolicies().Informer()}, nil
 
 	}
 
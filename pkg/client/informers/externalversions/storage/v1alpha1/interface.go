@@ -26,6 +26,10 @@ import (
 type Interface interface {
 	// LocalVolumes returns a LocalVolumeInformer.
 	LocalVolumes() LocalVolumeInformer
+	// LocalVolumeMigrations returns a LocalVolumeMigrationInformer.
+	LocalVolumeMigrations() LocalVolumeMigrationInformer
+	// SnapshotPolicies returns a SnapshotPolicyInformer.
+	SnapshotPolicies() SnapshotPolicyInformer
 }
 
 type version struct {
@@ -43,3 +47,13 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 func (v *version) LocalVolumes() LocalVolumeInformer {
 	return &localVolumeInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
+
+// LocalVolumeMigrations returns a LocalVolumeMigrationInformer.
+func (v *version) LocalVolumeMigrations() LocalVolumeMigrationInformer {
+	return &localVolumeMigrationInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// SnapshotPolicies returns a SnapshotPolicyInformer.
+func (v *version) SnapshotPolicies() SnapshotPolicyInformer {
+	return &snapshotPolicyInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
@@ -0,0 +1,70 @@
+package factory
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/apis/storage/v1alpha1"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/clientset/versioned"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/injection"
+)
+
+func init() {
+	// Registered after withInformerFactory of this package, so the
+	// factory already sits on the context when this one runs.
+	injection.Default.RegisterInformerFactory(withScopedInformers)
+}
+
+// withScopedInformers seeds the shared factory with our own LocalVolume
+// informer: the bootstrap list is paginated, and a selector the binary
+// attached with injection.WithInformerSelector narrows what is cached
+// at all, e.g. the agent only caching the LocalVolume of its own node.
+// Seeding must happen before any controller asks the factory for the
+// informer, the first construction wins.
+func withScopedInformers(ctx context.Context) context.Context {
+	f := Get(ctx)
+	namespace := metav1.NamespaceAll
+	if injection.HasNamespaceScope(ctx) {
+		namespace = injection.GetNamespaceScope(ctx)
+	}
+	selector, _ := injection.GetInformerSelector(ctx, "localvolumes")
+	bookmarks, haveBookmarks := injection.GetWatchBookmarks(ctx, "localvolumes")
+
+	f.InformerFor(&v1alpha1.LocalVolume{}, func(c versioned.Interface, resync time.Duration) cache.SharedIndexInformer {
+		if period, ok := injection.GetInformerResync(ctx, "localvolumes"); ok {
+			resync = period
+		}
+		lw := &cache.ListWatch{
+			ListFunc: injection.PaginatedListFunc(func(options metav1.ListOptions) (runtime.Object, error) {
+				applySelector(&options, selector)
+				return c.LocalV1alpha1().LocalVolumes(namespace).List(options)
+			}),
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				applySelector(&options, selector)
+				if haveBookmarks {
+					options.AllowWatchBookmarks = bookmarks
+				}
+				return c.LocalV1alpha1().LocalVolumes(namespace).Watch(options)
+			},
+		}
+		return cache.NewSharedIndexInformer(lw, &v1alpha1.LocalVolume{}, resync,
+			cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	})
+	return ctx
+}
+
+// applySelector narrows the list and watch options to the selector the
+// binary attached to the resource, empty selectors change nothing.
+func applySelector(options *metav1.ListOptions, selector injection.InformerSelector) {
+	if selector.LabelSelector != "" {
+		options.LabelSelector = selector.LabelSelector
+	}
+	if selector.FieldSelector != "" {
+		options.FieldSelector = selector.FieldSelector
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"context"
 
 	injection "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/injection"
+	kubeconfig "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/kubeconfig"
 	logging "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
 	kubernetes "k8s.io/client-go/kubernetes"
 	rest "k8s.io/client-go/rest"
@@ -35,7 +36,9 @@ func init() {
 type Key struct{}
 
 func withClient(ctx context.Context, cfg *rest.Config) context.Context {
-	return context.WithValue(ctx, Key{}, kubernetes.NewForConfigOrDie(cfg))
+	// Built-in types all have protobuf codecs, the crd client keeps the
+	// plain json config.
+	return context.WithValue(ctx, Key{}, kubernetes.NewForConfigOrDie(kubeconfig.WithProtobuf(cfg)))
 }
 
 // Get extracts the kubernetes.Interface client from the context.
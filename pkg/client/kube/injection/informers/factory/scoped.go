@@ -0,0 +1,104 @@
+package factory
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/injection"
+)
+
+func init() {
+	// Registered after withInformerFactory of this package, so the
+	// factory already sits on the context when this one runs.
+	injection.Default.RegisterInformerFactory(withScopedInformers)
+}
+
+// withScopedInformers seeds the shared factory with our own informers
+// for the high-volume core types: the bootstrap list is paginated, and
+// a selector the binary attached with injection.WithInformerSelector
+// narrows what is cached at all. Seeding must happen before any
+// controller asks the factory for the informer, the first construction
+// wins.
+func withScopedInformers(ctx context.Context) context.Context {
+	f := Get(ctx)
+	namespace := metav1.NamespaceAll
+	if injection.HasNamespaceScope(ctx) {
+		namespace = injection.GetNamespaceScope(ctx)
+	}
+
+	seed := func(obj runtime.Object, resource string,
+		list func(kubernetes.Interface, metav1.ListOptions) (runtime.Object, error),
+		watcher func(kubernetes.Interface, metav1.ListOptions) (watch.Interface, error)) {
+		selector, _ := injection.GetInformerSelector(ctx, resource)
+		bookmarks, haveBookmarks := injection.GetWatchBookmarks(ctx, resource)
+		f.InformerFor(obj, func(c kubernetes.Interface, resync time.Duration) cache.SharedIndexInformer {
+			if period, ok := injection.GetInformerResync(ctx, resource); ok {
+				resync = period
+			}
+			lw := &cache.ListWatch{
+				ListFunc: injection.PaginatedListFunc(func(options metav1.ListOptions) (runtime.Object, error) {
+					applySelector(&options, selector)
+					return list(c, options)
+				}),
+				WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+					applySelector(&options, selector)
+					if haveBookmarks {
+						options.AllowWatchBookmarks = bookmarks
+					}
+					return watcher(c, options)
+				},
+			}
+			return cache.NewSharedIndexInformer(lw, obj, resync,
+				cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+		})
+	}
+
+	seed(&corev1.Pod{}, "pods",
+		func(c kubernetes.Interface, options metav1.ListOptions) (runtime.Object, error) {
+			return c.CoreV1().Pods(namespace).List(options)
+		},
+		func(c kubernetes.Interface, options metav1.ListOptions) (watch.Interface, error) {
+			return c.CoreV1().Pods(namespace).Watch(options)
+		})
+	seed(&corev1.PersistentVolumeClaim{}, "persistentvolumeclaims",
+		func(c kubernetes.Interface, options metav1.ListOptions) (runtime.Object, error) {
+			return c.CoreV1().PersistentVolumeClaims(namespace).List(options)
+		},
+		func(c kubernetes.Interface, options metav1.ListOptions) (watch.Interface, error) {
+			return c.CoreV1().PersistentVolumeClaims(namespace).Watch(options)
+		})
+	seed(&corev1.PersistentVolume{}, "persistentvolumes",
+		func(c kubernetes.Interface, options metav1.ListOptions) (runtime.Object, error) {
+			return c.CoreV1().PersistentVolumes().List(options)
+		},
+		func(c kubernetes.Interface, options metav1.ListOptions) (watch.Interface, error) {
+			return c.CoreV1().PersistentVolumes().Watch(options)
+		})
+	seed(&corev1.Node{}, "nodes",
+		func(c kubernetes.Interface, options metav1.ListOptions) (runtime.Object, error) {
+			return c.CoreV1().Nodes().List(options)
+		},
+		func(c kubernetes.Interface, options metav1.ListOptions) (watch.Interface, error) {
+			return c.CoreV1().Nodes().Watch(options)
+		})
+
+	return ctx
+}
+
+// applySelector narrows the list and watch options to the selector the
+// binary attached to the resource, empty selectors change nothing.
+func applySelector(options *metav1.ListOptions, selector injection.InformerSelector) {
+	if selector.LabelSelector != "" {
+		options.LabelSelector = selector.LabelSelector
+	}
+	if selector.FieldSelector != "" {
+		options.FieldSelector = selector.FieldSelector
+	}
+}
@@ -25,3 +25,19 @@ type LocalVolumeListerExpansion interface{}
 // LocalVolumeNamespaceListerExpansion allows custom methods to be added to
 // LocalVolumeNamespaceLister.
 type LocalVolumeNamespaceListerExpansion interface{}
+
+// LocalVolumeMigrationListerExpansion allows custom methods to be added to
+// LocalVolumeMigrationLister.
+type LocalVolumeMigrationListerExpansion interface{}
+
+// LocalVolumeMigrationNamespaceListerExpansion allows custom methods to be added to
+// LocalVolumeMigrationNamespaceLister.
+type LocalVolumeMigrationNamespaceListerExpansion interface{}
+
+// SnapshotPolicyListerExpansion allows custom methods to be added to
+// SnapshotPolicyLister.
+type SnapshotPolicyListerExpansion interface{}
+
+// SnapshotPolicyNamespaceListerExpansion allows custom methods to be added to
+// SnapshotPolicyNamespaceLister.
+type SnapshotPolicyNamespaceListerExpansion interface{}
@@ -0,0 +1,55 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package chaos injects failures into the node backend so recovery paths
+// (rollback, GC, retries) can be tested deterministically. The hooks are
+// compiled in only with the chaos build tag (BUILD_TAGS=chaos hack/build.sh),
+// in regular builds they are no-ops.
+//
+// Rules come from the LOCAL_VOLUME_CHAOS environment variable or, when
+// LOCAL_VOLUME_CHAOS_FILE points at a file (typically a mounted ConfigMap
+// key), from that file, reloaded on change. The spec is a comma separated
+// list of op=mode entries:
+//
+//	lvcreate=fail           every lvcreate fails before running
+//	mount=delay:3s          every mount is delayed by 3s
+//	lvremove=partial        lvremove runs but is reported failed
+//	lvextend=fail:0.5       half of the lvextend calls fail
+package chaos
+
+const (
+	// ChaosEnv holds the rule spec.
+	ChaosEnv = "LOCAL_VOLUME_CHAOS"
+	// ChaosFileEnv points at a file holding the rule spec.
+	ChaosFileEnv = "LOCAL_VOLUME_CHAOS_FILE"
+)
+
+// Operations the hooks are wired into.
+const (
+	OpLVCreate = "lvcreate"
+	OpLVRemove = "lvremove"
+	OpLVExtend = "lvextend"
+	OpFormat   = "format"
+	OpMount    = "mount"
+	OpUnmount  = "unmount"
+)
+
+// Rule modes.
+const (
+	ModeFail    = "fail"
+	ModeDelay   = "delay"
+	ModePartial = "partial"
+)
@@ -0,0 +1,30 @@
+//go:build !chaos
+// +build !chaos
+
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaos
+
+// Hook is a no-op without the chaos build tag.
+func Hook(op string) error {
+	return nil
+}
+
+// HookResult is a no-op without the chaos build tag.
+func HookResult(op string, err error) error {
+	return err
+}
@@ -0,0 +1,167 @@
+//go:build chaos
+// +build chaos
+
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chaos
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+)
+
+type rule struct {
+	mode  string
+	prob  float64
+	delay time.Duration
+}
+
+var (
+	mu       sync.Mutex
+	rules    map[string]rule
+	fileMod  time.Time
+	envShown bool
+)
+
+// Hook runs the configured rule for op before the real operation, it
+// returns an error when the operation must fail and sleeps for delay
+// rules. Callers run the real operation when it returns nil.
+func Hook(op string) error {
+	r, ok := lookup(op)
+	if !ok {
+		return nil
+	}
+	switch r.mode {
+	case ModeFail:
+		if hit(r.prob) {
+			logging.GetLogger().Warnf("chaos: injecting %s failure", op)
+			return fmt.Errorf("chaos: injected %s failure", op)
+		}
+	case ModeDelay:
+		if hit(r.prob) {
+			logging.GetLogger().Warnf("chaos: delaying %s by %s", op, r.delay)
+			time.Sleep(r.delay)
+		}
+	}
+	return nil
+}
+
+// HookResult overrides the outcome of op after it ran, partial rules turn
+// a success into a reported failure so retry paths see a half done
+// operation.
+func HookResult(op string, err error) error {
+	if err != nil {
+		return err
+	}
+	if r, ok := lookup(op); ok && r.mode == ModePartial && hit(r.prob) {
+		logging.GetLogger().Warnf("chaos: reporting %s as failed after it ran", op)
+		return fmt.Errorf("chaos: injected partial %s, the operation ran but is reported failed", op)
+	}
+	return err
+}
+
+func hit(prob float64) bool {
+	return prob >= 1 || rand.Float64() < prob
+}
+
+func lookup(op string) (rule, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	reload()
+	r, ok := rules[op]
+	return r, ok
+}
+
+// reload parses the spec from the file (on change) or the environment
+// (once).
+func reload() {
+	if path := os.Getenv(ChaosFileEnv); path != "" {
+		info, err := os.Stat(path)
+		if err != nil {
+			rules = nil
+			return
+		}
+		if info.ModTime().Equal(fileMod) && rules != nil {
+			return
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			rules = nil
+			return
+		}
+		fileMod = info.ModTime()
+		rules = parse(strings.TrimSpace(string(data)))
+		return
+	}
+
+	if !envShown {
+		envShown = true
+		rules = parse(os.Getenv(ChaosEnv))
+	}
+}
+
+func parse(spec string) map[string]rule {
+	result := make(map[string]rule)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kv := strings.SplitN(entry, "=", 2)
+		if len(kv) != 2 {
+			logging.GetLogger().Warnf("chaos: ignoring malformed rule(%s)", entry)
+			continue
+		}
+		parts := strings.SplitN(kv[1], ":", 2)
+		r := rule{mode: parts[0], prob: 1}
+		switch r.mode {
+		case ModeFail, ModePartial:
+			if len(parts) == 2 {
+				prob, err := strconv.ParseFloat(parts[1], 64)
+				if err != nil {
+					logging.GetLogger().Warnf("chaos: ignoring rule(%s) with bad probability", entry)
+					continue
+				}
+				r.prob = prob
+			}
+		case ModeDelay:
+			if len(parts) != 2 {
+				logging.GetLogger().Warnf("chaos: ignoring delay rule(%s) without duration", entry)
+				continue
+			}
+			delay, err := time.ParseDuration(parts[1])
+			if err != nil {
+				logging.GetLogger().Warnf("chaos: ignoring rule(%s) with bad duration", entry)
+				continue
+			}
+			r.delay = delay
+		default:
+			logging.GetLogger().Warnf("chaos: ignoring rule(%s) with unknown mode", entry)
+			continue
+		}
+		result[strings.TrimSpace(kv[0])] = r
+	}
+	return result
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package config loads the versioned LocalVolumeConfiguration file and
+// applies it over the command line flags of a binary.
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/apis/config/v1alpha1"
+)
+
+var validateOnly = flag.Bool("validate-only", false, "Check the configuration file, report every problem found and exit, for ci pipelines")
+
+// Load reads, defaults and validates the configuration file at path.
+// With -validate-only the process exits after reporting the findings,
+// with status 0 only when the file is clean.
+func Load(path string) (*v1alpha1.LocalVolumeConfiguration, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if *validateOnly {
+			fmt.Fprintf(os.Stderr, "config %s: %v\n", path, err)
+			os.Exit(1)
+		}
+		return nil, err
+	}
+
+	cfg := &v1alpha1.LocalVolumeConfiguration{}
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		if *validateOnly {
+			fmt.Fprintf(os.Stderr, "config %s does not parse: %v\n", path, err)
+			os.Exit(1)
+		}
+		return nil, fmt.Errorf("parse config(%s) error: %v", path, err)
+	}
+
+	if *validateOnly {
+		problems := cfg.Problems()
+		if len(problems) == 0 {
+			fmt.Printf("config %s: ok\n", path)
+			os.Exit(0)
+		}
+		fmt.Fprintf(os.Stderr, "config %s has %d problem(s):\n", path, len(problems))
+		for _, problem := range problems {
+			fmt.Fprintf(os.Stderr, "  - %s\n", problem)
+		}
+		os.Exit(1)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config(%s): %v", path, err)
+	}
+	v1alpha1.SetDefaults(cfg)
+	return cfg, nil
+}
+
+// Apply sets the flag called name to value. Empty values, unknown flags
+// and flags set explicitly on the command line are left alone, explicit
+// flags win over the file. Call it after flag.Parse.
+func Apply(name, value string) {
+	if value == "" || flag.Lookup(name) == nil {
+		return
+	}
+	explicit := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			explicit = true
+		}
+	})
+	if explicit {
+		return
+	}
+	_ = flag.Set(name, value)
+}
+
+// ApplyClientConnection maps the clientConnection section onto the
+// client flags of the kubeconfig package, every binary shares it.
+func ApplyClientConnection(cfg *v1alpha1.LocalVolumeConfiguration) {
+	if cfg.ClientConnection.QPS > 0 {
+		Apply("kube-api-qps", strconv.FormatFloat(cfg.ClientConnection.QPS, 'f', -1, 64))
+	}
+	if cfg.ClientConnection.Burst > 0 {
+		Apply("kube-api-burst", strconv.Itoa(cfg.ClientConnection.Burst))
+	}
+	if cfg.ClientConnection.Timeout.Duration > 0 {
+		Apply("kube-api-timeout", cfg.ClientConnection.Timeout.Duration.String())
+	}
+}
@@ -2,6 +2,7 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -17,6 +18,7 @@ import (
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/debug"
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
 )
 
@@ -34,6 +36,11 @@ var (
 	// may adjust this process-wide default.  For finer control, invoke
 	// Run on the controller directly.
 	DefaultThreadsPerController = 2
+
+	// DefaultResyncFreshness is how recently a key must have reconciled
+	// successfully for GlobalResync to skip it.  Controller binaries may
+	// adjust this process-wide default, zero re-enqueues every key.
+	DefaultResyncFreshness = time.Minute
 )
 
 // Reconciler is the interface that controller implementations are expected
@@ -42,6 +49,27 @@ type Reconciler interface {
 	Reconcile(ctx context.Context, key string) error
 }
 
+// BatchReconciler is optionally implemented by Reconcilers of high-churn
+// resources. The controller then hands over all currently queued keys
+// sharing one batch group in a single call, e.g. all pvc reservations of
+// one node, so the reconcile can answer with one status write instead
+// of n.
+type BatchReconciler interface {
+	Reconciler
+
+	// BatchOf returns the group a key batches under, keys with equal
+	// groups reconcile together.
+	BatchOf(key string) string
+
+	// ReconcileBatch reconciles all given keys of one group at once. An
+	// error requeues the whole batch key by key.
+	ReconcileBatch(ctx context.Context, keys []string) error
+}
+
+// DefaultMaxBatchSize caps how many keys one ReconcileBatch call may
+// receive. Controller binaries may adjust this process-wide default.
+var DefaultMaxBatchSize = 64
+
 // PassNew makes it simple to create an UpdateFunc for use with
 // cache.ResourceEventHandlerFuncs that can delegate the same methods
 // as AddFunc/DeleteFunc but passing through only the second argument
@@ -121,6 +149,30 @@ type Impl struct {
 	// performance benefits, raw logger also preserves type-safety at
 	// the expense of slightly greater verbosity.
 	logger *zap.SugaredLogger
+
+	// traces holds originating trace ids attached to queued keys.
+	traces sync.Map
+
+	// meta holds enqueue metadata attached to queued keys.
+	meta sync.Map
+
+	// pending marks keys queued but not yet handed to a worker, so a
+	// resync can skip keys that will reconcile anyway.
+	pending sync.Map
+
+	// lastSuccess remembers when a key last reconciled without error.
+	lastSuccess sync.Map
+
+	// name is the workqueue name, it doubles as the controller name in
+	// debug output and snapshots.
+	name string
+
+	// informers are the caches tracked for snapshots, see TrackInformer.
+	informers []namedInformer
+
+	// errAgg folds repeated identical reconcile errors of a key into
+	// periodic summary lines.
+	errAgg *errorAggregator
 }
 
 // NewImpl instantiates an instance of our controller that will feed work to the
@@ -130,14 +182,43 @@ func NewImpl(r Reconciler, logger *zap.SugaredLogger, workQueueName string) *Imp
 }
 
 func NewImplWithStats(r Reconciler, logger *zap.SugaredLogger, workQueueName string) *Impl {
-	return &Impl{
-		Reconciler: r,
-		WorkQueue: workqueue.NewNamedRateLimitingQueue(
+	return NewImplWithPolicy(r, logger, workQueueName, DefaultDequeuePolicy)
+}
+
+// NewImplWithPolicy is NewImpl with an explicit dequeue policy instead
+// of the process-wide default.
+func NewImplWithPolicy(r Reconciler, logger *zap.SugaredLogger, workQueueName string, policy DequeuePolicy) *Impl {
+	// A batching reconciler needs the group draining of our own queue,
+	// the client-go queue hands out keys strictly one by one.
+	_, batching := r.(BatchReconciler)
+
+	var queue workqueue.RateLimitingInterface
+	if policy == DequeueFIFO && !batching {
+		queue = workqueue.NewNamedRateLimitingQueue(
 			workqueue.DefaultControllerRateLimiter(),
 			workQueueName,
-		),
-		logger: logger,
+		)
+	} else {
+		queue = newPolicyQueue(policy, workqueue.DefaultControllerRateLimiter())
 	}
+
+	impl := &Impl{
+		Reconciler: r,
+		WorkQueue:  queue,
+		logger:     logger,
+		name:       workQueueName,
+		errAgg:     newErrorAggregator(),
+	}
+
+	debug.Register("workqueue/"+workQueueName, func() string {
+		return fmt.Sprintf("depth = %d", impl.WorkQueue.Len())
+	})
+
+	snapshotMu.Lock()
+	snapshotImpls = append(snapshotImpls, impl)
+	snapshotMu.Unlock()
+
+	return impl
 }
 
 // EnqueueAfter takes a resource, converts it into a namespace/name string,
@@ -249,8 +330,85 @@ func (c *Impl) EnqueueLabelOfClusterScopedResource(nameLabel string) func(obj in
 	}
 }
 
+// TracedKey associates an originating trace id with a workqueue key, so
+// the reconcile triggered by a request can log the same trace id as the
+// request itself.
+type TracedKey struct {
+	Key     types.NamespacedName
+	TraceId string
+}
+
+// EnqueueTracedKey puts the key onto the work queue and remembers its
+// originating trace id. If several enqueues of one key are merged by the
+// queue, the latest trace id wins.
+func (c *Impl) EnqueueTracedKey(tk TracedKey) {
+	if tk.TraceId != "" {
+		c.traces.Store(tk.Key, tk.TraceId)
+	}
+	c.EnqueueKey(tk.Key)
+}
+
+// EnqueueReason classifies the event that caused a key to be queued.
+type EnqueueReason string
+
+const (
+	EnqueueReasonAdd    EnqueueReason = "add"
+	EnqueueReasonUpdate EnqueueReason = "update"
+	EnqueueReasonDelete EnqueueReason = "delete"
+	EnqueueReasonResync EnqueueReason = "resync"
+)
+
+// EnqueueMeta describes why a key was queued and what kind of object
+// triggered it. The source GVK may be empty, informer caches strip the
+// type meta of most objects.
+type EnqueueMeta struct {
+	Reason    EnqueueReason
+	SourceGVK schema.GroupVersionKind
+
+	// Tombstone carries the last known state of the object on delete
+	// events, so cleanup does not have to guess what went away after the
+	// lister already answers not found. Treat it as read only, it is
+	// shared with the informer cache.
+	Tombstone Accessor
+}
+
+// EnqueueWithReason returns an Enqueue method that additionally records
+// the given reason and the kind of the source object with the key. If
+// several enqueues of one key are merged by the queue, the latest
+// metadata wins.
+func (c *Impl) EnqueueWithReason(reason EnqueueReason) func(interface{}) {
+	return func(obj interface{}) {
+		object, err := DeletionHandlingAccessor(obj)
+		if err != nil {
+			c.logger.Errorw("Enqueue", zap.Error(err))
+			return
+		}
+		key := types.NamespacedName{Namespace: object.GetNamespace(), Name: object.GetName()}
+		meta := EnqueueMeta{Reason: reason, SourceGVK: object.GroupVersionKind()}
+		if reason == EnqueueReasonDelete {
+			// DeletionHandlingAccessor already unwrapped a possible
+			// DeletedFinalStateUnknown, object is the final state.
+			meta.Tombstone = object
+		}
+		c.meta.Store(key, meta)
+		c.EnqueueKey(key)
+	}
+}
+
+// HandleAllWithMeta is HandleAll wired to the queue of this controller,
+// recording the event type with every key so the reconciler can read it
+// back from the context via GetEnqueueMeta.
+func (c *Impl) HandleAllWithMeta() cache.ResourceEventHandler {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.EnqueueWithReason(EnqueueReasonAdd),
+		UpdateFunc: PassNew(c.EnqueueWithReason(EnqueueReasonUpdate)),
+		DeleteFunc: c.EnqueueWithReason(EnqueueReasonDelete),
+	}
+}
+
 // EnqueueKey takes a namespace/name string and puts it onto the work queue.
 func (c *Impl) EnqueueKey(key types.NamespacedName) {
+	c.pending.Store(key, struct{}{})
 	c.WorkQueue.Add(key)
 	c.logger.Debugf("Adding to queue %s (depth: %d)", safeKey(key), c.WorkQueue.Len())
 }
@@ -258,6 +416,7 @@ func (c *Impl) EnqueueKey(key types.NamespacedName) {
 // EnqueueKeyAfter takes a namespace/name string and schedules its execution in
 // the work queue after given delay.
 func (c *Impl) EnqueueKeyAfter(key types.NamespacedName, delay time.Duration) {
+	c.pending.Store(key, struct{}{})
 	c.WorkQueue.AddAfter(key, delay)
 	c.logger.Debugf("Adding to queue %s (delay: %v, depth: %d)", safeKey(key), delay, c.WorkQueue.Len())
 }
@@ -298,12 +457,19 @@ func (c *Impl) Run(threadiness int, stopCh <-chan struct{}) error {
 // processNextWorkItem will read a single work item off the workqueue and
 // attempt to process it, by calling Reconcile on our Reconciler.
 func (c *Impl) processNextWorkItem() bool {
+	if br, ok := c.Reconciler.(BatchReconciler); ok {
+		if bq, ok := c.WorkQueue.(*policyQueue); ok {
+			return c.processNextBatch(br, bq)
+		}
+	}
+
 	obj, shutdown := c.WorkQueue.Get()
 	if shutdown {
 		return false
 	}
 	key := obj.(types.NamespacedName)
 	keyStr := safeKey(key)
+	c.pending.Delete(key)
 
 	c.logger.Debugf("Processing from queue %s (depth: %d)", safeKey(key), c.WorkQueue.Len())
 
@@ -319,10 +485,23 @@ func (c *Impl) processNextWorkItem() bool {
 	var err error
 
 	// Embed the key into the logger and attach that to the context we pass
-	// to the Reconciler.
-	logger := c.logger.With(zap.String(logging.TraceId, uuid.New().String()), zap.String(logging.Key, keyStr))
+	// to the Reconciler. Reuse the originating trace id if the enqueuer
+	// attached one, otherwise generate a fresh one.
+	traceId := uuid.New().String()
+	if v, ok := c.traces.Load(key); ok {
+		traceId = v.(string)
+		c.traces.Delete(key)
+	}
+	logger := c.logger.With(zap.String(logging.TraceId, traceId), zap.String(logging.Key, keyStr))
 	ctx := logging.WithLogger(context.TODO(), logger)
 
+	// Hand any enqueue metadata the enqueuer attached over to the
+	// Reconciler through the context.
+	if v, ok := c.meta.Load(key); ok {
+		c.meta.Delete(key)
+		ctx = withEnqueueMeta(ctx, v.(EnqueueMeta))
+	}
+
 	// Run Reconcile, passing it the namespace/name string of the
 	// resource to be synced.
 	if err = c.Reconciler.Reconcile(ctx, keyStr); err != nil {
@@ -334,19 +513,85 @@ func (c *Impl) processNextWorkItem() bool {
 	// Finally, if no error occurs we Forget this item so it does not
 	// have any delay when another change happens.
 	c.WorkQueue.Forget(key)
+	c.lastSuccess.Store(key, time.Now())
+	c.errAgg.clear(key)
 	logger.Infof("Reconcile succeeded. Time taken: %v.", time.Since(startTime))
 
 	return true
 }
 
+// processNextBatch drains all queued keys of one batch group and hands
+// them to the BatchReconciler in a single call.
+func (c *Impl) processNextBatch(br BatchReconciler, q *policyQueue) bool {
+	batch, shutdown := q.GetBatch(func(item interface{}) string {
+		return br.BatchOf(safeKey(item.(types.NamespacedName)))
+	}, DefaultMaxBatchSize)
+	if shutdown {
+		return false
+	}
+
+	keys := make([]types.NamespacedName, 0, len(batch))
+	keyStrs := make([]string, 0, len(batch))
+	for _, item := range batch {
+		key := item.(types.NamespacedName)
+		c.pending.Delete(key)
+		keys = append(keys, key)
+		keyStrs = append(keyStrs, safeKey(key))
+	}
+	defer func() {
+		for _, key := range keys {
+			c.WorkQueue.Done(key)
+		}
+	}()
+
+	startTime := time.Now()
+	logger := c.logger.With(zap.String(logging.TraceId, uuid.New().String()), zap.Strings("keys", keyStrs))
+	ctx := logging.WithLogger(context.TODO(), logger)
+
+	if err := br.ReconcileBatch(ctx, keyStrs); err != nil {
+		for _, key := range keys {
+			c.handleErr(err, key)
+		}
+		logger.Infof("Batch reconcile of %d keys failed. Time taken: %v.", len(keys), time.Since(startTime))
+		return true
+	}
+
+	now := time.Now()
+	for _, key := range keys {
+		c.WorkQueue.Forget(key)
+		c.lastSuccess.Store(key, now)
+		c.errAgg.clear(key)
+	}
+	logger.Infof("Batch reconcile of %d keys succeeded. Time taken: %v.", len(keys), time.Since(startTime))
+	return true
+}
+
 func (c *Impl) handleErr(err error, key types.NamespacedName) {
-	c.logger.Errorw("Reconcile error", zap.Error(err))
+	logNow, suppressed := c.errAgg.observe(key, err.Error(), time.Now())
+	if logNow {
+		c.logger.Errorw("Reconcile error", zap.Error(err))
+	} else if suppressed > 0 {
+		c.logger.Errorw(fmt.Sprintf("Reconcile error persists, %d identical occurrences suppressed in the last %v",
+			suppressed, ErrorLogSummaryWindow), zap.Error(err))
+	}
+
+	// An explicit requeue delay of the reconciler takes precedence over
+	// the rate limited backoff.
+	var delayed RequeueAfterError
+	if errors.As(err, &delayed) && !c.WorkQueue.ShuttingDown() {
+		c.pending.Store(key, struct{}{})
+		c.WorkQueue.AddAfter(key, delayed.After)
+		c.logger.Debugf("Requeuing key %s after %v as the reconciler requested (depth: %d)",
+			safeKey(key), delayed.After, c.WorkQueue.Len())
+		return
+	}
 
 	// Re-queue the key if it's an transient error.
 	// We want to check that the queue is shutting down here
 	// since controller Run might have exited by now (since while this item was
 	// being processed, queue.Len==0).
 	if !IsPermanentError(err) && !c.WorkQueue.ShuttingDown() {
+		c.pending.Store(key, struct{}{})
 		c.WorkQueue.AddRateLimited(key)
 		c.logger.Debugf("Requeuing key %s due to non-permanent error (depth: %d)", safeKey(key), c.WorkQueue.Len())
 		return
@@ -370,12 +615,34 @@ func (c *Impl) FilteredGlobalResync(f func(interface{}) bool, si cache.SharedInf
 	list := si.GetStore().List()
 	count := float64(len(list))
 	for _, obj := range list {
-		if f(obj) {
-			c.EnqueueAfter(obj, wait.Jitter(time.Second, count))
+		if !f(obj) {
+			continue
+		}
+		if object, err := DeletionHandlingAccessor(obj); err == nil {
+			key := types.NamespacedName{Namespace: object.GetNamespace(), Name: object.GetName()}
+			if c.resyncRedundant(key) {
+				continue
+			}
+			c.meta.Store(key, EnqueueMeta{Reason: EnqueueReasonResync, SourceGVK: object.GroupVersionKind()})
 		}
+		c.EnqueueAfter(obj, wait.Jitter(time.Second, count))
 	}
 }
 
+// resyncRedundant reports whether a resync enqueue of the key would be
+// wasted work: the key either already sits on the queue or reconciled
+// successfully within DefaultResyncFreshness.  Event driven enqueues are
+// never suppressed, only the periodic full sweeps.
+func (c *Impl) resyncRedundant(key types.NamespacedName) bool {
+	if _, ok := c.pending.Load(key); ok {
+		return true
+	}
+	if v, ok := c.lastSuccess.Load(key); ok && DefaultResyncFreshness > 0 {
+		return time.Since(v.(time.Time)) < DefaultResyncFreshness
+	}
+	return false
+}
+
 // NewPermanentError returns a new instance of permanentError.
 // Users can wrap an error as permanentError with this in reconcile,
 // when he does not expect the key to get re-queued.
@@ -389,14 +656,22 @@ type permanentError struct {
 	e error
 }
 
-// IsPermanentError returns true if given error is permanentError
+// IsPermanentError returns true if given error is or wraps a
+// permanentError, or a registered classifier or the built-in
+// classification calls it permanent. An explicit permanentError always
+// wins.
 func IsPermanentError(err error) bool {
-	switch err.(type) {
-	case permanentError:
+	var pe permanentError
+	if errors.As(err, &pe) {
 		return true
-	default:
-		return false
 	}
+	for _, classify := range errorClassifiers {
+		if permanent, ok := classify(err); ok {
+			return permanent
+		}
+	}
+	permanent, _ := classifyBuiltin(err)
+	return permanent
 }
 
 // Error implements the Error() interface of error.
@@ -408,6 +683,33 @@ func (err permanentError) Error() string {
 	return err.e.Error()
 }
 
+// Unwrap exposes the wrapped error to errors.Is and errors.As, so a
+// fmt.Errorf("%w") chain around a permanentError keeps working.
+func (err permanentError) Unwrap() error {
+	return err.e
+}
+
+// RequeueAfterError asks the controller to requeue the key after the
+// given delay instead of the rate limited backoff, e.g. while waiting
+// for an external system with a known pace.
+type RequeueAfterError struct {
+	After time.Duration
+	Err   error
+}
+
+// Error implements the Error() interface of error.
+func (err RequeueAfterError) Error() string {
+	if err.Err == nil {
+		return fmt.Sprintf("requeue requested after %v", err.After)
+	}
+	return err.Err.Error()
+}
+
+// Unwrap exposes the wrapped error to errors.Is and errors.As.
+func (err RequeueAfterError) Unwrap() error {
+	return err.Err
+}
+
 // Informer is the group of methods that a type must implement to be passed to
 // StartInformers.
 type Informer interface {
@@ -491,6 +793,37 @@ func GetTrackerLease(ctx context.Context) time.Duration {
 	return 3 * GetResyncPeriod(ctx)
 }
 
+// enqueueMetaKey is used to associate EnqueueMeta with contexts.
+type enqueueMetaKey struct{}
+
+// withEnqueueMeta attaches the given EnqueueMeta to the provided context
+// in the returned context.
+func withEnqueueMeta(ctx context.Context, m EnqueueMeta) context.Context {
+	return context.WithValue(ctx, enqueueMetaKey{}, m)
+}
+
+// GetEnqueueMeta returns the metadata recorded when the key of the
+// running reconcile was queued. The second return is false when the
+// enqueuer attached none, e.g. on a plain Enqueue or EnqueueKey.
+func GetEnqueueMeta(ctx context.Context) (EnqueueMeta, bool) {
+	untyped := ctx.Value(enqueueMetaKey{})
+	if untyped == nil {
+		return EnqueueMeta{}, false
+	}
+	return untyped.(EnqueueMeta), true
+}
+
+// GetTombstone returns the last known state of the deleted object the
+// running reconcile was queued for. The second return is false when the
+// key was not queued by a delete event.
+func GetTombstone(ctx context.Context) (Accessor, bool) {
+	m, ok := GetEnqueueMeta(ctx)
+	if !ok || m.Tombstone == nil {
+		return nil, false
+	}
+	return m.Tombstone, true
+}
+
 // erKey is used to associate record.EventRecorders with contexts.
 type erKey struct{}
 
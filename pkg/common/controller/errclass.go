@@ -0,0 +1,49 @@
+package controller
+
+import (
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	lverrors "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/errors"
+)
+
+// ErrorClassifier gives one opinion on whether a reconcile error is
+// permanent, i.e. requeuing the key cannot fix it. The second return is
+// false when the classifier has no opinion, the next one is asked then.
+type ErrorClassifier func(err error) (permanent bool, ok bool)
+
+// errorClassifiers are consulted in registration order, the built-in
+// classification of api and category errors runs after all of them.
+// Appended during init of the binaries, not guarded for concurrent use.
+var errorClassifiers []ErrorClassifier
+
+// RegisterErrorClassifier adds a classifier consulted by
+// IsPermanentError before the built-in classification, so one binary
+// wide policy replaces per reconciler error matching.
+func RegisterErrorClassifier(f ErrorClassifier) {
+	errorClassifiers = append(errorClassifiers, f)
+}
+
+// classifyBuiltin is the default opinion shared by all reconcilers: a
+// malformed or vanished object never heals through retries, contention
+// and timeouts usually do.
+func classifyBuiltin(err error) (bool, bool) {
+	if category, ok := lverrors.CategoryOf(err); ok {
+		switch category {
+		case lverrors.Permanent, lverrors.InvalidInput:
+			return true, true
+		default:
+			// Transient, Conflict and CapacityExhausted can all resolve
+			// on their own, a requeue may win.
+			return false, true
+		}
+	}
+	switch {
+	case apierrors.IsNotFound(err), apierrors.IsInvalid(err),
+		apierrors.IsBadRequest(err), apierrors.IsMethodNotSupported(err):
+		return true, true
+	case apierrors.IsConflict(err), apierrors.IsServerTimeout(err),
+		apierrors.IsTimeout(err), apierrors.IsTooManyRequests(err):
+		return false, true
+	}
+	return false, false
+}
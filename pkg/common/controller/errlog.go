@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var (
+	// ErrorLogThreshold is how many identical reconcile errors of one key
+	// are logged in full before the aggregation kicks in.  Controller
+	// binaries may adjust this process-wide default, zero disables the
+	// aggregation.
+	ErrorLogThreshold = 3
+
+	// ErrorLogSummaryWindow is how often a suppressed error streak emits
+	// a summary line.
+	ErrorLogSummaryWindow = time.Minute
+)
+
+// errorAggregator keeps one log streak per key, so a node broken for
+// hours produces a summary line per window instead of a log line per
+// requeue.
+type errorAggregator struct {
+	mu      sync.Mutex
+	streaks map[types.NamespacedName]*errorStreak
+}
+
+// errorStreak is the run of identical errors one key currently produces.
+type errorStreak struct {
+	message     string
+	count       int
+	suppressed  int
+	lastSummary time.Time
+}
+
+func newErrorAggregator() *errorAggregator {
+	return &errorAggregator{streaks: make(map[types.NamespacedName]*errorStreak)}
+}
+
+// observe counts one error occurrence. The first return says whether to
+// log it in full, the second is non-zero when a periodic summary of the
+// suppressed occurrences is due instead.
+func (a *errorAggregator) observe(key types.NamespacedName, message string, now time.Time) (bool, int) {
+	if ErrorLogThreshold <= 0 {
+		return true, 0
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	s := a.streaks[key]
+	if s == nil || s.message != message {
+		// A new or changed error starts a fresh streak and is always
+		// worth a full line.
+		a.streaks[key] = &errorStreak{message: message, count: 1, lastSummary: now}
+		return true, 0
+	}
+
+	s.count++
+	if s.count <= ErrorLogThreshold {
+		return true, 0
+	}
+
+	s.suppressed++
+	if now.Sub(s.lastSummary) >= ErrorLogSummaryWindow {
+		suppressed := s.suppressed
+		s.suppressed = 0
+		s.lastSummary = now
+		return false, suppressed
+	}
+	return false, 0
+}
+
+// clear forgets the streak of a key once it reconciles cleanly again.
+func (a *errorAggregator) clear(key types.NamespacedName) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.streaks, key)
+}
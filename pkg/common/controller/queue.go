@@ -0,0 +1,263 @@
+package controller
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// DequeuePolicy selects the order the workqueue hands keys to workers.
+type DequeuePolicy string
+
+const (
+	// DequeueFIFO processes keys in arrival order, the client-go default.
+	DequeueFIFO DequeuePolicy = "fifo"
+	// DequeueLIFO processes the most recently queued key first, the
+	// freshest events win during a backlog.
+	DequeueLIFO DequeuePolicy = "lifo"
+	// DequeueFairNamespace round robins across the namespaces of the
+	// queued keys, one namespace generating thousands of events cannot
+	// starve the others.
+	DequeueFairNamespace DequeuePolicy = "fair-namespace"
+)
+
+// DefaultDequeuePolicy is the policy NewImpl builds queues with.
+// Controller binaries may adjust this process-wide default, for finer
+// control use NewImplWithPolicy.
+var DefaultDequeuePolicy = DequeueFIFO
+
+// policyQueue is a workqueue.RateLimitingInterface with a pluggable
+// dequeue order. It keeps the dirty/processing semantics of the
+// client-go workqueue: a key queued while being processed is queued
+// again once processing finishes, never concurrently.
+type policyQueue struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	policy DequeuePolicy
+
+	// items is the queue of DequeueFIFO and DequeueLIFO.
+	items []interface{}
+	// buckets and rotation implement DequeueFairNamespace, one fifo per
+	// namespace with a round robin over the non-empty ones.
+	buckets  map[string][]interface{}
+	rotation []string
+	next     int
+
+	length       int
+	dirty        map[interface{}]struct{}
+	processing   map[interface{}]struct{}
+	shuttingDown bool
+
+	rateLimiter workqueue.RateLimiter
+}
+
+func newPolicyQueue(policy DequeuePolicy, rateLimiter workqueue.RateLimiter) *policyQueue {
+	q := &policyQueue{
+		policy:      policy,
+		buckets:     make(map[string][]interface{}),
+		dirty:       make(map[interface{}]struct{}),
+		processing:  make(map[interface{}]struct{}),
+		rateLimiter: rateLimiter,
+	}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+func (q *policyQueue) Add(item interface{}) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.shuttingDown {
+		return
+	}
+	if _, ok := q.dirty[item]; ok {
+		return
+	}
+	q.dirty[item] = struct{}{}
+	if _, ok := q.processing[item]; ok {
+		return
+	}
+	q.push(item)
+	q.cond.Signal()
+}
+
+func (q *policyQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.length
+}
+
+func (q *policyQueue) Get() (interface{}, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for q.length == 0 && !q.shuttingDown {
+		q.cond.Wait()
+	}
+	if q.length == 0 {
+		return nil, true
+	}
+	item := q.pop()
+	q.processing[item] = struct{}{}
+	delete(q.dirty, item)
+	return item, false
+}
+
+// GetBatch returns the next item together with every other queued item
+// of the same group, all marked processing. Blocks like Get when the
+// queue is empty, the second return is true on shutdown.
+func (q *policyQueue) GetBatch(groupOf func(interface{}) string, max int) ([]interface{}, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for q.length == 0 && !q.shuttingDown {
+		q.cond.Wait()
+	}
+	if q.length == 0 {
+		return nil, true
+	}
+
+	first := q.pop()
+	q.processing[first] = struct{}{}
+	delete(q.dirty, first)
+
+	batch := []interface{}{first}
+	if max > 1 {
+		batch = append(batch, q.takeGroup(groupOf(first), groupOf, max-1)...)
+	}
+	return batch, false
+}
+
+// takeGroup removes up to max queued items of the given group and marks
+// them processing, callers hold the lock.
+func (q *policyQueue) takeGroup(group string, groupOf func(interface{}) string, max int) []interface{} {
+	taken := make([]interface{}, 0)
+	match := func(item interface{}) bool {
+		return len(taken) < max && groupOf(item) == group
+	}
+
+	if q.policy != DequeueFairNamespace {
+		kept := make([]interface{}, 0, len(q.items))
+		for _, item := range q.items {
+			if match(item) {
+				taken = append(taken, item)
+			} else {
+				kept = append(kept, item)
+			}
+		}
+		q.items = kept
+	} else {
+		for ns, bucket := range q.buckets {
+			kept := make([]interface{}, 0, len(bucket))
+			for _, item := range bucket {
+				if match(item) {
+					taken = append(taken, item)
+				} else {
+					kept = append(kept, item)
+				}
+			}
+			q.buckets[ns] = kept
+		}
+	}
+
+	for _, item := range taken {
+		q.length--
+		q.processing[item] = struct{}{}
+		delete(q.dirty, item)
+	}
+	return taken
+}
+
+func (q *policyQueue) Done(item interface{}) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.processing, item)
+	if _, ok := q.dirty[item]; ok {
+		q.push(item)
+		q.cond.Signal()
+	}
+}
+
+func (q *policyQueue) ShutDown() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.shuttingDown = true
+	q.cond.Broadcast()
+}
+
+func (q *policyQueue) ShuttingDown() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.shuttingDown
+}
+
+func (q *policyQueue) AddAfter(item interface{}, duration time.Duration) {
+	if duration <= 0 {
+		q.Add(item)
+		return
+	}
+	// Add checks for shutdown again once the timer fires.
+	time.AfterFunc(duration, func() { q.Add(item) })
+}
+
+func (q *policyQueue) AddRateLimited(item interface{}) {
+	q.AddAfter(item, q.rateLimiter.When(item))
+}
+
+func (q *policyQueue) Forget(item interface{}) {
+	q.rateLimiter.Forget(item)
+}
+
+func (q *policyQueue) NumRequeues(item interface{}) int {
+	return q.rateLimiter.NumRequeues(item)
+}
+
+// push appends the item under the policy, callers hold the lock.
+func (q *policyQueue) push(item interface{}) {
+	q.length++
+	if q.policy != DequeueFairNamespace {
+		q.items = append(q.items, item)
+		return
+	}
+	ns := itemNamespace(item)
+	if _, ok := q.buckets[ns]; !ok {
+		q.rotation = append(q.rotation, ns)
+	}
+	q.buckets[ns] = append(q.buckets[ns], item)
+}
+
+// pop removes the next item under the policy, callers hold the lock and
+// have checked the queue is not empty.
+func (q *policyQueue) pop() interface{} {
+	q.length--
+	switch q.policy {
+	case DequeueLIFO:
+		item := q.items[len(q.items)-1]
+		q.items = q.items[:len(q.items)-1]
+		return item
+	case DequeueFairNamespace:
+		for {
+			ns := q.rotation[q.next%len(q.rotation)]
+			q.next++
+			if len(q.buckets[ns]) == 0 {
+				continue
+			}
+			item := q.buckets[ns][0]
+			q.buckets[ns] = q.buckets[ns][1:]
+			return item
+		}
+	default:
+		item := q.items[0]
+		q.items = q.items[1:]
+		return item
+	}
+}
+
+// itemNamespace buckets an item by namespace, cluster-scoped keys all
+// share the empty bucket.
+func itemNamespace(item interface{}) string {
+	if key, ok := item.(types.NamespacedName); ok {
+		return key.Namespace
+	}
+	return ""
+}
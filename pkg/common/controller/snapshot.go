@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+)
+
+var (
+	snapshotMu sync.Mutex
+	// snapshotImpls holds every controller built through NewImpl, so a
+	// single call can snapshot the whole binary.
+	snapshotImpls []*Impl
+)
+
+// Snapshot is the file payload of one controller: what sits on its
+// queue and what its informer caches contain at the time of the dump.
+// Comparing it against the live cluster pins down "controller thinks X
+// but cluster shows Y" discrepancies.
+type Snapshot struct {
+	Controller  string                   `json:"controller"`
+	TakenAt     string                   `json:"takenAt"`
+	QueueDepth  int                      `json:"queueDepth"`
+	PendingKeys []string                 `json:"pendingKeys"`
+	Caches      map[string][]interface{} `json:"caches"`
+}
+
+// TrackInformer registers an informer cache under the given name for
+// inclusion in snapshots of this controller. Constructors call it for
+// every informer their reconciler reads from.
+func (c *Impl) TrackInformer(name string, si cache.SharedInformer) {
+	snapshotMu.Lock()
+	defer snapshotMu.Unlock()
+	c.informers = append(c.informers, namedInformer{name: name, informer: si})
+}
+
+type namedInformer struct {
+	name     string
+	informer cache.SharedInformer
+}
+
+// TakeSnapshot collects the queue and cache state of this controller.
+// The stores are read one after another, the snapshot is consistent per
+// cache but only best effort across them.
+func (c *Impl) TakeSnapshot() Snapshot {
+	keys := make([]string, 0)
+	c.pending.Range(func(k, _ interface{}) bool {
+		keys = append(keys, safeKey(k.(types.NamespacedName)))
+		return true
+	})
+	sort.Strings(keys)
+
+	snapshot := Snapshot{
+		Controller:  c.name,
+		TakenAt:     time.Now().UTC().Format(time.RFC3339),
+		QueueDepth:  c.WorkQueue.Len(),
+		PendingKeys: keys,
+		Caches:      make(map[string][]interface{}),
+	}
+
+	snapshotMu.Lock()
+	informers := append([]namedInformer(nil), c.informers...)
+	snapshotMu.Unlock()
+	for _, ni := range informers {
+		snapshot.Caches[ni.name] = ni.informer.GetStore().List()
+	}
+	return snapshot
+}
+
+// WriteSnapshot dumps the snapshot of this controller as json to the
+// given file.
+func (c *Impl) WriteSnapshot(path string) error {
+	data, err := json.MarshalIndent(c.TakeSnapshot(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// SnapshotAll writes one snapshot file per controller of the binary
+// into the given directory, named <controller>-<timestamp>.json, and
+// returns the first error encountered after trying all of them.
+func SnapshotAll(dir string) error {
+	snapshotMu.Lock()
+	impls := append([]*Impl(nil), snapshotImpls...)
+	snapshotMu.Unlock()
+
+	stamp := time.Now().UTC().Format("20060102-150405")
+	var firstErr error
+	for _, impl := range impls {
+		path := filepath.Join(dir, fmt.Sprintf("%s-%s.json", impl.name, stamp))
+		if err := impl.WriteSnapshot(path); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,75 @@
+package debug
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+)
+
+var (
+	mu      sync.Mutex
+	dumpers = make(map[string]func() string)
+)
+
+// Register register a named dumper, the dumper is called on every dump
+// to report the internal state of one component (workqueue depth,
+// cached capacity, in-flight operations ...).
+func Register(name string, dumper func() string) {
+	mu.Lock()
+	defer mu.Unlock()
+	dumpers[name] = dumper
+}
+
+// Dump collects the output of all registered dumpers and all goroutine stacks.
+func Dump() string {
+	mu.Lock()
+	names := make([]string, 0, len(dumpers))
+	for name := range dumpers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		fmt.Fprintf(&buf, "=== %s ===\n%s\n", name, dumpers[name]())
+	}
+	mu.Unlock()
+
+	stack := make([]byte, 1<<20)
+	n := runtime.Stack(stack, true)
+	fmt.Fprintf(&buf, "=== goroutines ===\n%s\n", stack[:n])
+
+	return buf.String()
+}
+
+// SetupDumpHandler dump the internal state to the log when the process
+// receives the dump signal (SIGUSR1 on posix systems).
+func SetupDumpHandler() {
+	if len(dumpSignals) == 0 {
+		return
+	}
+
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, dumpSignals...)
+	go func() {
+		for range c {
+			logging.GetLogger().Infof("debug dump:\n%s", Dump())
+		}
+	}()
+}
+
+// Handler return a http handler serving the same dump, it can be attached
+// to a debug mux of any binary.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprint(w, Dump())
+	})
+}
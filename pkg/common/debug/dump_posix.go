@@ -0,0 +1,11 @@
+//go:build !windows
+// +build !windows
+
+package debug
+
+import (
+	"os"
+	"syscall"
+)
+
+var dumpSignals = []os.Signal{syscall.SIGUSR1}
@@ -0,0 +1,7 @@
+package debug
+
+import (
+	"os"
+)
+
+var dumpSignals []os.Signal
@@ -0,0 +1,75 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Category is the machine readable class of an error, it drives retry
+// policies, metrics labels and user messages across scheduler, controllers
+// and the CSI driver.
+type Category string
+
+const (
+	// Transient error is expected to go away by retrying.
+	Transient Category = "Transient"
+	// Conflict error is raised when a concurrent writer won, retry with
+	// fresh data is expected to succeed.
+	Conflict Category = "Conflict"
+	// CapacityExhausted error is raised when the node has not enough free
+	// local volume capacity.
+	CapacityExhausted Category = "CapacityExhausted"
+	// InvalidInput error is raised on malformed requests, retrying does
+	// not help.
+	InvalidInput Category = "InvalidInput"
+	// Permanent error will never succeed no matter how often it is retried.
+	Permanent Category = "Permanent"
+)
+
+type categorized struct {
+	category Category
+	err      error
+}
+
+func (e *categorized) Error() string {
+	return e.err.Error()
+}
+
+func (e *categorized) Unwrap() error {
+	return e.err
+}
+
+// New create a categorized error from msg.
+func New(category Category, msg string) error {
+	return &categorized{category: category, err: errors.New(msg)}
+}
+
+// Newf create a categorized error from a format string.
+func Newf(category Category, format string, args ...interface{}) error {
+	return &categorized{category: category, err: fmt.Errorf(format, args...)}
+}
+
+// Wrap attach category to err, a nil err stays nil.
+func Wrap(category Category, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &categorized{category: category, err: err}
+}
+
+// CategoryOf return the category of err, the outermost category wins
+// if err was wrapped more than once. An error without category reports
+// ok = false.
+func CategoryOf(err error) (Category, bool) {
+	var c *categorized
+	if errors.As(err, &c) {
+		return c.category, true
+	}
+	return "", false
+}
+
+// IsCategory check whether err carries the given category.
+func IsCategory(err error, category Category) bool {
+	c, ok := CategoryOf(err)
+	return ok && c == category
+}
@@ -0,0 +1,39 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestCategoryOf(t *testing.T) {
+	err := New(Conflict, "update conflict")
+	checkCategory(err, Conflict, t)
+
+	err = Wrap(CapacityExhausted, errors.New("no space left"))
+	checkCategory(err, CapacityExhausted, t)
+
+	// wrapped with fmt keeps the category
+	err = fmt.Errorf("reconcile error: %w", New(Permanent, "gone"))
+	checkCategory(err, Permanent, t)
+
+	// plain error has no category
+	if _, ok := CategoryOf(errors.New("plain")); ok {
+		t.Errorf("unexpected category on plain error")
+	}
+
+	// nil stays nil
+	if Wrap(Transient, nil) != nil {
+		t.Errorf("Wrap(nil) should be nil")
+	}
+}
+
+func checkCategory(err error, want Category, t *testing.T) {
+	got, ok := CategoryOf(err)
+	if !ok || got != want {
+		t.Errorf("unexpected category. want: %v, got: %v", want, got)
+	}
+	if !IsCategory(err, want) {
+		t.Errorf("IsCategory(%v, %v) should be true", err, want)
+	}
+}
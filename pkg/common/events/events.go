@@ -0,0 +1,105 @@
+package events
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	lvscheme "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/clientset/versioned/scheme"
+)
+
+const (
+	// DefaultDedupWindow is the window within which identical events of
+	// one object are aggregated instead of emitted.
+	DefaultDedupWindow = time.Minute
+)
+
+// NewRecorder create an event recorder posting to the cluster under the
+// given component name.
+func NewRecorder(kubeClient kubernetes.Interface, component string) record.EventRecorder {
+	// make sure references to LocalVolume objects can be resolved
+	_ = lvscheme.AddToScheme(scheme.Scheme)
+
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
+	return broadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: component})
+}
+
+type dedupEntry struct {
+	lastEmit time.Time
+	count    int
+}
+
+// DedupRecorder deduplicate identical events per object within a window
+// and aggregate the suppressed count into the next emitted event, it
+// prevents event storms from per-reconcile failures.
+type DedupRecorder struct {
+	recorder record.EventRecorder
+	window   time.Duration
+
+	mu   sync.Mutex
+	seen map[string]*dedupEntry
+}
+
+// NewDedupRecorder wrap recorder with deduplication, window <= 0 falls
+// back to DefaultDedupWindow.
+func NewDedupRecorder(recorder record.EventRecorder, window time.Duration) *DedupRecorder {
+	if window <= 0 {
+		window = DefaultDedupWindow
+	}
+	return &DedupRecorder{
+		recorder: recorder,
+		window:   window,
+		seen:     make(map[string]*dedupEntry),
+	}
+}
+
+// Event emit one event, identical events of the same object within the
+// window are counted instead of emitted, the count is flushed with the
+// next emission.
+func (r *DedupRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	key := eventKey(object, eventtype, reason, message)
+	now := time.Now()
+
+	r.mu.Lock()
+	entry, ok := r.seen[key]
+	if !ok {
+		entry = &dedupEntry{}
+		r.seen[key] = entry
+	}
+	if now.Sub(entry.lastEmit) < r.window {
+		entry.count++
+		r.mu.Unlock()
+		return
+	}
+	suppressed := entry.count
+	entry.count = 0
+	entry.lastEmit = now
+	r.mu.Unlock()
+
+	if suppressed > 0 {
+		message = fmt.Sprintf("%s (%d similar events suppressed)", message, suppressed)
+	}
+	r.recorder.Event(object, eventtype, reason, message)
+}
+
+// Eventf is the printf style variant of Event.
+func (r *DedupRecorder) Eventf(object runtime.Object, eventtype, reason, format string, args ...interface{}) {
+	r.Event(object, eventtype, reason, fmt.Sprintf(format, args...))
+}
+
+func eventKey(object runtime.Object, eventtype, reason, message string) string {
+	accessor, err := meta.Accessor(object)
+	if err != nil {
+		return eventtype + "/" + reason + "/" + message
+	}
+	return string(accessor.GetUID()) + "/" + eventtype + "/" + reason + "/" + message
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package features holds the feature gates of all binaries. Risky new
+// capabilities ship disabled by default and are toggled per cluster with
+// the -feature-gates flag or the featureGates section of the
+// configuration file, the flag wins on conflicts.
+package features
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Known feature gates, all disabled by default.
+const (
+	// ThinProvisioning creates thin lvm volumes instead of linear ones.
+	ThinProvisioning = "ThinProvisioning"
+	// Snapshots enables the CSI snapshot RPCs.
+	Snapshots = "Snapshots"
+	// FrameworkPluginMode runs the scheduler as a framework plugin
+	// instead of an http extender.
+	FrameworkPluginMode = "FrameworkPluginMode"
+)
+
+var gatesFlag = flag.String("feature-gates", "",
+	"Comma separated list of gate=bool pairs toggling optional features, e.g. ThinProvisioning=true")
+
+var (
+	mu    sync.RWMutex
+	gates = map[string]bool{
+		ThinProvisioning:    false,
+		Snapshots:           false,
+		FrameworkPluginMode: false,
+	}
+)
+
+// Setup applies the -feature-gates flag, call it after flag.Parse and
+// after any SetFromMap so the flag wins.
+func Setup() error {
+	return Set(*gatesFlag)
+}
+
+// Set applies a comma separated gate=bool spec.
+func Set(spec string) error {
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kv := strings.SplitN(entry, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("malformed feature gate(%s), expect name=bool", entry)
+		}
+		value, err := strconv.ParseBool(strings.TrimSpace(kv[1]))
+		if err != nil {
+			return fmt.Errorf("malformed feature gate(%s): %v", entry, err)
+		}
+		if err := set(strings.TrimSpace(kv[0]), value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetFromMap applies the featureGates section of the configuration file.
+func SetFromMap(m map[string]bool) error {
+	for name, value := range m {
+		if err := set(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func set(name string, value bool) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := gates[name]; !ok {
+		return fmt.Errorf("unknown feature gate(%s)", name)
+	}
+	gates[name] = value
+	return nil
+}
+
+// Enabled reports whether the named gate is on.
+func Enabled(name string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return gates[name]
+}
+
+// EnabledGates returns the sorted names of all enabled gates.
+func EnabledGates() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	result := make([]string, 0, len(gates))
+	for name, value := range gates {
+		if value {
+			result = append(result, name)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
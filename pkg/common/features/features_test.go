@@ -0,0 +1,64 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package features
+
+import (
+	"testing"
+)
+
+func TestSet(t *testing.T) {
+	if err := Set("ThinProvisioning=true, Snapshots=false"); err != nil {
+		t.Fatalf("set error = %s", err.Error())
+	}
+	if !Enabled(ThinProvisioning) {
+		t.Error("ThinProvisioning must be enabled")
+	}
+	if Enabled(Snapshots) {
+		t.Error("Snapshots must be disabled")
+	}
+
+	enabled := EnabledGates()
+	if len(enabled) != 1 || enabled[0] != ThinProvisioning {
+		t.Errorf("enabled gates = %v, want only ThinProvisioning", enabled)
+	}
+
+	if err := Set("NoSuchGate=true"); err == nil {
+		t.Error("unknown gate must be rejected")
+	}
+	if err := Set("ThinProvisioning"); err == nil {
+		t.Error("entry without value must be rejected")
+	}
+
+	if err := Set("ThinProvisioning=false"); err != nil {
+		t.Fatalf("reset error = %s", err.Error())
+	}
+}
+
+func TestSetFromMap(t *testing.T) {
+	if err := SetFromMap(map[string]bool{Snapshots: true}); err != nil {
+		t.Fatalf("set from map error = %s", err.Error())
+	}
+	if !Enabled(Snapshots) {
+		t.Error("Snapshots must be enabled")
+	}
+	if err := SetFromMap(map[string]bool{"NoSuchGate": true}); err == nil {
+		t.Error("unknown gate must be rejected")
+	}
+	if err := SetFromMap(map[string]bool{Snapshots: false}); err != nil {
+		t.Fatalf("reset error = %s", err.Error())
+	}
+}
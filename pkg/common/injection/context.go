@@ -2,12 +2,36 @@ package injection
 
 import (
 	"context"
+	"time"
+
+	"k8s.io/client-go/rest"
 )
 
 // nsKey is the key that namespaces are associated with on
 // contexts returned by WithNamespaceScope.
 type nsKey struct{}
 
+// cfgKey is the key that the rest config is associated with on
+// contexts returned by WithConfig.
+type cfgKey struct{}
+
+// WithConfig associates the rest config the clients were built from
+// with the provided context, for reconcilers that need more than the
+// injected clients, e.g. a pod exec.
+func WithConfig(ctx context.Context, cfg *rest.Config) context.Context {
+	return context.WithValue(ctx, cfgKey{}, cfg)
+}
+
+// GetConfig accesses the rest config associated with the provided
+// context, or nil when there is none.
+func GetConfig(ctx context.Context) *rest.Config {
+	value := ctx.Value(cfgKey{})
+	if value == nil {
+		return nil
+	}
+	return value.(*rest.Config)
+}
+
 // WithNamespaceScope associates a namespace scoping with the
 // provided context, which will scope the informers produced
 // by the downstream informer factories.
@@ -31,3 +55,102 @@ func GetNamespaceScope(ctx context.Context) string {
 	}
 	return value.(string)
 }
+
+// selectorKey is the key that informer selectors are associated with on
+// contexts returned by WithInformerSelector.
+type selectorKey struct{}
+
+// InformerSelector narrows what the informer of one resource lists and
+// watches, so a component only caches the objects it needs.
+type InformerSelector struct {
+	LabelSelector string
+	FieldSelector string
+}
+
+// WithInformerSelector associates a selector with the named resource
+// (lowercase plural, e.g. "pods", "persistentvolumeclaims",
+// "localvolumes") in the provided context.  The downstream informer
+// factories build scoped informers for resources that carry one.
+func WithInformerSelector(ctx context.Context, resource string, selector InformerSelector) context.Context {
+	selectors := make(map[string]InformerSelector)
+	if existing, ok := ctx.Value(selectorKey{}).(map[string]InformerSelector); ok {
+		for k, v := range existing {
+			selectors[k] = v
+		}
+	}
+	selectors[resource] = selector
+	return context.WithValue(ctx, selectorKey{}, selectors)
+}
+
+// GetInformerSelector accesses the selector associated with the named
+// resource, the second return is false when the resource is unscoped.
+func GetInformerSelector(ctx context.Context, resource string) (InformerSelector, bool) {
+	selectors, ok := ctx.Value(selectorKey{}).(map[string]InformerSelector)
+	if !ok {
+		return InformerSelector{}, false
+	}
+	selector, ok := selectors[resource]
+	return selector, ok
+}
+
+// resyncKey is the key that per-resource resync periods are associated
+// with on contexts returned by WithInformerResync.
+type resyncKey struct{}
+
+// WithInformerResync associates a resync period with the named resource
+// in the provided context, overriding the global resync period of
+// controller.WithResyncPeriod for that one informer.  High-churn
+// resources can resync often while near-static ones stay quiet.
+func WithInformerResync(ctx context.Context, resource string, period time.Duration) context.Context {
+	periods := make(map[string]time.Duration)
+	if existing, ok := ctx.Value(resyncKey{}).(map[string]time.Duration); ok {
+		for k, v := range existing {
+			periods[k] = v
+		}
+	}
+	periods[resource] = period
+	return context.WithValue(ctx, resyncKey{}, periods)
+}
+
+// GetInformerResync accesses the resync period associated with the
+// named resource, the second return is false when only the global
+// period applies.
+func GetInformerResync(ctx context.Context, resource string) (time.Duration, bool) {
+	periods, ok := ctx.Value(resyncKey{}).(map[string]time.Duration)
+	if !ok {
+		return 0, false
+	}
+	period, ok := periods[resource]
+	return period, ok
+}
+
+// bookmarksKey is the key that per-resource watch bookmark settings are
+// associated with on contexts returned by WithWatchBookmarks.
+type bookmarksKey struct{}
+
+// WithWatchBookmarks associates an explicit watch bookmark setting with
+// the named resource in the provided context.  Bookmarks keep the
+// resource version of an idle watch fresh and make relists after a
+// watch expiry cheap, at the price of periodic bookmark events.
+func WithWatchBookmarks(ctx context.Context, resource string, allow bool) context.Context {
+	settings := make(map[string]bool)
+	if existing, ok := ctx.Value(bookmarksKey{}).(map[string]bool); ok {
+		for k, v := range existing {
+			settings[k] = v
+		}
+	}
+	settings[resource] = allow
+	return context.WithValue(ctx, bookmarksKey{}, settings)
+}
+
+// GetWatchBookmarks accesses the watch bookmark setting associated with
+// the named resource, the second return is false when the reflector
+// default stays in charge.
+func GetWatchBookmarks(ctx context.Context, resource string) (bool, bool) {
+	settings, ok := ctx.Value(bookmarksKey{}).(map[string]bool)
+	if !ok {
+		return false, false
+	}
+	allow, ok := settings[resource]
+	return allow, ok
+}
@@ -0,0 +1,25 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package injection attaches clients, informer factories and informers
+// to the context of a binary. Each binary calls
+// injection.Default.SetupInformers exactly once, after that controller
+// constructors fetch their shared client and informers from the context
+// with the generated Get helpers (pkg/client/injection and
+// pkg/client/kube/injection) instead of building their own watches, so
+// one informer factory and one watch per resource is shared by every
+// controller in the binary.
+package injection
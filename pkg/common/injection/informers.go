@@ -28,6 +28,10 @@ func (i *impl) GetInformers() []InformerInjector {
 }
 
 func (i *impl) SetupInformers(ctx context.Context, cfg *rest.Config) (context.Context, []controller.Informer) {
+	// Keep the rest config itself around for reconcilers that build
+	// requests the typed clients do not cover, e.g. a pod exec.
+	ctx = WithConfig(ctx, cfg)
+
 	// Based on the reconcilers we have linked, build up a set of clients and inject
 	// them onto the context.
 	for _, ci := range i.GetClients() {
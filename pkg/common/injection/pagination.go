@@ -0,0 +1,31 @@
+package injection
+
+import (
+	"context"
+	"flag"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/pager"
+)
+
+var informerListPageSize = flag.Int64("informer-list-page-size", 500,
+	"Page size of the initial informer lists, 0 fetches everything in one response")
+
+// PaginatedListFunc wraps the list side of a ListWatch so the bootstrap
+// list of the reflector is fetched in pages instead of one giant
+// response. Relists after a watch gap page the same way, the watch
+// itself is untouched.
+func PaginatedListFunc(list func(metav1.ListOptions) (runtime.Object, error)) func(metav1.ListOptions) (runtime.Object, error) {
+	return func(options metav1.ListOptions) (runtime.Object, error) {
+		if *informerListPageSize <= 0 || options.Continue != "" || options.Limit > 0 {
+			return list(options)
+		}
+		// A resource version 0 list is answered from the watch cache in
+		// one response regardless of any limit, only a quorum list pages.
+		options.ResourceVersion = ""
+		p := pager.New(pager.SimplePageFunc(list))
+		p.PageSize = *informerListPageSize
+		return p.List(context.Background(), options)
+	}
+}
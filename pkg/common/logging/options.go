@@ -0,0 +1,86 @@
+package logging
+
+import (
+	"flag"
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+var (
+	logEncoding   = flag.String("log-encoding", "json", "Log encoding, one of json or console. Production log pipelines want json, dev clusters want console.")
+	logTimestamp  = flag.String("log-timestamp-format", "epoch", "Log timestamp format, one of epoch, iso8601 or millis.")
+	logCaller     = flag.Bool("log-caller", true, "Annotate logs with the calling function's file name and line number.")
+	logStacktrace = flag.String("log-stacktrace-level", "error", "Minimum level that triggers stacktrace capture, one of info, warn, error, panic or fatal.")
+)
+
+// Options hold the logger configuration of one binary.
+type Options struct {
+	Encoding        string
+	TimestampFormat string
+	Caller          bool
+	StacktraceLevel string
+}
+
+// OptionsFromFlags return the logging options given on the command line.
+func OptionsFromFlags() Options {
+	return Options{
+		Encoding:        *logEncoding,
+		TimestampFormat: *logTimestamp,
+		Caller:          *logCaller,
+		StacktraceLevel: *logStacktrace,
+	}
+}
+
+// NewLogger build a logger from opts.
+func NewLogger(opts Options) (*zap.SugaredLogger, error) {
+	cfg := zap.NewProductionConfig()
+
+	switch opts.Encoding {
+	case "", "json":
+		cfg.Encoding = "json"
+	case "console":
+		cfg.Encoding = "console"
+		cfg.EncoderConfig = zap.NewDevelopmentEncoderConfig()
+	default:
+		return nil, fmt.Errorf("unknown log encoding %q", opts.Encoding)
+	}
+
+	switch opts.TimestampFormat {
+	case "", "epoch":
+		cfg.EncoderConfig.EncodeTime = zapcore.EpochTimeEncoder
+	case "iso8601":
+		cfg.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	case "millis":
+		cfg.EncoderConfig.EncodeTime = zapcore.EpochMillisTimeEncoder
+	default:
+		return nil, fmt.Errorf("unknown log timestamp format %q", opts.TimestampFormat)
+	}
+
+	cfg.DisableCaller = !opts.Caller
+
+	var level zapcore.Level
+	if opts.StacktraceLevel == "" {
+		level = zapcore.ErrorLevel
+	} else if err := level.UnmarshalText([]byte(opts.StacktraceLevel)); err != nil {
+		return nil, fmt.Errorf("unknown log stacktrace level %q", opts.StacktraceLevel)
+	}
+
+	logger, err := cfg.Build(zap.AddStacktrace(level))
+	if err != nil {
+		return nil, err
+	}
+	return logger.Sugar(), nil
+}
+
+// SetupFromFlags replace the process wide logger with one built from the
+// command line flags, it must run after flag.Parse.
+func SetupFromFlags() error {
+	logger, err := NewLogger(OptionsFromFlags())
+	if err != nil {
+		return err
+	}
+	fallbackLogger = logger
+	return nil
+}
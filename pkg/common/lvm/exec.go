@@ -8,10 +8,24 @@ import (
 	"strings"
 
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/utils"
 )
 
+var runOutput utils.RunFunc = runShellOutput
+
+// SetRunFunc replaces the executor behind the reporting lvm commands and
+// returns the previous one, pass nil to restore the default. The sanity
+// harness uses it to fake the LVM tooling in process.
+func SetRunFunc(f utils.RunFunc) utils.RunFunc {
+	old := runOutput
+	if f == nil {
+		f = runShellOutput
+	}
+	runOutput = f
+	return old
+}
+
 func run(cmd string, v interface{}, extraArgs ...string) error {
-	logger := logging.GetLogger()
 	var args []string
 	if v != nil {
 		args = append(args, "--reportformat=json")
@@ -21,20 +35,29 @@ func run(cmd string, v interface{}, extraArgs ...string) error {
 	args = append(args, extraArgs...)
 	cmd = cmd + " " + strings.Join(args, " ")
 
-	c := exec.Command("sh", "-c", cmd)
-	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
-	c.Stdout = stdout
-	c.Stderr = stderr
-	if err := c.Run(); err != nil {
-		logger.Errorf("run cmd(%s) error = %s", cmd, err.Error())
+	stdout, err := runOutput(cmd)
+	if err != nil {
 		return err
 	}
 
-	stdoutbuf := stdout.Bytes()
 	if v != nil {
-		if err := json.Unmarshal(stdoutbuf, v); err != nil {
-			return fmt.Errorf("%v: [%v]", err, string(stdoutbuf))
+		if err := json.Unmarshal([]byte(stdout), v); err != nil {
+			return fmt.Errorf("%v: [%v]", err, stdout)
 		}
 	}
 	return nil
 }
+
+// runShellOutput runs cmd and returns its stdout only, stderr must stay
+// out of the JSON the callers parse.
+func runShellOutput(cmd string) (string, error) {
+	c := exec.Command("sh", "-c", cmd)
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	c.Stdout = stdout
+	c.Stderr = stderr
+	if err := c.Run(); err != nil {
+		logging.GetLogger().Errorf("run cmd(%s) error = %s", cmd, err.Error())
+		return "", err
+	}
+	return stdout.String(), nil
+}
@@ -56,6 +56,12 @@ func CreateVG(vgName string) (int, error) {
 	return len(localDeviceList), nil
 }
 
+// DeviceCandidates returns the unused local devices a vg create would
+// claim, the dry-run reporting of the agent shows them to the operator.
+func DeviceCandidates() []string {
+	return getDeviceList()
+}
+
 func getDeviceList() []string {
 	devicePathPrefix := "/dev/vd"
 	result := make([]string, 0)
@@ -129,3 +135,92 @@ func GetVGInfo(vgName string) *VGInfo {
 	}
 	return nil
 }
+
+// ListLVs return the names of the logical volumes of the vg.
+func ListLVs(vgName string) []string {
+	result := make([]string, 0)
+
+	cmd := fmt.Sprintf("%s lvs --noheadings -o lv_name %s", types.NsenterCmd, vgName)
+	out, err := utils.Run(cmd)
+	if err != nil {
+		logging.GetLogger().Errorf("list lvs of vg(%s) error = %s", vgName, err.Error())
+		return result
+	}
+
+	for _, line := range strings.Split(out, "\n") {
+		name := strings.TrimSpace(line)
+		if name != "" {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// RenameLV renames a logical volume inside the vg.
+func RenameLV(vgName, oldName, newName string) error {
+	cmd := fmt.Sprintf("%s lvrename %s %s %s", types.NsenterCmd, vgName, oldName, newName)
+	_, err := utils.Run(cmd)
+	return err
+}
+
+// CreateSnapshot takes a copy-on-write snapshot of the logical volume,
+// sizeGB bounds the changes the snapshot can absorb.
+func CreateSnapshot(vgName, lvName, snapName string, sizeGB uint64) error {
+	cmd := fmt.Sprintf("%s lvcreate -s -n %s -L %dg /dev/%s/%s", types.NsenterCmd, snapName, sizeGB, vgName, lvName)
+	_, err := utils.Run(cmd)
+	return err
+}
+
+// RemoveSnapshot drops a snapshot again.
+func RemoveSnapshot(vgName, snapName string) error {
+	cmd := fmt.Sprintf("%s lvremove -f /dev/%s/%s", types.NsenterCmd, vgName, snapName)
+	_, err := utils.Run(cmd)
+	return err
+}
+
+// GetLVDevices return the physical devices backing one lv of the vg, a
+// striped lv lists every stripe device.
+func GetLVDevices(vgName, lvName string) []string {
+	result := make([]string, 0)
+
+	cmd := fmt.Sprintf("%s lvs --noheadings -o devices %s/%s", types.NsenterCmd, vgName, lvName)
+	out, err := utils.Run(cmd)
+	if err != nil {
+		logging.GetLogger().Errorf("get lv(%s/%s) devices error = %s", vgName, lvName, err.Error())
+		return result
+	}
+
+	for _, field := range strings.FieldsFunc(out, func(r rune) bool {
+		return r == ',' || r == '\n' || r == ' ' || r == '\t'
+	}) {
+		// entries look like /dev/vdb(0), the extent suffix goes
+		device := field
+		if idx := strings.Index(device, "("); idx >= 0 {
+			device = device[:idx]
+		}
+		if device != "" {
+			result = append(result, device)
+		}
+	}
+	return result
+}
+
+// GetVGDevices return the physical devices backing the vg.
+func GetVGDevices(vgName string) []string {
+	result := make([]string, 0)
+
+	cmd := fmt.Sprintf("%s pvs --noheadings -o pv_name -S vg_name=%s", types.NsenterCmd, vgName)
+	out, err := utils.Run(cmd)
+	if err != nil {
+		logging.GetLogger().Errorf("get vg(%s) devices error = %s", vgName, err.Error())
+		return result
+	}
+
+	for _, line := range strings.Split(out, "\n") {
+		device := strings.TrimSpace(line)
+		if device != "" {
+			result = append(result, device)
+		}
+	}
+	return result
+}
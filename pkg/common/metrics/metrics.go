@@ -0,0 +1,425 @@
+package metrics
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	clientmetrics "k8s.io/client-go/tools/metrics"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/debug"
+	lverrors "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/errors"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+)
+
+const (
+	// operation name of lvm/filesystem command
+	OperationCreateVolume = "lvcreate"
+	OperationDeleteVolume = "lvremove"
+	OperationExpandVolume = "lvextend"
+	OperationFormat       = "mkfs"
+	OperationMount        = "mount"
+	OperationUnmount      = "umount"
+)
+
+const (
+	// error class label value
+	ErrorClassNotFound = "notfound"
+	ErrorClassTimeout  = "timeout"
+	ErrorClassInternal = "internal"
+)
+
+var (
+	operationDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "local_volume_operation_duration_seconds",
+			Help:    "Duration of local volume backend operations in seconds.",
+			Buckets: prometheus.ExponentialBuckets(0.01, 2, 15),
+		},
+		[]string{"operation"},
+	)
+
+	operationErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "local_volume_operation_errors_total",
+			Help: "Number of failed local volume backend operations.",
+		},
+		[]string{"operation", "error_class"},
+	)
+
+	ioProbeLatency = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "local_volume_io_probe_latency_seconds",
+			Help: "Latest io probe latency per vg device.",
+		},
+		[]string{"device"},
+	)
+
+	daysToFull = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "local_volume_projected_days_to_full",
+			Help: "Projected days until the vg of a node runs full, -1 when usage is not growing.",
+		},
+		[]string{"node"},
+	)
+
+	slowDisk = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "local_volume_slow_disk",
+			Help: "Set to 1 while the io probe latency of a vg device exceeds the threshold.",
+		},
+		[]string{"device"},
+	)
+
+	tenantProvisionedBytes = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "local_volume_tenant_provisioned_bytes",
+			Help: "Provisioned local volume bytes per namespace and tenant on this node.",
+		},
+		[]string{"node", "namespace", "tenant"},
+	)
+
+	tenantUsedBytes = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "local_volume_tenant_used_bytes",
+			Help: "Used local volume bytes per namespace and tenant on this node.",
+		},
+		[]string{"node", "namespace", "tenant"},
+	)
+
+	volumeReadBytes = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "local_volume_read_bytes_total",
+			Help: "Bytes read from the lv of a claim since device creation, from diskstats.",
+		},
+		[]string{"node", "namespace", "pvc"},
+	)
+
+	volumeWrittenBytes = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "local_volume_written_bytes_total",
+			Help: "Bytes written to the lv of a claim since device creation, from diskstats.",
+		},
+		[]string{"node", "namespace", "pvc"},
+	)
+
+	volumeReadOps = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "local_volume_read_ops_total",
+			Help: "Read operations completed on the lv of a claim since device creation.",
+		},
+		[]string{"node", "namespace", "pvc"},
+	)
+
+	volumeWriteOps = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "local_volume_write_ops_total",
+			Help: "Write operations completed on the lv of a claim since device creation.",
+		},
+		[]string{"node", "namespace", "pvc"},
+	)
+
+	volumeReadSeconds = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "local_volume_read_time_seconds_total",
+			Help: "Time spent reading on the lv of a claim since device creation.",
+		},
+		[]string{"node", "namespace", "pvc"},
+	)
+
+	volumeWriteSeconds = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "local_volume_write_time_seconds_total",
+			Help: "Time spent writing on the lv of a claim since device creation.",
+		},
+		[]string{"node", "namespace", "pvc"},
+	)
+
+	capacityExhausted = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "local_volume_nodegroup_capacity_exhausted_total",
+			Help: "Number of scheduling attempts where no node of the nodegroup could fit a local volume request.",
+		},
+		[]string{"nodegroup"},
+	)
+
+	externalBindReservations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "local_volume_external_bind_reservations_total",
+			Help: "Number of pods with local claims bound outside the extender whose reservations were backfilled.",
+		},
+		[]string{"node"},
+	)
+
+	registrationProblem = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "local_volume_registration_problem",
+			Help: "Set to 1 while a csi registration check of the node fails, labeled by check.",
+		},
+		[]string{"node", "check"},
+	)
+
+	bindVerificationFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "local_volume_bind_verification_failures_total",
+			Help: "Number of pods the extender bound whose volumes never came up within the verification window, labeled by node.",
+		},
+		[]string{"node"},
+	)
+
+	reservationExpirations = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "local_volume_reservation_expirations_total",
+			Help: "Number of capacity reservations expired because the volume was not provisioned within the ttl.",
+		},
+		[]string{"node"},
+	)
+
+	schedulingDecisionSeconds = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "local_volume_scheduling_decision_seconds",
+			Help:    "End-to-end latency from the first predicate call of a pod to its successful bind.",
+			Buckets: prometheus.ExponentialBuckets(0.005, 2, 13),
+		},
+	)
+
+	schedulingSLOViolations = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "local_volume_scheduling_slo_violations_total",
+			Help: "Number of slo evaluations where the rolling p99 scheduling decision latency exceeded the target.",
+		},
+	)
+
+	restRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "local_volume_rest_request_duration_seconds",
+			Help:    "Latency of kubernetes api requests, throttled requests show up in the upper buckets.",
+			Buckets: prometheus.ExponentialBuckets(0.001, 2, 14),
+		},
+		[]string{"verb", "resource"},
+	)
+
+	restRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "local_volume_rest_requests_total",
+			Help: "Number of kubernetes api responses by status code, 429s mean the api server throttles us.",
+		},
+		[]string{"code", "method"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(operationDuration)
+	prometheus.MustRegister(operationErrors)
+	prometheus.MustRegister(ioProbeLatency)
+	prometheus.MustRegister(daysToFull)
+	prometheus.MustRegister(slowDisk)
+	prometheus.MustRegister(tenantProvisionedBytes)
+	prometheus.MustRegister(tenantUsedBytes)
+	prometheus.MustRegister(volumeReadBytes)
+	prometheus.MustRegister(volumeWrittenBytes)
+	prometheus.MustRegister(volumeReadOps)
+	prometheus.MustRegister(volumeWriteOps)
+	prometheus.MustRegister(volumeReadSeconds)
+	prometheus.MustRegister(volumeWriteSeconds)
+	prometheus.MustRegister(capacityExhausted)
+	prometheus.MustRegister(externalBindReservations)
+	prometheus.MustRegister(registrationProblem)
+	prometheus.MustRegister(bindVerificationFailures)
+	prometheus.MustRegister(reservationExpirations)
+	prometheus.MustRegister(schedulingDecisionSeconds)
+	prometheus.MustRegister(schedulingSLOViolations)
+	prometheus.MustRegister(restRequestDuration)
+	prometheus.MustRegister(restRequests)
+
+	// Every binary of the project imports this package, so the rest
+	// clients of all of them report here.
+	clientmetrics.Register(restLatencyAdapter{}, restResultAdapter{})
+}
+
+// restLatencyAdapter feeds the client-go request latency hook into our
+// histogram. The url is reduced to the api resource, object names would
+// explode the label cardinality.
+type restLatencyAdapter struct{}
+
+func (restLatencyAdapter) Observe(verb string, u url.URL, latency time.Duration) {
+	restRequestDuration.WithLabelValues(verb, requestResource(u)).Observe(latency.Seconds())
+}
+
+// restResultAdapter feeds the client-go response code hook into our
+// counter.
+type restResultAdapter struct{}
+
+func (restResultAdapter) Increment(code, method, _ string) {
+	restRequests.WithLabelValues(code, method).Inc()
+}
+
+// requestResource reduces an api request path to resource[/subresource],
+// following the /api/<version> and /apis/<group>/<version> layouts with
+// an optional namespaces/<ns> in between.
+func requestResource(u url.URL) string {
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	i := 0
+	switch {
+	case len(parts) > 0 && parts[0] == "api":
+		i = 2
+	case len(parts) > 0 && parts[0] == "apis":
+		i = 3
+	}
+	if i+1 < len(parts) && parts[i] == "namespaces" {
+		i += 2
+	}
+	if i >= len(parts) {
+		return u.Path
+	}
+	resource := parts[i]
+	if i+2 < len(parts) {
+		// resource/<name>/<subresource>
+		resource += "/" + parts[i+2]
+	}
+	return resource
+}
+
+// RecordSchedulingDecision record the end-to-end scheduling latency of
+// one pod, from the first predicate call to the successful bind.
+func RecordSchedulingDecision(seconds float64) {
+	schedulingDecisionSeconds.Observe(seconds)
+}
+
+// RecordSchedulingSLOViolation count one evaluation where the rolling
+// p99 decision latency exceeded the target.
+func RecordSchedulingSLOViolation() {
+	schedulingSLOViolations.Inc()
+}
+
+// RecordRegistrationCheck record the latest result of one csi
+// registration check of the node.
+func RecordRegistrationCheck(node, check string, ok bool) {
+	if ok {
+		registrationProblem.WithLabelValues(node, check).Set(0)
+	} else {
+		registrationProblem.WithLabelValues(node, check).Set(1)
+	}
+}
+
+// RecordBindVerificationFailed count one bound pod whose local volumes
+// did not come up within the verification window.
+func RecordBindVerificationFailed(node string) {
+	bindVerificationFailures.WithLabelValues(node).Inc()
+}
+
+// RecordReservationExpired count reservations expired on the node.
+func RecordReservationExpired(node string, count int) {
+	reservationExpirations.WithLabelValues(node).Add(float64(count))
+}
+
+// RecordExternalBindReservation count one pod another scheduler placed
+// whose local claims had to be reserved after the fact.
+func RecordExternalBindReservation(node string) {
+	externalBindReservations.WithLabelValues(node).Inc()
+}
+
+// VolumeIOStats are the cumulative io counters of one lv as the kernel
+// reports them in diskstats.
+type VolumeIOStats struct {
+	ReadOps      float64
+	ReadBytes    float64
+	ReadSeconds  float64
+	WriteOps     float64
+	WriteBytes   float64
+	WriteSeconds float64
+}
+
+// ResetVolumeIO drops all per volume io series before a fresh collection
+// pass, so deleted volumes stop reporting.
+func ResetVolumeIO() {
+	volumeReadBytes.Reset()
+	volumeWrittenBytes.Reset()
+	volumeReadOps.Reset()
+	volumeWriteOps.Reset()
+	volumeReadSeconds.Reset()
+	volumeWriteSeconds.Reset()
+}
+
+// RecordVolumeIO record the cumulative io counters of the lv of one
+// claim.
+func RecordVolumeIO(node, namespace, pvc string, stats VolumeIOStats) {
+	volumeReadBytes.WithLabelValues(node, namespace, pvc).Set(stats.ReadBytes)
+	volumeWrittenBytes.WithLabelValues(node, namespace, pvc).Set(stats.WriteBytes)
+	volumeReadOps.WithLabelValues(node, namespace, pvc).Set(stats.ReadOps)
+	volumeWriteOps.WithLabelValues(node, namespace, pvc).Set(stats.WriteOps)
+	volumeReadSeconds.WithLabelValues(node, namespace, pvc).Set(stats.ReadSeconds)
+	volumeWriteSeconds.WithLabelValues(node, namespace, pvc).Set(stats.WriteSeconds)
+}
+
+// RecordCapacityExhausted count one scheduling attempt the nodegroup had
+// no node with enough local volume capacity for.
+func RecordCapacityExhausted(nodegroup string) {
+	capacityExhausted.WithLabelValues(nodegroup).Inc()
+}
+
+// ResetTenantUsage drops all tenant accounting series before a fresh
+// aggregation pass, so deleted claims stop being billed.
+func ResetTenantUsage() {
+	tenantProvisionedBytes.Reset()
+	tenantUsedBytes.Reset()
+}
+
+// AddTenantUsage add the bytes of one volume to the accounting of its
+// namespace and tenant.
+func AddTenantUsage(node, namespace, tenant string, provisioned, used float64) {
+	tenantProvisionedBytes.WithLabelValues(node, namespace, tenant).Add(provisioned)
+	tenantUsedBytes.WithLabelValues(node, namespace, tenant).Add(used)
+}
+
+// RecordOperation record one backend operation duration, and count the error
+// by error class if the operation failed.
+func RecordOperation(operation string, start time.Time, err error) {
+	operationDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	if err != nil {
+		operationErrors.WithLabelValues(operation, errorClass(err)).Inc()
+	}
+}
+
+func errorClass(err error) string {
+	if category, ok := lverrors.CategoryOf(err); ok {
+		return strings.ToLower(string(category))
+	}
+
+	msg := strings.ToLower(err.Error())
+	if strings.Contains(msg, "not found") || strings.Contains(msg, "failed to find") {
+		return ErrorClassNotFound
+	}
+	if strings.Contains(msg, "timeout") || strings.Contains(msg, "timed out") {
+		return ErrorClassTimeout
+	}
+	return ErrorClassInternal
+}
+
+// RecordIOProbe record the latest io probe result of one vg device.
+func RecordIOProbe(device string, latency time.Duration, slow bool) {
+	ioProbeLatency.WithLabelValues(device).Set(latency.Seconds())
+	if slow {
+		slowDisk.WithLabelValues(device).Set(1)
+	} else {
+		slowDisk.WithLabelValues(device).Set(0)
+	}
+}
+
+// RecordDaysToFull record the capacity forecast of one node.
+func RecordDaysToFull(node string, days float64) {
+	daysToFull.WithLabelValues(node).Set(days)
+}
+
+// Serve expose prometheus metrics on addr, it never returns.
+func Serve(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.Handle("/debug/dump", debug.Handler())
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		logging.GetLogger().Errorf("metrics server listen on %s error = %s", addr, err.Error())
+	}
+}
@@ -17,14 +17,8 @@ limitations under the License.
 package mounter
 
 import (
-	"errors"
-	"fmt"
 	"io"
 	"os"
-	"os/exec"
-	"strings"
-
-	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
 )
 
 // Mounter is responsible for formatting and mounting volumes
@@ -58,278 +52,11 @@ type Mounter interface {
 	HasMountRefs(mountPath string, mountRefs []string) bool
 }
 
-// TODO(arslan): this is Linux only for now. Refactor this into a package with
-// architecture specific code in the future, such as mounter_darwin.go,
-// mounter_linux.go, etc..
-type mounter struct {
-}
-
-// NewMounter returns a new mounter instance
+// NewMounter returns the mounter of the platform, volumes can only be
+// served on linux, the other platforms get a stub that always errors so
+// the binaries still compile for local development.
 func NewMounter() Mounter {
-	return &mounter{}
-}
-func (m *mounter) EnsureFolder(target string) error {
-	mdkirCmd := "mkdir"
-	_, err := exec.LookPath(mdkirCmd)
-	if err != nil {
-		if err == exec.ErrNotFound {
-			return fmt.Errorf("%q executable not found in $PATH", mdkirCmd)
-		}
-		return err
-	}
-
-	mkdirArgs := []string{"-p", target}
-	_, err = exec.Command(mdkirCmd, mkdirArgs...).CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("mkdir for folder error: %v", err)
-	}
-	return nil
-}
-
-func (m *mounter) EnsureBlock(target string) error {
-	fi, err := os.Lstat(target)
-	if err != nil && !os.IsNotExist(err) {
-		return err
-	}
-	if err == nil && fi.IsDir() {
-		os.Remove(target)
-	}
-	targetPathFile, err := os.OpenFile(target, os.O_CREATE|os.O_RDWR, 0750)
-	if err != nil {
-		logging.GetLogger().Infof("Failed to create block:%s with error: %v", target, err)
-		return fmt.Errorf("create block error: %v", err)
-	}
-	if err := targetPathFile.Close(); err != nil {
-		logging.GetLogger().Infof("Failed to close targetPath:%s with error: %v", target, err)
-		return fmt.Errorf("close block error: %v", err)
-	}
-	return nil
-}
-
-func (m *mounter) Format(source, fsType string) error {
-	mkfsCmd := fmt.Sprintf("mkfs.%s", fsType)
-
-	_, err := exec.LookPath(mkfsCmd)
-	if err != nil {
-		if err == exec.ErrNotFound {
-			return fmt.Errorf("%q executable not found in $PATH", mkfsCmd)
-		}
-		return err
-	}
-
-	mkfsArgs := []string{}
-	if fsType == "" {
-		return errors.New("fs type is not specified for formatting the volume")
-	}
-	if source == "" {
-		return errors.New("source is not specified for formatting the volume")
-	}
-	mkfsArgs = append(mkfsArgs, source)
-	if fsType == "ext4" || fsType == "ext3" {
-		mkfsArgs = []string{"-F", source}
-	}
-
-	logging.GetLogger().Infof("Format %s with fsType %s, the command is %s %v", source, fsType, mkfsCmd, mkfsArgs)
-	out, err := exec.Command(mkfsCmd, mkfsArgs...).CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("formatting disk failed: %v cmd: '%s %s' output: %q",
-			err, mkfsCmd, strings.Join(mkfsArgs, " "), string(out))
-	}
-
-	return nil
-}
-
-func (m *mounter) MountBlock(source, target string, opts ...string) error {
-	mountCmd := "mount"
-	mountArgs := []string{}
-
-	if source == "" {
-		return errors.New("source is not specified for mounting the volume")
-	}
-	if target == "" {
-		return errors.New("target is not specified for mounting the volume")
-	}
-
-	if len(opts) > 0 {
-		mountArgs = append(mountArgs, "-o", strings.Join(opts, ","))
-	}
-	mountArgs = append(mountArgs, source)
-	mountArgs = append(mountArgs, target)
-	// create target, os.Mkdirall is noop if it exists
-	_, err := os.Create(target)
-	if err != nil {
-		return err
-	}
-
-	logging.GetLogger().Infof("Mount %s to %s, the command is %s %v", source, target, mountCmd, mountArgs)
-	out, err := exec.Command(mountCmd, mountArgs...).CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("mounting failed: %v cmd: '%s %s' output: %q",
-			err, mountCmd, strings.Join(mountArgs, " "), string(out))
-	}
-	return nil
-}
-
-func (m *mounter) Mount(source, target, fsType string, opts ...string) error {
-	mountCmd := "mount"
-	mountArgs := []string{}
-
-	if fsType == "" {
-		return errors.New("fs type is not specified for mounting the volume")
-	}
-
-	if source == "" {
-		return errors.New("source is not specified for mounting the volume")
-	}
-
-	if target == "" {
-		return errors.New("target is not specified for mounting the volume")
-	}
-
-	mountArgs = append(mountArgs, "-t", fsType)
-
-	if len(opts) > 0 {
-		mountArgs = append(mountArgs, "-o", strings.Join(opts, ","))
-	}
-
-	mountArgs = append(mountArgs, source)
-	mountArgs = append(mountArgs, target)
-
-	// create target, os.Mkdirall is noop if it exists
-	err := os.MkdirAll(target, 0750)
-	if err != nil {
-		return err
-	}
-
-	logging.GetLogger().Infof("Mount %s to %s with fsType %s, the command is %s %v", source, target, fsType, mountCmd, mountArgs)
-
-	out, err := exec.Command(mountCmd, mountArgs...).CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("mounting failed: %v cmd: '%s %s' output: %q",
-			err, mountCmd, strings.Join(mountArgs, " "), string(out))
-	}
-
-	return nil
-}
-
-func (m *mounter) Unmount(target string) error {
-	umountCmd := "umount"
-	if target == "" {
-		return errors.New("target is not specified for unmounting the volume")
-	}
-
-	umountArgs := []string{target}
-
-	logging.GetLogger().Infof("Unmount %s, the command is %s %v", target, umountCmd, umountArgs)
-
-	out, err := exec.Command(umountCmd, umountArgs...).CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("unmounting failed: %v cmd: '%s %s' output: %q",
-			err, umountCmd, target, string(out))
-	}
-
-	return nil
-}
-
-func (m *mounter) IsFormatted(source string) (bool, error) {
-	if source == "" {
-		return false, errors.New("source is not specified")
-	}
-
-	fileCmd := "file"
-	_, err := exec.LookPath(fileCmd)
-	if err != nil {
-		if err == exec.ErrNotFound {
-			return false, fmt.Errorf("%q executable not found in $PATH", fileCmd)
-		}
-		return false, err
-	}
-
-	args := []string{"-sL", source}
-
-	out, err := exec.Command(fileCmd, args...).CombinedOutput()
-	if err != nil {
-		return false, fmt.Errorf("checking formatting failed: %v cmd: %q output: %q",
-			err, fileCmd, string(out))
-	}
-
-	output := strings.TrimPrefix(string(out), fmt.Sprintf("%s:", source))
-	if strings.TrimSpace(output) == "data" {
-		return false, nil
-	}
-
-	return true, nil
-}
-
-func (m *mounter) IsMounted(target string) (bool, error) {
-	if target == "" {
-		return false, errors.New("target is not specified for checking the mount")
-	}
-	findmntCmd := "grep"
-	findmntArgs := []string{target, "/proc/mounts"}
-	out, err := exec.Command(findmntCmd, findmntArgs...).CombinedOutput()
-	outStr := strings.TrimSpace(string(out))
-	if err != nil {
-		if outStr == "" {
-			return false, nil
-		}
-		return false, fmt.Errorf("checking mounted failed: %v cmd: %q output: %q",
-			err, findmntCmd, outStr)
-	}
-	if strings.Contains(outStr, target) {
-		return true, nil
-	}
-	return false, nil
-}
-
-func (m *mounter) SafePathRemove(targetPath string) error {
-	fo, err := os.Lstat(targetPath)
-	if err != nil {
-		return err
-	}
-	isMounted, err := m.IsMounted(targetPath)
-	if err != nil {
-		return err
-	}
-	if isMounted {
-		return errors.New("Path is mounted, not remove: " + targetPath)
-	}
-	if fo.IsDir() {
-		empty, err := IsDirEmpty(targetPath)
-		if err != nil {
-			return errors.New("Check path empty error: " + targetPath + err.Error())
-		}
-		if !empty {
-			return errors.New("Cannot remove Path not empty: " + targetPath)
-		}
-	}
-	err = os.Remove(targetPath)
-	if err != nil {
-		return err
-	}
-	return nil
-}
-
-func (m *mounter) HasMountRefs(mountPath string, mountRefs []string) bool {
-	count := 0
-	for _, refPath := range mountRefs {
-		if !strings.Contains(refPath, mountPath) {
-			if strings.HasPrefix(mountPath, "/var/lib/kubelet/") {
-				mountPathSuffix := strings.Replace(mountPath, "/var/lib/kubelet/", "", 1)
-				refPathSuffix := strings.Replace(refPath, "/var/lib/container/kubelet/", "", 1)
-				if refPathSuffix != mountPathSuffix {
-					count = count + 1
-				}
-			} else if strings.HasPrefix(mountPath, "/var/lib/container/kubelet/") {
-				mountPathSuffix := strings.Replace(mountPath, "/var/lib/container/kubelet/", "", 1)
-				refPathSuffix := strings.Replace(refPath, "/var/lib/kubelet/", "", 1)
-				if refPathSuffix != mountPathSuffix {
-					count = count + 1
-				}
-			}
-		}
-	}
-	return count > 0
+	return newMounter()
 }
 
 // IsDirEmpty return status of dir empty or not
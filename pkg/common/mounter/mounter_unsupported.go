@@ -0,0 +1,76 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mounter
+
+import (
+	"errors"
+	"runtime"
+)
+
+// unsupportedMounter keeps the binaries compiling on darwin for local
+// development, every operation fails.
+type unsupportedMounter struct {
+}
+
+func newMounter() Mounter {
+	return &unsupportedMounter{}
+}
+
+var errUnsupported = errors.New("local volume mounter is not supported on " + runtime.GOOS)
+
+func (m *unsupportedMounter) EnsureFolder(target string) error {
+	return errUnsupported
+}
+
+func (m *unsupportedMounter) EnsureBlock(target string) error {
+	return errUnsupported
+}
+
+func (m *unsupportedMounter) Format(source, fsType string) error {
+	return errUnsupported
+}
+
+func (m *unsupportedMounter) Mount(source, target, fsType string, options ...string) error {
+	return errUnsupported
+}
+
+func (m *unsupportedMounter) MountBlock(source, target string, options ...string) error {
+	return errUnsupported
+}
+
+func (m *unsupportedMounter) Unmount(target string) error {
+	return errUnsupported
+}
+
+func (m *unsupportedMounter) IsFormatted(source string) (bool, error) {
+	return false, errUnsupported
+}
+
+func (m *unsupportedMounter) IsMounted(target string) (bool, error) {
+	return false, errUnsupported
+}
+
+func (m *unsupportedMounter) SafePathRemove(target string) error {
+	return errUnsupported
+}
+
+func (m *unsupportedMounter) HasMountRefs(mountPath string, mountRefs []string) bool {
+	return false
+}
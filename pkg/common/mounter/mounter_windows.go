@@ -0,0 +1,103 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mounter
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// windowsMounter publishes directory backed volumes. Windows has no
+// bind mounts for plain directories, a publish is a symlink from the
+// target path to the volume directory, which is what kubelet expects
+// of windows csi plugins.
+type windowsMounter struct {
+}
+
+func newMounter() Mounter {
+	return &windowsMounter{}
+}
+
+func (m *windowsMounter) EnsureFolder(target string) error {
+	return os.MkdirAll(target, 0750)
+}
+
+func (m *windowsMounter) EnsureBlock(target string) error {
+	return errors.New("block volumes are not supported on windows")
+}
+
+// Format is a no-op, the volume directory carries the filesystem of its
+// ntfs parent.
+func (m *windowsMounter) Format(source, fsType string) error {
+	return nil
+}
+
+func (m *windowsMounter) Mount(source, target, fsType string, options ...string) error {
+	// kubelet pre-creates the target directory, the symlink can only be
+	// created in its place
+	if info, err := os.Lstat(target); err == nil && info.IsDir() {
+		if err := os.Remove(target); err != nil {
+			return err
+		}
+	}
+	return os.Symlink(source, target)
+}
+
+func (m *windowsMounter) MountBlock(source, target string, options ...string) error {
+	return errors.New("block volumes are not supported on windows")
+}
+
+func (m *windowsMounter) Unmount(target string) error {
+	if _, err := os.Lstat(target); os.IsNotExist(err) {
+		return nil
+	}
+	return os.Remove(target)
+}
+
+func (m *windowsMounter) IsFormatted(source string) (bool, error) {
+	return true, nil
+}
+
+func (m *windowsMounter) IsMounted(target string) (bool, error) {
+	info, err := os.Lstat(target)
+	if err != nil {
+		return false, err
+	}
+	return info.Mode()&os.ModeSymlink != 0, nil
+}
+
+func (m *windowsMounter) SafePathRemove(target string) error {
+	info, err := os.Lstat(target)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		return os.Remove(target)
+	}
+	if empty, err := IsDirEmpty(target); err != nil || !empty {
+		return fmt.Errorf("path %s is not an empty directory, not removing", target)
+	}
+	return os.Remove(target)
+}
+
+func (m *windowsMounter) HasMountRefs(mountPath string, mountRefs []string) bool {
+	return false
+}
@@ -0,0 +1,170 @@
+// Package rbac defines the minimal api permissions of every binary in
+// Go, the deploy manifests are generated from these specs so the
+// deployed roles cannot drift from what the code actually calls and no
+// component has to run with a broad cluster-admin binding.
+package rbac
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/apis/storage"
+)
+
+// Component is one deployable binary together with the api access it
+// needs, nothing more.
+type Component struct {
+	// Name distinguishes the generated objects, the service account is
+	// named local-volume-<name>-service-account.
+	Name string
+	// Rules is the minimal rule set of the binary.
+	Rules []rbacv1.PolicyRule
+}
+
+var (
+	// Driver covers the csi plugin and its sidecars (registrar,
+	// provisioner, resizer), the sidecars own most of the pv surface.
+	Driver = Component{
+		Name: "csi-driver",
+		Rules: []rbacv1.PolicyRule{
+			rule("", []string{"persistentvolumes"}, "get", "list", "watch", "create", "delete", "update", "patch"),
+			rule("", []string{"persistentvolumeclaims"}, "get", "list", "watch", "update"),
+			rule("", []string{"nodes"}, "get", "list", "watch"),
+			rule("", []string{"secrets"}, "get", "list"),
+			rule("", []string{"events"}, "get", "list", "watch", "create", "update", "patch"),
+			// leader election of the sidecars
+			rule("", []string{"endpoints", "configmaps"}, "get", "list", "watch", "create", "update", "delete"),
+			rule("storage.k8s.io", []string{"storageclasses", "csinodes"}, "get", "list", "watch"),
+			rule("storage.k8s.io", []string{"volumeattachments"}, "get", "list", "watch", "update"),
+			rule("snapshot.storage.k8s.io", []string{"volumesnapshotclasses", "volumesnapshots"}, "get", "list", "watch", "update"),
+			rule("snapshot.storage.k8s.io", []string{"volumesnapshotcontents"}, "get", "list", "watch", "create", "update", "delete"),
+			rule(storage.GroupName, []string{"localvolumes"}, "get", "list", "watch"),
+		},
+	}
+
+	// Agent covers the node agent, it only ever writes the LocalVolume
+	// of its own node and the labels of its own node.
+	Agent = Component{
+		Name: "csi-agent",
+		Rules: []rbacv1.PolicyRule{
+			rule(storage.GroupName, []string{"localvolumes"}, "get", "list", "watch", "create"),
+			rule(storage.GroupName, []string{"localvolumes/status"}, "get", "update", "patch"),
+			rule("", []string{"persistentvolumes"}, "get", "list", "watch", "update", "delete"),
+			rule("", []string{"persistentvolumeclaims"}, "get", "list", "watch"),
+			// fencing flips the fenced condition on claims
+			rule("", []string{"persistentvolumeclaims/status"}, "update", "patch"),
+			rule("", []string{"pods"}, "get", "list", "watch"),
+			// node label discovery
+			rule("", []string{"nodes"}, "get", "list", "watch", "update"),
+			rule("", []string{"events"}, "create", "patch"),
+			rule("storage.k8s.io", []string{"storageclasses"}, "get", "list", "watch"),
+			rule("apiextensions.k8s.io", []string{"customresourcedefinitions"}, "get", "list", "watch", "create"),
+		},
+	}
+
+	// Scheduler covers the extender, it reads capacity and reserves it,
+	// and binds pods unless running in no-bind mode.
+	Scheduler = Component{
+		Name: "scheduler",
+		Rules: []rbacv1.PolicyRule{
+			rule(storage.GroupName, []string{"localvolumes"}, "get", "list", "watch"),
+			rule(storage.GroupName, []string{"localvolumes/status"}, "get", "update", "patch"),
+			rule("", []string{"pods"}, "get", "list", "watch"),
+			rule("", []string{"pods/binding"}, "create"),
+			rule("", []string{"persistentvolumeclaims"}, "get", "list", "watch"),
+			rule("", []string{"events"}, "create", "patch"),
+			rule("storage.k8s.io", []string{"storageclasses"}, "get", "list", "watch"),
+		},
+	}
+
+	// ControllerManager covers the cluster side controllers, stale node
+	// cleanup is the only component allowed to delete a LocalVolume.
+	ControllerManager = Component{
+		Name: "controller-manager",
+		Rules: []rbacv1.PolicyRule{
+			rule(storage.GroupName, []string{"localvolumes"}, "get", "list", "watch", "create", "delete"),
+			rule(storage.GroupName, []string{"localvolumes/status"}, "get", "update", "patch"),
+			rule("", []string{"persistentvolumes"}, "get", "list", "watch", "update", "delete"),
+			rule("", []string{"persistentvolumeclaims"}, "get", "list", "watch"),
+			rule("", []string{"persistentvolumeclaims/status"}, "update", "patch"),
+			rule("", []string{"pods"}, "get", "list", "watch"),
+			rule("", []string{"nodes"}, "get", "list", "watch", "update"),
+			rule("", []string{"events"}, "create", "patch"),
+			rule("storage.k8s.io", []string{"storageclasses"}, "get", "list", "watch"),
+		},
+	}
+
+	// Components lists every component in deploy order.
+	Components = []Component{Driver, Agent, Scheduler, ControllerManager}
+)
+
+// ServiceAccountName is the name of the service account of the
+// component.
+func (c Component) ServiceAccountName() string {
+	return fmt.Sprintf("local-volume-%s-service-account", c.Name)
+}
+
+func (c Component) roleName() string {
+	return "local-volume-" + c.Name
+}
+
+// Objects builds the service account, cluster role and binding of the
+// component in the given namespace.
+func (c Component) Objects(namespace string) (*corev1.ServiceAccount, *rbacv1.ClusterRole, *rbacv1.ClusterRoleBinding) {
+	sa := &corev1.ServiceAccount{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      c.ServiceAccountName(),
+			Namespace: namespace,
+		},
+	}
+	role := &rbacv1.ClusterRole{
+		TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRole"},
+		ObjectMeta: metav1.ObjectMeta{Name: c.roleName()},
+		Rules:      c.Rules,
+	}
+	binding := &rbacv1.ClusterRoleBinding{
+		TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "ClusterRoleBinding"},
+		ObjectMeta: metav1.ObjectMeta{Name: c.roleName()},
+		Subjects: []rbacv1.Subject{{
+			Kind:      "ServiceAccount",
+			Name:      c.ServiceAccountName(),
+			Namespace: namespace,
+		}},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     c.roleName(),
+		},
+	}
+	return sa, role, binding
+}
+
+// Manifest renders the rbac objects of the component as yaml documents.
+func (c Component) Manifest(namespace string) (string, error) {
+	sa, role, binding := c.Objects(namespace)
+	docs := make([]string, 0, 3)
+	for _, obj := range []interface{}{sa, role, binding} {
+		out, err := yaml.Marshal(obj)
+		if err != nil {
+			return "", err
+		}
+		// the zero timestamp of the typed objects is noise in a manifest
+		doc := strings.Replace(string(out), "  creationTimestamp: null\n", "", 1)
+		docs = append(docs, doc)
+	}
+	return strings.Join(docs, "\n---\n\n"), nil
+}
+
+func rule(group string, resources []string, verbs ...string) rbacv1.PolicyRule {
+	return rbacv1.PolicyRule{
+		APIGroups: []string{group},
+		Resources: resources,
+		Verbs:     verbs,
+	}
+}
@@ -1,13 +1,18 @@
 package sharemain
 
 import (
+	"context"
 	"flag"
 	"log"
 	"os"
 
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/config"
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/controller"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/debug"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/features"
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/kubeconfig"
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/metrics"
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/signals"
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/injection"
 
@@ -15,23 +20,99 @@ import (
 	"k8s.io/client-go/rest"
 )
 
+// NamedController pairs a ControllerConstructor with the name used for
+// its enable flag in the controller manager.
+type NamedController struct {
+	Name        string
+	Constructor controller.ControllerConstructor
+}
+
 func Main(ctors ...controller.ControllerConstructor) {
+	cfg := parseFlags()
+	MainWithConfig(cfg, ctors...)
+}
+
+// MainNamed is Main with one -enable-<name> flag per controller, so the
+// controller manager can toggle controllers per cluster.
+func MainNamed(ctors ...NamedController) {
+	enabled := make(map[string]*bool, len(ctors))
+	for _, c := range ctors {
+		enabled[c.Name] = flag.Bool("enable-"+c.Name, true, "Enable the "+c.Name+" controller")
+	}
+
+	cfg := parseFlags()
+
+	selected := make([]controller.ControllerConstructor, 0, len(ctors))
+	for _, c := range ctors {
+		if !*enabled[c.Name] {
+			logging.GetLogger().Infof("controller(%s) is disabled", c.Name)
+			continue
+		}
+		selected = append(selected, c.Constructor)
+	}
+	MainWithConfig(cfg, selected...)
+}
+
+func parseFlags() *rest.Config {
 	var (
-		masterURL = flag.String("master", "", "The address of the Kubernetes API server. Overrides any value in kubeconfig. Only required if out-of-cluster.")
-		config    = flag.String("kubeconfig", "", "Path to a kubeconfig. Only required if out-of-cluster.")
+		masterURL   = flag.String("master", "", "The address of the Kubernetes API server. Overrides any value in kubeconfig. Only required if out-of-cluster.")
+		kubeConfig  = flag.String("kubeconfig", "", "Path to a kubeconfig. Only required if out-of-cluster.")
+		metricsAddr = flag.String("metrics-addr", ":9101", "The address the prometheus metrics endpoint binds to")
+		configFile  = flag.String("config", "", "Path to a LocalVolumeConfiguration yaml file")
 	)
 	flag.Parse()
 
-	cfg, err := kubeconfig.GetConfig(*masterURL, *config)
+	if *configFile != "" {
+		conf, err := config.Load(*configFile)
+		if err != nil {
+			log.Fatal("Error loading config file ", err)
+		}
+		config.Apply("metrics-addr", conf.Agent.MetricsAddress)
+		config.Apply("slow-io-probe-interval", conf.Agent.SlowIOProbeInterval.Duration.String())
+		config.Apply("slow-io-threshold", conf.Agent.SlowIOThreshold.Duration.String())
+		config.ApplyClientConnection(conf)
+		if err := features.SetFromMap(conf.FeatureGates); err != nil {
+			log.Fatal("Error applying feature gates ", err)
+		}
+	}
+	if err := features.Setup(); err != nil {
+		log.Fatal("Error applying feature gates ", err)
+	}
+
+	go metrics.Serve(*metricsAddr)
+
+	if err := logging.SetupFromFlags(); err != nil {
+		log.Fatal("Error configuring logging", err)
+	}
+
+	cfg, err := kubeconfig.GetConfig(*masterURL, *kubeConfig)
 	if err != nil {
 		log.Fatal("Error building kubeconfig", err)
 	}
-	MainWithConfig(cfg, ctors...)
+	return cfg
+}
+
+// ctxDecorators are applied to the root context before the informers
+// are built, see DecorateContext.
+var ctxDecorators []func(context.Context) context.Context
+
+// DecorateContext registers a function applied to the root context of
+// the binary before the informers are built, e.g. to scope informers
+// with injection.WithInformerSelector. Flags are parsed by then, the
+// function may read them.
+func DecorateContext(f func(context.Context) context.Context) {
+	ctxDecorators = append(ctxDecorators, f)
 }
 
 func MainWithConfig(cfg *rest.Config, ctors ...controller.ControllerConstructor) {
 	// context
-	ctx := signals.NewContext()
+	var ctx context.Context = signals.NewContext()
+	for _, decorate := range ctxDecorators {
+		ctx = decorate(ctx)
+	}
+
+	// debug dump on SIGUSR1
+	debug.SetupDumpHandler()
 
 	// logging
 	logger := logging.FromContext(ctx)
@@ -60,6 +141,10 @@ func MainWithConfig(cfg *rest.Config, ctors ...controller.ControllerConstructor)
 	eg, egCtx := errgroup.WithContext(ctx)
 	<-egCtx.Done()
 
+	// let the registered hooks leave a consistent state behind before
+	// the restart, bounded by the shutdown grace
+	runShutdownHooks()
+
 	if err := eg.Wait(); err != nil {
 		logger.Error(err)
 		os.Exit(0)
@@ -0,0 +1,56 @@
+package sharemain
+
+import (
+	"context"
+	"flag"
+	"sync"
+	"time"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+)
+
+var shutdownGrace = flag.Duration("shutdown-grace", 30*time.Second,
+	"Bound on running the shutdown hooks after a termination signal, hooks still pending afterwards are abandoned")
+
+var (
+	shutdownMu    sync.Mutex
+	shutdownHooks []namedHook
+)
+
+type namedHook struct {
+	name string
+	hook func(context.Context)
+}
+
+// OnShutdown registers a hook that runs after the termination signal and
+// before the process exits, bounded by the shutdown grace. Controllers
+// use it to leave a consistent state behind for their restart.
+func OnShutdown(name string, hook func(context.Context)) {
+	shutdownMu.Lock()
+	defer shutdownMu.Unlock()
+	shutdownHooks = append(shutdownHooks, namedHook{name: name, hook: hook})
+}
+
+// runShutdownHooks runs the registered hooks in registration order, all
+// of them share the one grace deadline.
+func runShutdownHooks() {
+	shutdownMu.Lock()
+	hooks := shutdownHooks
+	shutdownMu.Unlock()
+	if len(hooks) == 0 {
+		return
+	}
+
+	logger := logging.GetLogger()
+	ctx, cancel := context.WithTimeout(context.Background(), *shutdownGrace)
+	defer cancel()
+
+	for _, h := range hooks {
+		if ctx.Err() != nil {
+			logger.Warnf("shutdown grace over, hook(%s) skipped", h.name)
+			continue
+		}
+		logger.Infof("running shutdown hook(%s)", h.name)
+		h.hook(ctx)
+	}
+}
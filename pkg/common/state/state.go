@@ -0,0 +1,109 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package state persists the volume operations in flight on a node
+// into a small journal file. After a crash the pending entries are
+// replayed, half-created lvs are rolled back and half-deleted ones are
+// rolled forward instead of leaking.
+package state
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Operations recorded in the journal.
+const (
+	OpCreating = "creating"
+	OpDeleting = "deleting"
+)
+
+// Store journals one operation per volume, every change is written
+// through to the file before the lvm command runs.
+type Store struct {
+	mu   sync.Mutex
+	path string
+	ops  map[string]string
+}
+
+// Open loads the journal at path, a missing file starts empty.
+func Open(path string) (*Store, error) {
+	s := &Store{
+		path: path,
+		ops:  make(map[string]string),
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &s.ops); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Begin journals the operation for the volume before it runs.
+func (s *Store) Begin(volumeID, op string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ops[volumeID] = op
+	return s.persist()
+}
+
+// Finish drops the journal entry of the volume after the operation
+// completed.
+func (s *Store) Finish(volumeID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.ops, volumeID)
+	return s.persist()
+}
+
+// Pending returns a copy of the journaled operations, used for replay
+// on startup.
+func (s *Store) Pending() map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make(map[string]string, len(s.ops))
+	for id, op := range s.ops {
+		result[id] = op
+	}
+	return result
+}
+
+// persist writes the journal atomically, callers hold the lock.
+func (s *Store) persist() error {
+	data, err := json.Marshal(s.ops)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStoreRoundTrip(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "lv-state")
+	if err != nil {
+		t.Fatalf("create temp dir error = %s", err.Error())
+	}
+	defer os.RemoveAll(tmp)
+	path := filepath.Join(tmp, "journal", "state.json")
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("open error = %s", err.Error())
+	}
+	if len(s.Pending()) != 0 {
+		t.Fatalf("fresh store is not empty: %v", s.Pending())
+	}
+
+	if err := s.Begin("vol-1", OpCreating); err != nil {
+		t.Fatalf("begin error = %s", err.Error())
+	}
+	if err := s.Begin("vol-2", OpDeleting); err != nil {
+		t.Fatalf("begin error = %s", err.Error())
+	}
+	if err := s.Finish("vol-1"); err != nil {
+		t.Fatalf("finish error = %s", err.Error())
+	}
+
+	// a second open simulates the restart, only the unfinished entry
+	// comes back
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("reopen error = %s", err.Error())
+	}
+	pending := reopened.Pending()
+	if len(pending) != 1 || pending["vol-2"] != OpDeleting {
+		t.Fatalf("pending after reopen = %v, want vol-2 %s", pending, OpDeleting)
+	}
+
+	if err := reopened.Finish("vol-2"); err != nil {
+		t.Fatalf("finish error = %s", err.Error())
+	}
+	if len(reopened.Pending()) != 0 {
+		t.Fatalf("pending after finish = %v, want empty", reopened.Pending())
+	}
+}
+
+func TestStoreOpenCorrupt(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "lv-state")
+	if err != nil {
+		t.Fatalf("create temp dir error = %s", err.Error())
+	}
+	defer os.RemoveAll(tmp)
+	path := filepath.Join(tmp, "state.json")
+	if err := ioutil.WriteFile(path, []byte("not json"), 0644); err != nil {
+		t.Fatalf("write error = %s", err.Error())
+	}
+
+	if _, err := Open(path); err == nil {
+		t.Fatal("open of a corrupt journal did not fail")
+	}
+}
@@ -0,0 +1,228 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package status implements patch based updates of the LocalVolume
+// status subresource, so concurrent writers of unrelated fields do not
+// run into the conflict retries of UpdateStatus.
+package status
+
+import (
+	"encoding/json"
+	"time"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/apis/storage/v1alpha1"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/clientset/versioned"
+)
+
+// Field managers of the status writers, distinct managers own distinct
+// fields under server-side apply.
+const (
+	AgentFieldManager     = "local-volume-agent"
+	SchedulerFieldManager = "local-volume-scheduler"
+)
+
+// Patch sends the diff between old and new as a json merge patch on the
+// status subresource, it is a no-op when nothing changed.
+func Patch(client versioned.Interface, old, new *v1alpha1.LocalVolume) (*v1alpha1.LocalVolume, error) {
+	oldData, err := json.Marshal(old)
+	if err != nil {
+		return nil, err
+	}
+	newData, err := json.Marshal(new)
+	if err != nil {
+		return nil, err
+	}
+	patch, err := jsonpatch.CreateMergePatch(oldData, newData)
+	if err != nil {
+		return nil, err
+	}
+	if string(patch) == "{}" {
+		return new, nil
+	}
+	return client.LocalV1alpha1().LocalVolumes(old.Namespace).Patch(old.Name, types.MergePatchType, patch, "status")
+}
+
+// NewReservationStamp renders the creation time a reservation entry in
+// the preAllocated map carries as its value, the expiry controller ages
+// entries by it. Entries with an empty value predate the stamps and are
+// never expired.
+func NewReservationStamp() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
+
+// ReservationAge parses the stamp of a preAllocated entry, the second
+// return is false for unstamped or unparseable values.
+func ReservationAge(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	stamp, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return 0, false
+	}
+	return time.Since(stamp), true
+}
+
+// AddPreAllocated inserts the given keys into the preAllocated map with
+// a merge patch touching only those entries, concurrent binds to the
+// same node do not conflict on the rest of the status.
+func AddPreAllocated(client versioned.Interface, name string, keys []string) error {
+	entries := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		entries[key] = NewReservationStamp()
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{
+			"preAllocated": entries,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = client.LocalV1alpha1().LocalVolumes(corev1.NamespaceDefault).Patch(name, types.MergePatchType, patch, "status")
+	return err
+}
+
+// RemovePreAllocated deletes the given keys from the preAllocated map
+// with a merge patch. Entries applied by another field manager can not
+// be removed through server-side apply, the merge patch can.
+func RemovePreAllocated(client versioned.Interface, name string, keys []string) error {
+	entries := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		entries[key] = nil
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{
+			"preAllocated": entries,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = client.LocalV1alpha1().LocalVolumes(corev1.NamespaceDefault).Patch(name, types.MergePatchType, patch, "status")
+	return err
+}
+
+// AddRetained records logical volumes kept by a Retain reclaim policy
+// in the retained map, the value of an entry is the claim it last
+// served.
+func AddRetained(client versioned.Interface, name string, entries map[string]string) error {
+	retained := make(map[string]interface{}, len(entries))
+	for key, val := range entries {
+		retained[key] = val
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{
+			"retained": retained,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = client.LocalV1alpha1().LocalVolumes(corev1.NamespaceDefault).Patch(name, types.MergePatchType, patch, "status")
+	return err
+}
+
+// RemoveRetained deletes the given keys from the retained map, used
+// once a retained volume is re-adopted or removed for good.
+func RemoveRetained(client versioned.Interface, name string, keys []string) error {
+	retained := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		retained[key] = nil
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{
+			"retained": retained,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = client.LocalV1alpha1().LocalVolumes(corev1.NamespaceDefault).Patch(name, types.MergePatchType, patch, "status")
+	return err
+}
+
+// AppendAllocations appends records to the bounded allocation log of
+// the node, the oldest entries fall off the front. The log is a support
+// aid, a record lost to a concurrent writer of the same list is
+// acceptable and not retried.
+func AppendAllocations(client versioned.Interface, name string, records ...v1alpha1.AllocationRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	lv, err := client.LocalV1alpha1().LocalVolumes(corev1.NamespaceDefault).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	log := append(append([]v1alpha1.AllocationRecord{}, lv.Status.AllocationLog...), records...)
+	if len(log) > v1alpha1.AllocationLogLimit {
+		log = log[len(log)-v1alpha1.AllocationLogLimit:]
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{
+			"allocationLog": log,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = client.LocalV1alpha1().LocalVolumes(corev1.NamespaceDefault).Patch(name, types.MergePatchType, patch, "status")
+	return err
+}
+
+// Apply server-side applies the given status under its own field
+// manager. Writers with distinct managers own distinct fields, so the
+// agent and the scheduler stop clobbering each other.
+func Apply(client versioned.Interface, name, fieldManager string, status *v1alpha1.LocalVolumeStatus) (*v1alpha1.LocalVolume, error) {
+	body := map[string]interface{}{
+		"apiVersion": v1alpha1.SchemeGroupVersion.String(),
+		"kind":       "LocalVolume",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": corev1.NamespaceDefault,
+		},
+		"status": status,
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	// the generated clientset of this tree predates typed apply
+	// configurations, go through the rest client directly.
+	result := &v1alpha1.LocalVolume{}
+	err = client.LocalV1alpha1().RESTClient().Patch(types.ApplyPatchType).
+		Namespace(corev1.NamespaceDefault).
+		Resource("localvolumes").
+		Name(name).
+		SubResource("status").
+		Param("fieldManager", fieldManager).
+		Param("force", "true").
+		Body(data).
+		Do().
+		Into(result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/apis/storage/v1alpha1"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/clientset/versioned/fake"
+)
+
+func TestPatch(t *testing.T) {
+	lv := &v1alpha1.LocalVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1", Namespace: corev1.NamespaceDefault},
+		Status: v1alpha1.LocalVolumeStatus{
+			TotalSize:    100,
+			FreeSize:     80,
+			PreAllocated: map[string]string{"pvc-a": ""},
+		},
+	}
+	client := fake.NewSimpleClientset(lv)
+
+	copylv := lv.DeepCopy()
+	copylv.Status.FreeSize = 70
+	copylv.Status.PreAllocated["pvc-b"] = ""
+
+	result, err := Patch(client, lv, copylv)
+	if err != nil {
+		t.Fatalf("patch error = %s", err.Error())
+	}
+	if result.Status.FreeSize != 70 {
+		t.Errorf("free size = %d, want 70", result.Status.FreeSize)
+	}
+	if _, ok := result.Status.PreAllocated["pvc-b"]; !ok {
+		t.Error("pvc-b must be pre allocated")
+	}
+
+	got, err := client.LocalV1alpha1().LocalVolumes(corev1.NamespaceDefault).Get("node1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get error = %s", err.Error())
+	}
+	if got.Status.TotalSize != 100 {
+		t.Errorf("total size = %d, want 100", got.Status.TotalSize)
+	}
+	if len(got.Status.PreAllocated) != 2 {
+		t.Errorf("pre allocated = %v, want 2 entries", got.Status.PreAllocated)
+	}
+}
+
+func TestAddPreAllocated(t *testing.T) {
+	lv := &v1alpha1.LocalVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1", Namespace: corev1.NamespaceDefault},
+		Status: v1alpha1.LocalVolumeStatus{
+			FreeSize:     80,
+			PreAllocated: map[string]string{"pvc-a": ""},
+		},
+	}
+	client := fake.NewSimpleClientset(lv)
+
+	if err := AddPreAllocated(client, "node1", []string{"pvc-b"}); err != nil {
+		t.Fatalf("add error = %s", err.Error())
+	}
+
+	got, err := client.LocalV1alpha1().LocalVolumes(corev1.NamespaceDefault).Get("node1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get error = %s", err.Error())
+	}
+	if len(got.Status.PreAllocated) != 2 {
+		t.Errorf("pre allocated = %v, want 2 entries", got.Status.PreAllocated)
+	}
+	if got.Status.FreeSize != 80 {
+		t.Errorf("free size = %d, want untouched 80", got.Status.FreeSize)
+	}
+}
+
+func TestRemovePreAllocated(t *testing.T) {
+	lv := &v1alpha1.LocalVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1", Namespace: corev1.NamespaceDefault},
+		Status: v1alpha1.LocalVolumeStatus{
+			FreeSize:     80,
+			PreAllocated: map[string]string{"pvc-a": "", "pvc-b": ""},
+		},
+	}
+	client := fake.NewSimpleClientset(lv)
+
+	if err := RemovePreAllocated(client, "node1", []string{"pvc-a"}); err != nil {
+		t.Fatalf("remove error = %s", err.Error())
+	}
+
+	got, err := client.LocalV1alpha1().LocalVolumes(corev1.NamespaceDefault).Get("node1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get error = %s", err.Error())
+	}
+	if _, ok := got.Status.PreAllocated["pvc-a"]; ok {
+		t.Error("pvc-a must be removed")
+	}
+	if _, ok := got.Status.PreAllocated["pvc-b"]; !ok {
+		t.Error("pvc-b must be kept")
+	}
+	if got.Status.FreeSize != 80 {
+		t.Errorf("free size = %d, want untouched 80", got.Status.FreeSize)
+	}
+}
+
+func TestPatchNoChange(t *testing.T) {
+	lv := &v1alpha1.LocalVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1", Namespace: corev1.NamespaceDefault},
+		Status:     v1alpha1.LocalVolumeStatus{FreeSize: 80},
+	}
+	client := fake.NewSimpleClientset(lv)
+
+	if _, err := Patch(client, lv, lv.DeepCopy()); err != nil {
+		t.Fatalf("patch error = %s", err.Error())
+	}
+	if len(client.Actions()) != 0 {
+		t.Errorf("actions = %v, want none for a no-op patch", client.Actions())
+	}
+}
+
+func TestReservationAge(t *testing.T) {
+	if _, ok := ReservationAge(""); ok {
+		t.Error("empty value must report no age")
+	}
+	if _, ok := ReservationAge("not-a-time"); ok {
+		t.Error("unparseable value must report no age")
+	}
+	age, ok := ReservationAge(NewReservationStamp())
+	if !ok {
+		t.Fatal("fresh stamp must report an age")
+	}
+	if age < 0 || age > time.Minute {
+		t.Errorf("age of a fresh stamp = %v, want close to zero", age)
+	}
+}
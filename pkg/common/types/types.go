@@ -1,6 +1,8 @@
 package types
 
 import (
+	"fmt"
+	"strconv"
 	"strings"
 
 	"k8s.io/api/core/v1"
@@ -32,6 +34,139 @@ const (
 	LocalVolumeGCTag = "finalizers.localvolume.kubernetes.io/gc"
 )
 
+const (
+	// WarmPoolLVPrefix prefixes the pre-provisioned logical volumes of
+	// the warm pool, pooled names look like warm-pool-10g-1.
+	WarmPoolLVPrefix = "warm-pool-"
+)
+
+// MakeWarmPoolLVName builds the name of a pooled lv of the given size
+// in GB.
+func MakeWarmPoolLVName(sizeGB uint64, index int) string {
+	return fmt.Sprintf("%s%dg-%d", WarmPoolLVPrefix, sizeGB, index)
+}
+
+// WarmPoolLVSize parses the size in GB out of a pooled lv name, the
+// second return is false when the name is not a pooled lv.
+func WarmPoolLVSize(name string) (uint64, bool) {
+	if !strings.HasPrefix(name, WarmPoolLVPrefix) {
+		return 0, false
+	}
+	rest := strings.TrimPrefix(name, WarmPoolLVPrefix)
+	end := strings.Index(rest, "g-")
+	if end <= 0 {
+		return 0, false
+	}
+	size, err := strconv.ParseUint(rest[:end], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return size, true
+}
+
+const (
+	// LocalVolumeCordonAnnotation marks the local volume storage of a
+	// node as unschedulable when set to "true".
+	LocalVolumeCordonAnnotation = "local.volume.csi.kubernetes.io/cordoned"
+	// LocalVolumeRescanAnnotation carries the timestamp of the last
+	// manual rescan request of a node.
+	LocalVolumeRescanAnnotation = "local.volume.csi.kubernetes.io/rescan-requested-at"
+	// DiskPressureTaintKey is the default taint put on a node whose vg
+	// runs critically full or whose disk is failing.
+	DiskPressureTaintKey = "local.volume.csi.kubernetes.io/disk-pressure"
+	// DiskPressureAnnotation marks the pods with local volumes on a node
+	// under disk pressure, descheduler policies can select on it.
+	DiskPressureAnnotation = "local.volume.csi.kubernetes.io/disk-pressure"
+	// SchedulingExplanationAnnotation on a pod carries the per node
+	// capacity numbers of the last failed scheduling attempts, users read
+	// it instead of the extender logs.
+	SchedulingExplanationAnnotation = "local.volume.csi.kubernetes.io/scheduling-explanation"
+	// MaxSizeParam is the storage class parameter capping the size of a
+	// single volume, the driver and the claim webhook both enforce it.
+	MaxSizeParam = "maxSize"
+	// DefaultStorageClassLabel on a namespace names the local volume
+	// storage class claims without a class default to there, the cluster
+	// wide default stays untouched.
+	DefaultStorageClassLabel = "local.volume.csi.kubernetes.io/default-storage-class"
+	// ForceDrainAnnotation set to "true" on a pod lets an eviction pass
+	// the drain webhook although local data has no migration yet.
+	ForceDrainAnnotation = "local.volume.csi.kubernetes.io/force-drain"
+	// ReservedCapacityAnnotation holds gigabytes of the vg set aside
+	// for system workloads on the node, the predicate keeps general
+	// pods out of the slice.
+	ReservedCapacityAnnotation = "local.volume.csi.kubernetes.io/reserved-capacity"
+	// ReservedForAnnotation lists who may use the reserved slice, comma
+	// separated namespaces or namespace/daemonset entries.
+	ReservedForAnnotation = "local.volume.csi.kubernetes.io/reserved-for"
+)
+
+// Backup session annotations on a pvc, a backup tool like velero sets
+// the request and reads back the snapshot device, the agent on the node
+// of the volume does the work.
+const (
+	// BackupRequestedAnnotation asks the agent for a snapshot, the value
+	// identifies the session and makes retries idempotent.
+	BackupRequestedAnnotation = "local.volume.csi.kubernetes.io/backup-requested-at"
+	// BackupSessionAnnotation records the session a snapshot exists for.
+	BackupSessionAnnotation = "local.volume.csi.kubernetes.io/backup-session"
+	// BackupDeviceAnnotation names the snapshot device to upload from.
+	BackupDeviceAnnotation = "local.volume.csi.kubernetes.io/backup-device"
+	// BackupPreFreezeAnnotation is a command the agent runs on the node
+	// before taking the snapshot, e.g. to quiesce the application.
+	BackupPreFreezeAnnotation = "local.volume.csi.kubernetes.io/pre-freeze-command"
+	// BackupPostFreezeAnnotation is a command the agent runs after the
+	// snapshot was taken.
+	BackupPostFreezeAnnotation = "local.volume.csi.kubernetes.io/post-freeze-command"
+	// SnapshotClassAnnotation names the VolumeSnapshotClass whose
+	// parameters configure fsfreeze and the in-pod snapshot hooks.
+	SnapshotClassAnnotation = "local.volume.csi.kubernetes.io/snapshot-class"
+
+	// UsageAlertAnnotation carries the filesystem usage percent of a
+	// claim that crossed the alert threshold, it goes away when the
+	// usage drops again.
+	UsageAlertAnnotation = "local.volume.csi.kubernetes.io/usage-alert"
+
+	// MigrationPodLabel marks the sender pod of a migration, the data
+	// service selects it by this label.
+	MigrationPodLabel = "local.volume.csi.kubernetes.io/migration"
+)
+
+// Node labels published from discovered local storage properties, so
+// users can write plain node selectors against them.
+const (
+	// NodeLabelHasNVMe is "true" when an nvme device backs the vg.
+	NodeLabelHasNVMe = "local.volume.csi.kubernetes.io/has-nvme"
+	// NodeLabelVGNames carries the managed volume group of the node.
+	NodeLabelVGNames = "local.volume.csi.kubernetes.io/vg-names"
+	// NodeLabelDeviceClass names the class of the devices backing the
+	// vg, nvme, ssd or hdd.
+	NodeLabelDeviceClass = "local.volume.csi.kubernetes.io/device-class"
+	// NodeLabelCapacityBucket buckets the total vg size into coarse
+	// ranges like 100-500G.
+	NodeLabelCapacityBucket = "local.volume.csi.kubernetes.io/capacity-range"
+)
+
+// PVCFencedCondition is set on a claim while its volume is remounted
+// read-only because the disk of the node is unhealthy, workloads can
+// watch it to expect write errors.
+const PVCFencedCondition v1.PersistentVolumeClaimConditionType = "LocalVolumeFenced"
+
+// Parameters of a VolumeSnapshotClass the agent understands.
+const (
+	// SnapshotParameterFSFreeze freezes the filesystem around the
+	// snapshot when set to "true".
+	SnapshotParameterFSFreeze = "fsfreeze"
+	// SnapshotParameterPreHook is a command executed in the pod mounting
+	// the claim before the snapshot.
+	SnapshotParameterPreHook = "pre-snapshot-hook"
+	// SnapshotParameterPostHook is a command executed in the pod after
+	// the snapshot.
+	SnapshotParameterPostHook = "post-snapshot-hook"
+	// SnapshotParameterHookContainer selects the container the hooks run
+	// in, the first container of the pod is used when unset.
+	SnapshotParameterHookContainer = "snapshot-hook-container"
+)
+
 func MakePVCKey(namespace, name string) string {
 	return namespace + "/" + name
 }
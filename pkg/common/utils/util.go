@@ -20,10 +20,51 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"sync/atomic"
+	"time"
 )
 
+// RunFunc is the signature of the shell executor behind Run.
+type RunFunc func(cmd string) (string, error)
+
+var runFunc RunFunc = runShell
+
+// SetRunFunc replaces the shell executor behind Run and returns the
+// previous one, pass nil to restore the default. The sanity harness uses
+// it to fake the LVM tooling in process.
+func SetRunFunc(f RunFunc) RunFunc {
+	old := runFunc
+	if f == nil {
+		f = runShell
+	}
+	runFunc = f
+	return old
+}
+
+var inflight int64
+
 // Run run shell command
 func Run(cmd string) (string, error) {
+	atomic.AddInt64(&inflight, 1)
+	defer atomic.AddInt64(&inflight, -1)
+	return runFunc(cmd)
+}
+
+// WaitIdle blocks until no Run command is in flight or the timeout has
+// passed, it returns false on timeout. Shutdown paths use it so an
+// lvcreate or mkfs is never abandoned halfway.
+func WaitIdle(timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for atomic.LoadInt64(&inflight) > 0 {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return true
+}
+
+func runShell(cmd string) (string, error) {
 	out, err := exec.Command("sh", "-c", cmd).CombinedOutput()
 	if err != nil {
 		return "", fmt.Errorf("Failed to run cmd: " + cmd + ", with out: " + string(out) + ", with error: " + err.Error())
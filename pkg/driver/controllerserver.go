@@ -50,8 +50,11 @@ func (cs *controllerServer) CreateVolume(ctx context.Context, req *csi.CreateVol
 	if len(req.Name) == 0 {
 		return nil, status.Error(codes.InvalidArgument, "Volume Name cannot be empty")
 	}
-	if req.VolumeCapabilities == nil {
-		return nil, status.Error(codes.InvalidArgument, "Volume Capabilities cannot be empty")
+	if err := validateVolumeCapabilities(req.VolumeCapabilities); err != nil {
+		return nil, err
+	}
+	if err := validateParameters(req.GetParameters()); err != nil {
+		return nil, err
 	}
 
 	// Get nodeID if pvc in topology mode.
@@ -60,10 +63,22 @@ func (cs *controllerServer) CreateVolume(ctx context.Context, req *csi.CreateVol
 		return nil, status.Error(codes.InvalidArgument, "NodeID cannot be empty")
 	}
 
+	// the class may raise the size to its minimum and round it up to the
+	// allocation granularity, the response carries the real allocation
+	volSizeBytes, err := adjustVolumeSize(req.GetCapacityRange(), req.GetParameters())
+	if err != nil {
+		return nil, err
+	}
+
+	if !cs.driver.inFlight.insert(req.GetName()) {
+		return nil, errAborted(req.GetName())
+	}
+	defer cs.driver.inFlight.remove(req.GetName())
+
 	response := &csi.CreateVolumeResponse{
 		Volume: &csi.Volume{
 			VolumeId:      req.GetName(),
-			CapacityBytes: req.GetCapacityRange().GetRequiredBytes(),
+			CapacityBytes: volSizeBytes,
 			VolumeContext: req.GetParameters(),
 			AccessibleTopology: []*csi.Topology{
 				{
@@ -75,12 +90,16 @@ func (cs *controllerServer) CreateVolume(ctx context.Context, req *csi.CreateVol
 		},
 	}
 
-	logging.GetLogger().Infof("Controller:CreateVolume Success :: volume = %s, size = %d", req.GetName(), req.GetCapacityRange().GetRequiredBytes())
+	logging.GetLogger().Infof("Controller:CreateVolume Success :: volume = %s, size = %d", req.GetName(), volSizeBytes)
 	return response, nil
 }
 
 func (cs *controllerServer) DeleteVolume(ctx context.Context, req *csi.DeleteVolumeRequest) (*csi.DeleteVolumeResponse, error) {
 	logging.GetLogger().Infof("Controller:DeleteVolume Request :: %+v", *req)
+	if !cs.driver.inFlight.insert(req.GetVolumeId()) {
+		return nil, errAborted(req.GetVolumeId())
+	}
+	defer cs.driver.inFlight.remove(req.GetVolumeId())
 	logging.GetLogger().Infof("Controller:DeleteVolume Success :: volume = %s", req.GetVolumeId())
 	return &csi.DeleteVolumeResponse{}, nil
 }
@@ -0,0 +1,75 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver
+
+import (
+	"sort"
+	"sync"
+)
+
+// deviceLimiter bounds concurrent heavy disk operations per physical
+// device. A StatefulSet creation burst then formats several volumes in
+// parallel across devices without saturating a single disk.
+type deviceLimiter struct {
+	limit int
+
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}
+
+func newDeviceLimiter(limit int) *deviceLimiter {
+	return &deviceLimiter{
+		limit: limit,
+		slots: make(map[string]chan struct{}),
+	}
+}
+
+// acquire takes one slot on every given device and returns the release.
+// The devices are taken in sorted order so two volumes spanning the
+// same devices cannot deadlock each other. A limit of 0 disables the
+// limiter.
+func (d *deviceLimiter) acquire(devices []string) func() {
+	if d.limit <= 0 || len(devices) == 0 {
+		return func() {}
+	}
+
+	sorted := append([]string{}, devices...)
+	sort.Strings(sorted)
+
+	held := make([]chan struct{}, 0, len(sorted))
+	for _, device := range sorted {
+		slot := d.slotFor(device)
+		slot <- struct{}{}
+		held = append(held, slot)
+	}
+	return func() {
+		for _, slot := range held {
+			<-slot
+		}
+	}
+}
+
+func (d *deviceLimiter) slotFor(device string) chan struct{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	slot, ok := d.slots[device]
+	if !ok {
+		slot = make(chan struct{}, d.limit)
+		d.slots[device] = slot
+	}
+	return slot
+}
@@ -17,11 +17,19 @@ limitations under the License.
 package driver
 
 import (
+	"flag"
+	"time"
+
 	"github.com/container-storage-interface/spec/lib/go/csi"
 	csicommon "github.com/kubernetes-csi/drivers/pkg/csi-common"
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/signals"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/utils"
 )
 
+var shutdownGrace = flag.Duration("shutdown-grace", 30*time.Second,
+	"Bound on draining in-flight rpcs before the csi server stops on a termination signal")
+
 type LocalVolumeDriver struct {
 	driverName       string
 	driverVersion    string
@@ -34,6 +42,9 @@ type LocalVolumeDriver struct {
 
 	cap   []*csi.VolumeCapability_AccessMode
 	cscap []*csi.ControllerServiceCapability
+
+	// inFlight rejects concurrent rpcs for the same volume with ABORTED
+	inFlight *inFlight
 }
 
 // Init checks for the persistent volume file and loads all found volumes
@@ -49,15 +60,24 @@ func NewLocalVolumeDriver(driverName, driverVersion, nodeID, endpoint string) *L
 	driver.driverVersion = driverVersion
 	driver.nodeID = nodeID
 	driver.endpoint = endpoint
+	driver.inFlight = newInFlight()
 
 	csiDriver := csicommon.NewCSIDriver(driverName, driverVersion, nodeID)
 	driver.csiDriver = csiDriver
+	// PUBLISH_UNPUBLISH_VOLUME is deliberately not advertised, the
+	// controller server does not implement it and no attacher is deployed,
+	// the csi-sanity suite flags the mismatch otherwise.
 	driver.csiDriver.AddControllerServiceCapabilities([]csi.ControllerServiceCapability_RPC_Type{
 		csi.ControllerServiceCapability_RPC_CREATE_DELETE_VOLUME,
-		csi.ControllerServiceCapability_RPC_PUBLISH_UNPUBLISH_VOLUME,
 		csi.ControllerServiceCapability_RPC_EXPAND_VOLUME,
 	})
-	driver.csiDriver.AddVolumeCapabilityAccessModes([]csi.VolumeCapability_AccessMode_Mode{csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER})
+	// a local volume never leaves its node, the single node modes cover
+	// one or many pods on that node, reader only publishes mount ro
+	driver.csiDriver.AddVolumeCapabilityAccessModes([]csi.VolumeCapability_AccessMode_Mode{
+		csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+		csi.VolumeCapability_AccessMode_SINGLE_NODE_READER_ONLY,
+		csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER,
+	})
 
 	// Create GRPC servers
 	driver.idServer = newIdentityServer(driver)
@@ -68,10 +88,36 @@ func NewLocalVolumeDriver(driverName, driverVersion, nodeID, endpoint string) *L
 }
 
 func (d *LocalVolumeDriver) Run() {
-	logging.GetLogger().Infof("Local Volume Driver(%s) version(%s) starting on node(%s) listen endpoint(%s)",
+	logger := logging.GetLogger()
+	logger.Infof("Local Volume Driver(%s) version(%s) starting on node(%s) listen endpoint(%s)",
 		d.driverName, d.driverVersion, d.nodeID, d.endpoint)
 
+	// a leftover socket of an unclean restart blocks the bind, a socket
+	// of a live driver must not be stolen
+	if err := checkStaleSocket(d.endpoint); err != nil {
+		logger.Fatalf("csi endpoint error = %s", err.Error())
+	}
+
 	server := csicommon.NewNonBlockingGRPCServer()
 	server.Start(d.endpoint, d.idServer, d.controllerServer, d.nodeServer)
-	server.Wait()
+	restrictSocket(d.endpoint)
+
+	// on a termination signal the in-flight rpcs and their shell commands
+	// drain first, bounded, the grpc server and with it the csi socket
+	// close last, so kubelet retries land on a consistent state after the
+	// restart
+	<-signals.SetupSignalHandler()
+	logger.Infof("termination signal received, draining %d in-flight rpc(s)", d.inFlight.count())
+
+	deadline := time.Now().Add(*shutdownGrace)
+	for d.inFlight.count() > 0 && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if n := d.inFlight.count(); n > 0 {
+		logger.Warnf("%d rpc(s) still in flight after the shutdown grace, stopping anyway", n)
+	}
+	utils.WaitIdle(time.Until(deadline))
+
+	server.Stop()
+	logger.Info("Local Volume Driver stopped")
 }
@@ -0,0 +1,234 @@
+//go:build sanity
+// +build sanity
+
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+)
+
+// fakeBackend stands in for the LVM tooling, the filesystem tooling and
+// the mount table so the driver can be exercised by csi-sanity without a
+// node. State lives in memory, only the /dev entries of created lvs are
+// real files because the node server stats them.
+type fakeBackend struct {
+	mu        sync.Mutex
+	devDir    string
+	lvs       map[string]bool
+	formatted map[string]bool
+	mounts    map[string]bool
+}
+
+func newFakeBackend(t *testing.T) *fakeBackend {
+	devDir := filepath.Join("/dev", types.VGName)
+	if err := os.MkdirAll(devDir, 0750); err != nil {
+		t.Fatalf("create fake dev dir(%s) error = %s", devDir, err.Error())
+	}
+	return &fakeBackend{
+		devDir:    devDir,
+		lvs:       make(map[string]bool),
+		formatted: make(map[string]bool),
+		mounts:    make(map[string]bool),
+	}
+}
+
+func (b *fakeBackend) cleanup() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for lv := range b.lvs {
+		os.Remove(filepath.Join(b.devDir, lv))
+	}
+	os.Remove(b.devDir)
+}
+
+// run fakes the shell commands issued through utils.Run and lvm.run.
+func (b *fakeBackend) run(cmd string) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case strings.Contains(cmd, " vgck "):
+		return "", nil
+	case strings.Contains(cmd, "vgdisplay") && strings.Contains(cmd, "VG Name"):
+		return "1\n", nil
+	case strings.Contains(cmd, "vgdisplay") && strings.Contains(cmd, "Cur PV"):
+		return "1\n", nil
+	case strings.Contains(cmd, " vgs "):
+		return fmt.Sprintf(`{"report":[{"vg":[{"vg_name":"%s","vg_size":"4096.00","vg_free":"2048.00","vg_free_count":"512","vg_extent_size":"4.00"}]}]}`, types.VGName), nil
+	case strings.Contains(cmd, " lvcreate "):
+		name := argAfter(cmd, "-n")
+		if name == "" {
+			return "", fmt.Errorf("fake lvcreate: no lv name in cmd(%s)", cmd)
+		}
+		devicePath := filepath.Join(b.devDir, name)
+		if err := writeFakeDevice(devicePath); err != nil {
+			return "", err
+		}
+		b.lvs[name] = true
+		return "", nil
+	case strings.Contains(cmd, " lvremove "):
+		name := filepath.Base(strings.Fields(cmd)[len(strings.Fields(cmd))-1])
+		os.Remove(filepath.Join(b.devDir, name))
+		delete(b.lvs, name)
+		return "", nil
+	case strings.Contains(cmd, " lvextend "):
+		return "", nil
+	case strings.Contains(cmd, "lvdisplay") && strings.Contains(cmd, "LV Size"):
+		return "1.00\n", nil
+	}
+	return "", nil
+}
+
+// formatDevice and checkFSType replace the real filesystem tooling.
+func (b *fakeBackend) formatDevice(devicePath, fstype string, extraArgs []string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.formatted[devicePath] = true
+	return nil
+}
+
+func (b *fakeBackend) checkFSType(devicePath string) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.formatted[devicePath] {
+		return DefaultFs, nil
+	}
+	return "", nil
+}
+
+// Mounter implementation backed by the in-memory mount table.
+func (b *fakeBackend) EnsureFolder(target string) error {
+	return os.MkdirAll(target, 0750)
+}
+
+func (b *fakeBackend) EnsureBlock(target string) error {
+	return writeFakeDevice(target)
+}
+
+func (b *fakeBackend) Format(source, fsType string) error {
+	return b.formatDevice(source, fsType, nil)
+}
+
+func (b *fakeBackend) Mount(source, target, fsType string, options ...string) error {
+	if _, err := os.Stat(target); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.mounts[target] = true
+	return nil
+}
+
+func (b *fakeBackend) MountBlock(source, target string, options ...string) error {
+	return b.Mount(source, target, "", options...)
+}
+
+func (b *fakeBackend) Unmount(target string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.mounts[target] {
+		return fmt.Errorf("fake unmount: target(%s) is not mounted", target)
+	}
+	delete(b.mounts, target)
+	return nil
+}
+
+func (b *fakeBackend) IsFormatted(source string) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.formatted[source], nil
+}
+
+func (b *fakeBackend) IsMounted(target string) (bool, error) {
+	b.mu.Lock()
+	mounted := b.mounts[target]
+	b.mu.Unlock()
+	if mounted {
+		return true, nil
+	}
+	if _, err := os.Stat(target); err != nil {
+		return false, err
+	}
+	return false, nil
+}
+
+func (b *fakeBackend) SafePathRemove(target string) error {
+	return os.Remove(target)
+}
+
+func (b *fakeBackend) HasMountRefs(mountPath string, mountRefs []string) bool {
+	return false
+}
+
+// newFakeKubeClient builds a clientset that answers every pv read and
+// patch with a synthetic 1Gi pv, the node server only looks at the
+// capacity and patches the publish tag.
+func newFakeKubeClient() *fake.Clientset {
+	client := fake.NewSimpleClientset()
+	stubPV := func(name string) *corev1.PersistentVolume {
+		return &corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: corev1.PersistentVolumeSpec{
+				Capacity: corev1.ResourceList{
+					corev1.ResourceStorage: resource.MustParse("1Gi"),
+				},
+			},
+		}
+	}
+	client.PrependReactor("get", "persistentvolumes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, stubPV(action.(k8stesting.GetAction).GetName()), nil
+	})
+	client.PrependReactor("patch", "persistentvolumes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, stubPV(action.(k8stesting.PatchAction).GetName()), nil
+	})
+	return client
+}
+
+// argAfter returns the field following flag in cmd.
+func argAfter(cmd, flag string) string {
+	fields := strings.Fields(cmd)
+	for i, field := range fields {
+		if field == flag && i+1 < len(fields) {
+			return fields[i+1]
+		}
+	}
+	return ""
+}
+
+func writeFakeDevice(path string) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver
+
+import (
+	"sync"
+
+	"golang.org/x/net/context"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/lvm"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+)
+
+// formatOperations dedupes concurrent formats of the same volume. The
+// mkfs of a very large volume can outlive the CSI timeout, the retried
+// rpc then joins the running operation instead of double-formatting
+// while the first mkfs keeps running in the background. The device
+// limiter additionally bounds how many formats run on one physical
+// device at a time.
+type formatOperations struct {
+	limiter *deviceLimiter
+
+	mu  sync.Mutex
+	ops map[string]*formatOp
+}
+
+type formatOp struct {
+	done chan struct{}
+	err  error
+}
+
+func newFormatOperations(limiter *deviceLimiter) *formatOperations {
+	return &formatOperations{
+		limiter: limiter,
+		ops:     make(map[string]*formatOp),
+	}
+}
+
+// formatOptions accelerate the mkfs of large volumes, both come from
+// storage class parameters.
+type formatOptions struct {
+	// lazyInit formats ext4 with lazy_itable_init and lazy_journal_init,
+	// the inode tables are then initialized in the background after the
+	// first mount
+	lazyInit bool
+	// imagePath, when set, clones a small preformatted filesystem image
+	// onto the device and grows it instead of running mkfs
+	imagePath string
+}
+
+// formatOptionsFromContext reads the fast format parameters of the
+// volume, validation already rejected unusable combinations.
+func formatOptionsFromContext(volumeContext map[string]string, fsType string) formatOptions {
+	opts := formatOptions{}
+	switch volumeContext[FastFormatTag] {
+	case FastFormatLazy:
+		opts.lazyInit = fsType == "ext4"
+	case FastFormatImage:
+		opts.imagePath = volumeContext[FormatImageTag]
+	}
+	return opts
+}
+
+// mkfsArgs translates the options into extra mkfs arguments.
+func mkfsArgs(opts formatOptions) []string {
+	if opts.lazyInit {
+		return []string{"-E", "lazy_itable_init=1,lazy_journal_init=1"}
+	}
+	return nil
+}
+
+// format runs formatDevice asynchronously keyed by volumeID and waits
+// for it, a second caller for the same volume waits on the same mkfs.
+// When the context runs out the mkfs keeps going, only the waiting
+// stops.
+func (f *formatOperations) format(ctx context.Context, volumeID, devicePath, fsType string, opts formatOptions) error {
+	f.mu.Lock()
+	op, running := f.ops[volumeID]
+	if !running {
+		op = &formatOp{done: make(chan struct{})}
+		f.ops[volumeID] = op
+	}
+	f.mu.Unlock()
+
+	if !running {
+		go func() {
+			release := f.limiter.acquire(lvm.GetLVDevices(types.VGName, volumeID))
+			if opts.imagePath != "" {
+				op.err = imageFormatDevice(devicePath, opts.imagePath)
+			} else {
+				op.err = formatDevice(devicePath, fsType, mkfsArgs(opts))
+			}
+			release()
+			f.mu.Lock()
+			delete(f.ops, volumeID)
+			f.mu.Unlock()
+			close(op.done)
+		}()
+	}
+
+	select {
+	case <-op.done:
+		return op.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
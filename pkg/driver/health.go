@@ -0,0 +1,53 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/lvm"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/utils"
+)
+
+var (
+	healthMu    sync.Mutex
+	lastHealthy time.Time
+)
+
+// healthCheck reports whether the node backend is really usable, it is
+// wired into the Identity Probe RPC so the livenessprobe sidecar restarts
+// a wedged node plugin instead of seeing always-healthy.
+func (d *LocalVolumeDriver) healthCheck() error {
+	// lvm tooling reachable and vg consistent
+	ckCmd := fmt.Sprintf("%s vgck %s", types.NsenterCmd, types.VGName)
+	if _, err := utils.Run(ckCmd); err != nil {
+		return fmt.Errorf("vg(%s) check failed: %s", types.VGName, err.Error())
+	}
+
+	// vg metadata readable
+	if vgInfo := lvm.GetVGInfo(types.VGName); vgInfo == nil {
+		return fmt.Errorf("vg(%s) info not readable", types.VGName)
+	}
+
+	healthMu.Lock()
+	lastHealthy = time.Now()
+	healthMu.Unlock()
+	return nil
+}
@@ -17,6 +17,9 @@ limitations under the License.
 package driver
 
 import (
+	"strings"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -24,6 +27,7 @@ import (
 	"github.com/container-storage-interface/spec/lib/go/csi"
 	csicommon "github.com/kubernetes-csi/drivers/pkg/csi-common"
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/version"
 )
 
 type identityServer struct {
@@ -50,15 +54,30 @@ func (iden *identityServer) GetPluginInfo(ctx context.Context, req *csi.GetPlugi
 		return nil, status.Error(codes.Unavailable, "Driver is missing version")
 	}
 
+	info := version.Get()
 	return &csi.GetPluginInfoResponse{
 		Name:          iden.driver.driverName,
 		VendorVersion: iden.driver.driverVersion,
+		Manifest: map[string]string{
+			"gitCommit":    info.GitCommit,
+			"buildDate":    info.BuildDate,
+			"goVersion":    info.GoVersion,
+			"featureGates": strings.Join(info.FeatureGates, ","),
+		},
 	}, nil
 }
 
 func (iden *identityServer) Probe(ctx context.Context, req *csi.ProbeRequest) (*csi.ProbeResponse, error) {
 	logging.GetLogger().Infof("Identity:Probe Request :: %+v", *req)
-	return &csi.ProbeResponse{}, nil
+
+	if err := iden.driver.healthCheck(); err != nil {
+		logging.GetLogger().Errorf("Identity:Probe unhealthy :: %s", err.Error())
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	return &csi.ProbeResponse{
+		Ready: &wrappers.BoolValue{Value: true},
+	}, nil
 }
 
 // GetPluginCapabilities returns available capabilities of the plugin
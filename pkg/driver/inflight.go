@@ -0,0 +1,71 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver
+
+import (
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// inFlight tracks volumes with an rpc in progress, the CSI spec
+// requires a second rpc for the same volume to be rejected with
+// ABORTED instead of running duplicate lvm operations.
+type inFlight struct {
+	mu  sync.Mutex
+	ids map[string]bool
+}
+
+func newInFlight() *inFlight {
+	return &inFlight{
+		ids: make(map[string]bool),
+	}
+}
+
+// insert marks the volume as busy, it returns false when another rpc
+// for the volume is already running.
+func (f *inFlight) insert(volumeID string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.ids[volumeID] {
+		return false
+	}
+	f.ids[volumeID] = true
+	return true
+}
+
+// remove marks the volume as idle again.
+func (f *inFlight) remove(volumeID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.ids, volumeID)
+}
+
+// count returns how many volumes have an rpc in progress, shutdown
+// drains on it.
+func (f *inFlight) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.ids)
+}
+
+// errAborted is the ABORTED response of a volume with an rpc already in
+// progress.
+func errAborted(volumeID string) error {
+	return status.Errorf(codes.Aborted, "an operation with volume %s already exists", volumeID)
+}
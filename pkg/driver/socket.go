@@ -0,0 +1,67 @@
+package driver
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/utils"
+)
+
+// endpointSocketPath returns the filesystem path of a unix endpoint,
+// empty for anything else. The csicommon parser resolves
+// unix://tmp/csi.sock to /tmp/csi.sock, this mirrors it.
+func endpointSocketPath(endpoint string) string {
+	if !strings.HasPrefix(strings.ToLower(endpoint), "unix://") {
+		return ""
+	}
+	return "/" + endpoint[len("unix://"):]
+}
+
+// checkStaleSocket clears a socket file an unclean shutdown left behind
+// so the bind succeeds, but refuses to start while a live driver still
+// answers on it, removing the socket under a running process would
+// crash-loop both.
+func checkStaleSocket(endpoint string) error {
+	path := endpointSocketPath(endpoint)
+	if path == "" || !utils.IsFileExisting(path) {
+		return nil
+	}
+
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err == nil {
+		conn.Close()
+		return fmt.Errorf("socket(%s) is still served by a live process", path)
+	}
+
+	logging.GetLogger().Infof("removing stale socket(%s) left by an unclean shutdown", path)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// restrictSocket waits for the listener to create the socket and
+// tightens its permissions, only kubelet and the sidecars running as
+// root talk csi.
+func restrictSocket(endpoint string) {
+	path := endpointSocketPath(endpoint)
+	if path == "" {
+		return
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !utils.IsFileExisting(path) {
+		if time.Now().After(deadline) {
+			logging.GetLogger().Warnf("socket(%s) did not appear, permissions left alone", path)
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		logging.GetLogger().Warnf("restrict socket(%s) permissions error = %s", path, err.Error())
+	}
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+)
+
+var (
+	kubeletRootDir = flag.String("kubelet-root-dir", "/var/lib/kubelet",
+		"Root directory of the kubelet, the publish paths of the volumes live below it")
+
+	sweepStalePublishDirs = flag.Bool("sweep-stale-publish-dirs", true,
+		"Remove publish directories of this driver whose lv no longer exists at startup, a kubelet or driver restart can leave them behind")
+)
+
+// volData is the part of the vol_data.json kubelet writes next to every
+// csi publish directory that identifies the owning driver.
+type volData struct {
+	DriverName string `json:"driverName"`
+}
+
+// sweepPublishDirs walks the csi publish directories under the kubelet
+// root and removes the ones of this driver whose lv is gone. An
+// unpublish that raced a restart leaves the directory and sometimes the
+// mount behind, kubelet then refuses to fully delete the pod directory.
+func (ns *nodeServer) sweepPublishDirs() {
+	pattern := filepath.Join(*kubeletRootDir, "pods", "*", "volumes", "kubernetes.io~csi", "*", "mount")
+	targets, err := filepath.Glob(pattern)
+	if err != nil {
+		logging.GetLogger().Errorf("sweepPublishDirs:: glob %s error = %s", pattern, err.Error())
+		return
+	}
+
+	for _, target := range targets {
+		volumeDir := filepath.Dir(target)
+		if !ownsPublishDir(volumeDir) {
+			continue
+		}
+
+		// the lv still exists, the publish is live or kubelet is about
+		// to republish it, leave it alone
+		volumeID := filepath.Base(volumeDir)
+		if _, err := os.Stat(volumeDevicePath(volumeID)); err == nil {
+			continue
+		}
+
+		if mounted, err := ns.mounter.IsMounted(target); err == nil && mounted {
+			if err := ns.mounter.Unmount(target); err != nil {
+				logging.GetLogger().Errorf("sweepPublishDirs:: unmount stale publish %s error = %s", target, err.Error())
+				continue
+			}
+		}
+		if err := ns.mounter.SafePathRemove(target); err != nil {
+			logging.GetLogger().Errorf("sweepPublishDirs:: remove stale publish %s error = %s", target, err.Error())
+			continue
+		}
+		logging.GetLogger().Infof("sweepPublishDirs:: removed stale publish of volume %s at %s", volumeID, target)
+	}
+}
+
+// ownsPublishDir reports whether the vol_data.json of the publish
+// directory names this driver, directories of other csi drivers are
+// never touched.
+func ownsPublishDir(volumeDir string) bool {
+	data, err := ioutil.ReadFile(filepath.Join(volumeDir, "vol_data.json"))
+	if err != nil {
+		return false
+	}
+	parsed := volData{}
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return false
+	}
+	return parsed.DriverName == types.DriverName
+}
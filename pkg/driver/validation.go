@@ -0,0 +1,245 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+)
+
+// supportedFsTypes are the filesystems the format path can mkfs.
+var supportedFsTypes = map[string]bool{
+	"":     true, // defaults to DefaultFs
+	"ext3": true,
+	"ext4": true,
+	"xfs":  true,
+}
+
+// validateVolumeCapabilities checks the requested capabilities up
+// front, an unservable request fails with INVALID_ARGUMENT and a
+// message naming the offending piece instead of erroring deep in the
+// lvm backend.
+func validateVolumeCapabilities(caps []*csi.VolumeCapability) error {
+	if len(caps) == 0 {
+		return status.Error(codes.InvalidArgument, "Volume Capabilities cannot be empty")
+	}
+	for _, capability := range caps {
+		if capability.GetBlock() != nil {
+			return status.Error(codes.InvalidArgument,
+				"block volumes are not supported, the driver formats and mounts a filesystem, request a mount volume")
+		}
+		mount := capability.GetMount()
+		if mount == nil {
+			return status.Error(codes.InvalidArgument, "volume capability carries neither mount nor block access type")
+		}
+		if !supportedFsTypes[mount.GetFsType()] {
+			return status.Errorf(codes.InvalidArgument,
+				"fsType %q is not supported, pick one of %s", mount.GetFsType(), fsTypeNames())
+		}
+		if err := validateAccessMode(capability.GetAccessMode().GetMode()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateParameters checks the storage class parameters the driver
+// reads, unknown keys pass through untouched for forward compatibility.
+func validateParameters(parameters map[string]string) error {
+	if fsType, ok := parameters[FsTypeTag]; ok && !supportedFsTypes[fsType] {
+		return status.Errorf(codes.InvalidArgument,
+			"parameter %s=%q is not supported, pick one of %s", FsTypeTag, fsType, fsTypeNames())
+	}
+	if lvmType, ok := parameters[LvmTypeTag]; ok && lvmType != LinearType && lvmType != StripingType {
+		return status.Errorf(codes.InvalidArgument,
+			"parameter %s=%q is not supported, pick %s or %s", LvmTypeTag, lvmType, LinearType, StripingType)
+	}
+	fsType := parameters[FsTypeTag]
+	switch fastFormat := parameters[FastFormatTag]; fastFormat {
+	case "":
+	case FastFormatLazy:
+		if fsType != "" && fsType != "ext4" {
+			return status.Errorf(codes.InvalidArgument,
+				"parameter %s=%s needs ext4, %s does not support lazy init", FastFormatTag, FastFormatLazy, fsType)
+		}
+	case FastFormatImage:
+		if parameters[FormatImageTag] == "" {
+			return status.Errorf(codes.InvalidArgument,
+				"parameter %s=%s needs %s pointing at a preformatted image on the node", FastFormatTag, FastFormatImage, FormatImageTag)
+		}
+		if fsType == "xfs" {
+			return status.Errorf(codes.InvalidArgument,
+				"parameter %s=%s only grows ext filesystems, xfs cannot use it", FastFormatTag, FastFormatImage)
+		}
+	default:
+		return status.Errorf(codes.InvalidArgument,
+			"parameter %s=%q is not supported, pick %s or %s", FastFormatTag, fastFormat, FastFormatLazy, FastFormatImage)
+	}
+	switch discard := parameters[DiscardTag]; discard {
+	case "", "true", "false":
+	default:
+		return status.Errorf(codes.InvalidArgument,
+			"parameter %s=%q is not supported, pick true or false", DiscardTag, discard)
+	}
+	switch propagation := parameters[MountPropagationTag]; propagation {
+	case "", PropagationRShared, PropagationRSlave:
+	default:
+		return status.Errorf(codes.InvalidArgument,
+			"parameter %s=%q is not supported, pick %s or %s", MountPropagationTag, propagation, PropagationRShared, PropagationRSlave)
+	}
+	for _, tag := range []string{MinimumSizeTag, AllocationGranularityTag, types.MaxSizeParam} {
+		if _, err := parameterQuantity(parameters, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parameterQuantity parses a size parameter, zero with no error when the
+// parameter is absent.
+func parameterQuantity(parameters map[string]string, tag string) (int64, error) {
+	value, ok := parameters[tag]
+	if !ok || value == "" {
+		return 0, nil
+	}
+	quantity, err := resource.ParseQuantity(value)
+	if err != nil || quantity.Value() <= 0 {
+		return 0, status.Errorf(codes.InvalidArgument,
+			"parameter %s=%q is not a positive quantity, use values like 10Gi or 4Mi", tag, value)
+	}
+	return quantity.Value(), nil
+}
+
+// adjustVolumeSize applies the minimum size and allocation granularity
+// of the storage class to the requested capacity. The result goes back
+// in the create response, the pv then shows what is actually allocated
+// instead of what was asked for.
+func adjustVolumeSize(capacity *csi.CapacityRange, parameters map[string]string) (int64, error) {
+	size := capacity.GetRequiredBytes()
+
+	minimum, err := parameterQuantity(parameters, MinimumSizeTag)
+	if err != nil {
+		return 0, err
+	}
+	if size < minimum {
+		size = minimum
+	}
+
+	granularity, err := parameterQuantity(parameters, AllocationGranularityTag)
+	if err != nil {
+		return 0, err
+	}
+	if granularity > 0 && size%granularity != 0 {
+		size += granularity - size%granularity
+	}
+
+	// the class cap guards the vg of a node against one oversized claim,
+	// the webhook rejects most of those earlier but not claims that
+	// bypass it
+	max, err := parameterQuantity(parameters, types.MaxSizeParam)
+	if err != nil {
+		return 0, err
+	}
+	if max > 0 && size > max {
+		return 0, status.Errorf(codes.OutOfRange,
+			"size %d exceeds the %s=%s of the storage class", size, types.MaxSizeParam, parameters[types.MaxSizeParam])
+	}
+
+	if limit := capacity.GetLimitBytes(); limit > 0 && size > limit {
+		return 0, status.Errorf(codes.OutOfRange,
+			"size %d after the class minimum and rounding exceeds the capacity limit %d", size, limit)
+	}
+	return size, nil
+}
+
+// publishPropagation validates the mount propagation attribute against
+// the publish. The default private propagation stays unless the volume
+// asks for more, and a read-only publish never shares its mounts back.
+func publishPropagation(req *csi.NodePublishVolumeRequest, readOnly bool) (string, error) {
+	switch propagation := req.VolumeContext[MountPropagationTag]; propagation {
+	case "":
+		return "", nil
+	case PropagationRSlave:
+		return PropagationRSlave, nil
+	case PropagationRShared:
+		if readOnly {
+			return "", status.Errorf(codes.InvalidArgument,
+				"attribute %s=%s is not allowed on a read-only publish, mounts would leak out of it, use %s", MountPropagationTag, PropagationRShared, PropagationRSlave)
+		}
+		return PropagationRShared, nil
+	default:
+		return "", status.Errorf(codes.InvalidArgument,
+			"attribute %s=%q is not supported, pick %s or %s", MountPropagationTag, propagation, PropagationRShared, PropagationRSlave)
+	}
+}
+
+// normalizeSELinuxFlags passes the mount flags of the publish through
+// and rewrites selinux context options on the way. The context value
+// carries commas, it has to stay quoted to survive the comma joined
+// option string handed to mount, and two publishes demanding different
+// contexts on one volume cannot both be honored.
+func normalizeSELinuxFlags(mountFlags []string) ([]string, error) {
+	result := make([]string, 0, len(mountFlags))
+	seLinuxContext := ""
+	for _, mountFlag := range mountFlags {
+		if !strings.HasPrefix(mountFlag, "context=") {
+			result = append(result, mountFlag)
+			continue
+		}
+		value := strings.Trim(strings.TrimPrefix(mountFlag, "context="), `"`)
+		if seLinuxContext == value {
+			continue
+		}
+		if seLinuxContext != "" {
+			return nil, status.Errorf(codes.InvalidArgument,
+				"mount flags carry conflicting selinux contexts %q and %q", seLinuxContext, value)
+		}
+		seLinuxContext = value
+		result = append(result, fmt.Sprintf("context=%q", value))
+	}
+	return result, nil
+}
+
+// validateAccessMode rejects the access modes a local volume can never
+// serve, it lives on one node only.
+func validateAccessMode(mode csi.VolumeCapability_AccessMode_Mode) error {
+	switch mode {
+	case csi.VolumeCapability_AccessMode_SINGLE_NODE_WRITER,
+		csi.VolumeCapability_AccessMode_SINGLE_NODE_READER_ONLY,
+		csi.VolumeCapability_AccessMode_MULTI_NODE_MULTI_WRITER:
+		// MULTI_NODE_MULTI_WRITER is kept for compatibility, it only
+		// ever meant many pods on the one node of the volume
+		return nil
+	case csi.VolumeCapability_AccessMode_MULTI_NODE_READER_ONLY,
+		csi.VolumeCapability_AccessMode_MULTI_NODE_SINGLE_WRITER:
+		return status.Errorf(codes.InvalidArgument,
+			"access mode %s is not supported, a local volume is only reachable from its node, use a single node mode", mode)
+	default:
+		return status.Errorf(codes.InvalidArgument, "access mode %s is not supported", mode)
+	}
+}
+
+func fsTypeNames() string {
+	return fmt.Sprintf("%s, %s or %s", "ext3", "ext4", "xfs")
+}
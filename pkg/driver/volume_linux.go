@@ -0,0 +1,209 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	k8sexec "k8s.io/utils/exec"
+	k8smount "k8s.io/utils/mount"
+	"k8s.io/kubernetes/pkg/util/resizefs"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/chaos"
+	lverrors "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/errors"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/lvm"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/metrics"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/state"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/utils"
+)
+
+// volumeDevicePath is the node local path the publish mounts, on linux
+// the lv device of the volume.
+func volumeDevicePath(volumeID string) string {
+	return filepath.Join("/dev/", types.VGName, volumeID)
+}
+
+// recoverState replays the journal after a restart. A volume that was
+// mid-creation when the plugin died may be an unformatted or half
+// formatted lv, it is removed so the retried publish starts clean.
+func (ns *nodeServer) recoverState() {
+	for volumeID, op := range ns.state.Pending() {
+		if op != state.OpCreating {
+			continue
+		}
+		logging.GetLogger().Infof("recoverState:: rolling back half-created volume %s", volumeID)
+		devicePath := volumeDevicePath(volumeID)
+		if _, err := os.Stat(devicePath); err == nil {
+			cmd := fmt.Sprintf("%s lvremove -f %s", types.NsenterCmd, devicePath)
+			if _, err := utils.Run(cmd); err != nil {
+				logging.GetLogger().Errorf("recoverState:: remove half-created volume %s error = %s", volumeID, err.Error())
+				continue
+			}
+		}
+		if err := ns.state.Finish(volumeID); err != nil {
+			logging.GetLogger().Errorf("recoverState:: clear journal entry of volume %s error = %s", volumeID, err.Error())
+		}
+	}
+}
+
+// create lvm volume
+func (ns *nodeServer) createVolume(ctx context.Context, volumeID, vgName, lvmType string) error {
+	pvSize, unit := ns.getPvSize(volumeID)
+
+	pvNumber := 0
+	var err error
+	// Create VG if vg not exist,
+	if pvNumber, err = lvm.CreateVG(vgName); err != nil {
+		return err
+	}
+
+	// check vg exist
+	ckCmd := fmt.Sprintf("%s vgck %s", types.NsenterCmd, vgName)
+	_, err = utils.Run(ckCmd)
+	if err != nil {
+		logging.GetLogger().Errorf("createVolume:: VG is not exist: %s", vgName)
+		return err
+	}
+
+	// try to hand out a pre-provisioned lv of the warm pool first
+	if unit == "g" && ns.claimWarmPoolVolume(volumeID, vgName, pvSize) {
+		return nil
+	}
+
+	// Create lvm volume
+	if err := chaos.Hook(chaos.OpLVCreate); err != nil {
+		return err
+	}
+	if lvmType == StripingType {
+		cmd := fmt.Sprintf("%s lvcreate -i %d -n %s -L %d%s %s", types.NsenterCmd, pvNumber, volumeID, pvSize, unit, vgName)
+		start := time.Now()
+		_, err = utils.Run(cmd)
+		err = lverrors.Wrap(lverrors.Transient, err)
+		metrics.RecordOperation(metrics.OperationCreateVolume, start, err)
+		if err != nil {
+			return err
+		}
+		logging.GetLogger().Infof("Successful Create Striping LVM volume: %s, Size: %d%s, vgName: %s, striped number: %d", volumeID, pvSize, unit, vgName, pvNumber)
+	} else if lvmType == LinearType {
+		cmd := fmt.Sprintf("%s lvcreate -n %s -L %d%s %s", types.NsenterCmd, volumeID, pvSize, unit, vgName)
+		start := time.Now()
+		_, err = utils.Run(cmd)
+		err = lverrors.Wrap(lverrors.Transient, err)
+		metrics.RecordOperation(metrics.OperationCreateVolume, start, err)
+		if err != nil {
+			return err
+		}
+		logging.GetLogger().Infof("Successful Create Linear LVM volume: %s, Size: %d%s, vgName: %s", volumeID, pvSize, unit, vgName)
+	}
+
+	// discard the fresh lv so an ssd backed vg starts from trimmed
+	// blocks, stale data of a previous lv on the same extents is gone
+	// before mkfs touches the device
+	if *discardOnCreate {
+		discardCmd := fmt.Sprintf("%s blkdiscard /dev/%s/%s", types.NsenterCmd, vgName, volumeID)
+		if _, err := utils.Run(discardCmd); err != nil {
+			// not every device supports discard, the format still works
+			logging.GetLogger().Warnf("createVolume:: blkdiscard of %s error = %s", volumeID, err.Error())
+		}
+	}
+	return nil
+}
+
+// claimWarmPoolVolume renames a pooled lv of exactly the requested size
+// to the volume. The pooled lv is already formatted, so the publish
+// path skips the mkfs as well.
+func (ns *nodeServer) claimWarmPoolVolume(volumeID, vgName string, sizeGB int64) bool {
+	for _, name := range lvm.ListLVs(vgName) {
+		size, ok := types.WarmPoolLVSize(name)
+		if !ok || int64(size) != sizeGB {
+			continue
+		}
+		start := time.Now()
+		if err := lvm.RenameLV(vgName, name, volumeID); err != nil {
+			// lost the race for this lv, try the next one
+			logging.GetLogger().Warnf("claim warm pool lv(%s) error = %s", name, err.Error())
+			continue
+		}
+		metrics.RecordOperation(metrics.OperationCreateVolume, start, nil)
+		logging.GetLogger().Infof("Successful claimed warm pool lv(%s) for volume: %s, vgName: %s", name, volumeID, vgName)
+		return true
+	}
+	return false
+}
+
+func (ns *nodeServer) resizeVolume(ctx context.Context, volumeID, vgName, targetPath string) error {
+	pvSize, unit := ns.getPvSize(volumeID)
+	devicePath := filepath.Join("/dev", vgName, volumeID)
+	sizeCmd := fmt.Sprintf("%s lvdisplay %s | grep 'LV Size' | awk '{print $3}'", types.NsenterCmd, devicePath)
+	sizeStr, err := utils.Run(sizeCmd)
+	if err != nil {
+		return err
+	}
+	if sizeStr == "" {
+		return status.Error(codes.Internal, "Get lvm size error")
+	}
+	sizeStr = strings.Split(sizeStr, ".")[0]
+	sizeInt, err := strconv.ParseInt(strings.TrimSpace(sizeStr), 10, 64)
+	if err != nil {
+		return err
+	}
+
+	// if lvmsize equal/bigger than pv size, no do expand.
+	if sizeInt >= pvSize {
+		return nil
+	}
+	logging.GetLogger().Infof("NodeExpandVolume:: volumeId: %s, devicePath: %s, from size: %d, to Size: %d%s", volumeID, devicePath, sizeInt, pvSize, unit)
+
+	// resize lvm volume
+	// lvextend -L3G /dev/vgtest/lvm-5db74864-ea6b-11e9-a442-00163e07fb69
+	if err := chaos.Hook(chaos.OpLVExtend); err != nil {
+		return err
+	}
+	resizeCmd := fmt.Sprintf("%s lvextend -L%d%s %s", types.NsenterCmd, pvSize, unit, devicePath)
+	start := time.Now()
+	_, err = utils.Run(resizeCmd)
+	metrics.RecordOperation(metrics.OperationExpandVolume, start, err)
+	if err != nil {
+		return err
+	}
+
+	// use resizer to expand volume filesystem
+	realExec := k8sexec.New()
+	resizer := resizefs.NewResizeFs(&k8smount.SafeFormatAndMount{Interface: ns.k8smounter, Exec: realExec})
+	ok, err := resizer.Resize(devicePath, targetPath)
+	if err != nil {
+		logging.GetLogger().Errorf("NodeExpandVolume:: Resize Error, volumeId: %s, devicePath: %s, volumePath: %s, err: %s", volumeID, devicePath, targetPath, err.Error())
+		return err
+	}
+	if !ok {
+		logging.GetLogger().Errorf("NodeExpandVolume:: Resize failed, volumeId: %s, devicePath: %s, volumePath: %s", volumeID, devicePath, targetPath)
+		return status.Error(codes.Internal, "Fail to resize volume fs")
+	}
+	logging.GetLogger().Infof("NodeExpandVolume:: resizefs successful volumeId: %s, devicePath: %s, volumePath: %s", volumeID, devicePath, targetPath)
+	return nil
+}
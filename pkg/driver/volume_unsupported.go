@@ -0,0 +1,49 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver
+
+import (
+	"errors"
+	"path/filepath"
+
+	"golang.org/x/net/context"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+)
+
+// volumes can only be served on linux and windows nodes, the other
+// platforms get stubs that always error so the binaries still compile
+// for local development.
+
+var errUnsupportedPlatform = errors.New("local volumes are not supported on this platform")
+
+func volumeDevicePath(volumeID string) string {
+	return filepath.Join("/dev/", types.VGName, volumeID)
+}
+
+func (ns *nodeServer) recoverState() {}
+
+func (ns *nodeServer) createVolume(ctx context.Context, volumeID, vgName, lvmType string) error {
+	return errUnsupportedPlatform
+}
+
+func (ns *nodeServer) resizeVolume(ctx context.Context, volumeID, vgName, targetPath string) error {
+	return errUnsupportedPlatform
+}
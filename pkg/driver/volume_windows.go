@@ -0,0 +1,125 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/metrics"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/state"
+)
+
+var (
+	volumeBaseDir = flag.String("volume-base-dir", `C:\local-volumes`,
+		"Directory the volumes are created under, an ntfs directory with an fsrm quota stands in for the lv of the linux backend")
+)
+
+func init() {
+	// the volume is a directory, it carries the filesystem of its ntfs
+	// parent and is never formatted
+	checkFSType = func(devicePath string) (string, error) {
+		return "ntfs", nil
+	}
+}
+
+// volumeDevicePath is the node local path the publish links to, on
+// windows the quota limited volume directory.
+func volumeDevicePath(volumeID string) string {
+	return filepath.Join(*volumeBaseDir, volumeID)
+}
+
+// recoverState replays the journal after a restart. A volume directory
+// that was mid-creation when the plugin died may carry a half applied
+// quota, it is removed so the retried publish starts clean.
+func (ns *nodeServer) recoverState() {
+	for volumeID, op := range ns.state.Pending() {
+		if op != state.OpCreating {
+			continue
+		}
+		logging.GetLogger().Infof("recoverState:: rolling back half-created volume %s", volumeID)
+		if err := os.RemoveAll(volumeDevicePath(volumeID)); err != nil {
+			logging.GetLogger().Errorf("recoverState:: remove half-created volume %s error = %s", volumeID, err.Error())
+			continue
+		}
+		if err := ns.state.Finish(volumeID); err != nil {
+			logging.GetLogger().Errorf("recoverState:: clear journal entry of volume %s error = %s", volumeID, err.Error())
+		}
+	}
+}
+
+// createVolume creates the volume directory and limits it to the pv
+// size with an fsrm directory quota, the windows counterpart of the
+// lvcreate of the linux backend. The lvmType of the storage class has
+// no meaning on ntfs and is ignored.
+func (ns *nodeServer) createVolume(ctx context.Context, volumeID, vgName, lvmType string) error {
+	pvSize, unit := ns.getPvSize(volumeID)
+	volumeDir := volumeDevicePath(volumeID)
+
+	start := time.Now()
+	err := provisionQuotaDir(volumeDir, pvSize, unit)
+	metrics.RecordOperation(metrics.OperationCreateVolume, start, err)
+	if err != nil {
+		return err
+	}
+	logging.GetLogger().Infof("Successful Create quota directory volume: %s, Size: %d%s", volumeID, pvSize, unit)
+	return nil
+}
+
+// resizeVolume raises the directory quota to the pv size, shrinking
+// never happens because the pv capacity only grows.
+func (ns *nodeServer) resizeVolume(ctx context.Context, volumeID, vgName, targetPath string) error {
+	pvSize, unit := ns.getPvSize(volumeID)
+
+	start := time.Now()
+	cmd := exec.Command("dirquota", "quota", "modify",
+		"/path:"+volumeDevicePath(volumeID), "/limit:"+quotaLimit(pvSize, unit))
+	output, err := cmd.CombinedOutput()
+	metrics.RecordOperation(metrics.OperationExpandVolume, start, err)
+	if err != nil {
+		return fmt.Errorf("modify quota of %s error: %s", volumeID, string(output))
+	}
+	logging.GetLogger().Infof("NodeExpandVolume:: quota of volume %s raised to %d%s", volumeID, pvSize, unit)
+	return nil
+}
+
+func provisionQuotaDir(volumeDir string, pvSize int64, unit string) error {
+	if err := os.MkdirAll(volumeDir, 0750); err != nil {
+		return err
+	}
+	cmd := exec.Command("dirquota", "quota", "add",
+		"/path:"+volumeDir, "/limit:"+quotaLimit(pvSize, unit))
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("add quota on %s error: %s", volumeDir, string(output))
+	}
+	return nil
+}
+
+// quotaLimit renders the pv size in the unit syntax of dirquota.
+func quotaLimit(pvSize int64, unit string) string {
+	if unit == "m" {
+		return fmt.Sprintf("%dMB", pvSize)
+	}
+	return fmt.Sprintf("%dGB", pvSize)
+}
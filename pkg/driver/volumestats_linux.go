@@ -0,0 +1,47 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver
+
+import (
+	"golang.org/x/sys/unix"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+)
+
+// volumePathUsage reads the byte and inode usage of the filesystem at
+// volumePath.
+func volumePathUsage(volumePath string) ([]*csi.VolumeUsage, error) {
+	var statfs unix.Statfs_t
+	if err := unix.Statfs(volumePath, &statfs); err != nil {
+		return nil, err
+	}
+
+	return []*csi.VolumeUsage{
+		{
+			Unit:      csi.VolumeUsage_BYTES,
+			Total:     int64(statfs.Blocks) * int64(statfs.Bsize),
+			Available: int64(statfs.Bavail) * int64(statfs.Bsize),
+			Used:      int64(statfs.Blocks-statfs.Bfree) * int64(statfs.Bsize),
+		},
+		{
+			Unit:      csi.VolumeUsage_INODES,
+			Total:     int64(statfs.Files),
+			Available: int64(statfs.Ffree),
+			Used:      int64(statfs.Files) - int64(statfs.Ffree),
+		},
+	}, nil
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package driver
+
+import (
+	"golang.org/x/sys/windows"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+)
+
+// volumePathUsage reads the byte usage of the filesystem at volumePath,
+// ntfs reports no inode counts so only bytes are returned.
+func volumePathUsage(volumePath string) ([]*csi.VolumeUsage, error) {
+	var free, total, totalFree uint64
+	path, err := windows.UTF16PtrFromString(volumePath)
+	if err != nil {
+		return nil, err
+	}
+	if err := windows.GetDiskFreeSpaceEx(path, &free, &total, &totalFree); err != nil {
+		return nil, err
+	}
+
+	return []*csi.VolumeUsage{
+		{
+			Unit:      csi.VolumeUsage_BYTES,
+			Total:     int64(total),
+			Available: int64(free),
+			Used:      int64(total - totalFree),
+		},
+	}, nil
+}
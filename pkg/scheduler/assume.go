@@ -0,0 +1,76 @@
+package scheduler
+
+import (
+	"flag"
+	"sync"
+	"time"
+)
+
+var assumedReservationTTL = flag.Duration("assumed-reservation-ttl", 30*time.Second,
+	"How long a prioritized but not yet bound pod keeps its best node reserved in scoring, 0 disables reserve-ahead scoring")
+
+// assumedReservations tracks pods that have been scored but whose bind
+// has not arrived yet. Scoring subtracts their request from the free
+// size of the node they will most likely land on, so concurrent
+// scheduling cycles do not all pick the same nearly full node. Entries
+// are dropped on bind or expire when the bind never comes.
+type assumedReservations struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]assumedEntry
+}
+
+type assumedEntry struct {
+	node   string
+	size   uint64
+	expiry time.Time
+}
+
+func newAssumedReservations(ttl time.Duration) *assumedReservations {
+	return &assumedReservations{
+		ttl:     ttl,
+		entries: make(map[string]assumedEntry),
+	}
+}
+
+// assume records that the pod will most likely be bound to node, an
+// earlier assumption of the same pod is replaced.
+func (a *assumedReservations) assume(podKey, node string, size uint64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.entries[podKey] = assumedEntry{
+		node:   node,
+		size:   size,
+		expiry: time.Now().Add(a.ttl),
+	}
+}
+
+// forget drops the assumption of a pod once its reservation reached the
+// api server, from then on PreAllocated covers it.
+func (a *assumedReservations) forget(podKey string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.entries, podKey)
+}
+
+// sizeOnNode sums the live assumptions on one node, the pod being
+// scored itself is skipped and expired entries are pruned.
+func (a *assumedReservations) sizeOnNode(node, self string) uint64 {
+	now := time.Now()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var result uint64
+	for key, entry := range a.entries {
+		if now.After(entry.expiry) {
+			delete(a.entries, key)
+			continue
+		}
+		if key == self || entry.node != node {
+			continue
+		}
+		result = result + entry.size
+	}
+	return result
+}
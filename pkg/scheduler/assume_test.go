@@ -0,0 +1,129 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	listerscorev1 "k8s.io/client-go/listers/core/v1"
+	listersstoragev1 "k8s.io/client-go/listers/storage/v1"
+	"k8s.io/client-go/tools/cache"
+	schedulerapi "k8s.io/kubernetes/pkg/scheduler/apis/extender/v1"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/apis/storage/v1alpha1"
+	lvlisters "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/listers/storage/v1alpha1"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+)
+
+// TestPrioritizeAssumedReservations scores two identical pending pods in
+// a row and expects the second one to be steered away from the node the
+// first one was assumed onto.
+func TestPrioritizeAssumedReservations(t *testing.T) {
+	lvIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	pvcIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	scIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+
+	scName := types.VGName
+	if err := scIndexer.Add(&storagev1.StorageClass{
+		ObjectMeta:  metav1.ObjectMeta{Name: scName},
+		Provisioner: types.DriverName,
+	}); err != nil {
+		t.Fatalf("add storageclass error = %s", err.Error())
+	}
+
+	// FreeSize 19 scores 9, FreeSize 15 scores 5, so node-a wins the
+	// first cycle
+	for name, freeSize := range map[string]uint64{"assume-node-a": 19, "assume-node-b": 15} {
+		if err := lvIndexer.Add(&v1alpha1.LocalVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: corev1.NamespaceDefault},
+			Status:     v1alpha1.LocalVolumeStatus{TotalSize: 100, FreeSize: freeSize},
+		}); err != nil {
+			t.Fatalf("add local volume error = %s", err.Error())
+		}
+	}
+
+	pods := make([]corev1.Pod, 0, 2)
+	for i := 0; i < 2; i++ {
+		pvcName := fmt.Sprintf("assume-claim-%d", i)
+		if err := pvcIndexer.Add(&corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: pvcName, Namespace: "assume"},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				StorageClassName: &scName,
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("5Gi")},
+				},
+			},
+		}); err != nil {
+			t.Fatalf("add pvc error = %s", err.Error())
+		}
+		pods = append(pods, corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("assume-pod-%d", i), Namespace: "assume"},
+			Spec: corev1.PodSpec{
+				Volumes: []corev1.Volume{{
+					Name: "data",
+					VolumeSource: corev1.VolumeSource{
+						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: pvcName},
+					},
+				}},
+			},
+		})
+	}
+
+	nodes := []corev1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "assume-node-a"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "assume-node-b"}},
+	}
+
+	lvs := &LocalVolumeScheduler{
+		pvcLister:          listerscorev1.NewPersistentVolumeClaimLister(pvcIndexer),
+		storageClassLister: listersstoragev1.NewStorageClassLister(scIndexer),
+		localVolumeLister:  lvlisters.NewLocalVolumeLister(lvIndexer),
+		assumed:            newAssumedReservations(*assumedReservationTTL),
+		ctx:                logging.WithLogger(context.Background(), zap.NewNop().Sugar()),
+	}
+
+	first, err := lvs.prioritize(pods[0], nodes)
+	if err != nil {
+		t.Fatalf("prioritize first pod error = %s", err.Error())
+	}
+	if host := topHost(first); host != "assume-node-a" {
+		t.Fatalf("first pod best node = %s, want assume-node-a", host)
+	}
+
+	// the first pod is now assumed on node-a, its 5Gi push the score of
+	// node-a below node-b for the second pod
+	second, err := lvs.prioritize(pods[1], nodes)
+	if err != nil {
+		t.Fatalf("prioritize second pod error = %s", err.Error())
+	}
+	if host := topHost(second); host != "assume-node-b" {
+		t.Errorf("second pod best node = %s, want assume-node-b", host)
+	}
+
+	// once the bind of the first pod arrived its assumption is gone
+	if size := lvs.assumed.sizeOnNode("assume-node-a", ""); size != 5 {
+		t.Errorf("assumed size on node-a = %d, want 5 of the first pod", size)
+	}
+	lvs.assumed.forget("assume/assume-pod-0")
+	if size := lvs.assumed.sizeOnNode("assume-node-a", ""); size != 0 {
+		t.Errorf("assumed size on node-a after forget = %d, want 0", size)
+	}
+}
+
+func topHost(list *schedulerapi.HostPriorityList) string {
+	best := ""
+	var bestScore int64 = -1
+	for _, hp := range *list {
+		if hp.Score > bestScore {
+			best = hp.Host
+			bestScore = hp.Score
+		}
+	}
+	return best
+}
@@ -0,0 +1,170 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	listerscorev1 "k8s.io/client-go/listers/core/v1"
+	listersstoragev1 "k8s.io/client-go/listers/storage/v1"
+	"k8s.io/client-go/tools/cache"
+	schedulerapi "k8s.io/kubernetes/pkg/scheduler/apis/extender/v1"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/apis/storage/v1alpha1"
+	lvlisters "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/listers/storage/v1alpha1"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+)
+
+// Benchmarks for the extender hot path, they drive the predicate and
+// prioritize handlers in-process against synthetic ExtenderArgs, run them
+// with
+//
+//	make bench-scheduler
+//
+// The cluster dimensions come from BENCH_NODES and BENCH_PODS, add
+// -memprofile/-cpuprofile to chase allocation hotspots.
+
+const benchNamespace = "bench"
+
+func benchDimension(env string, def int) int {
+	if value := os.Getenv(env); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			return n
+		}
+	}
+	return def
+}
+
+// newBenchWorld builds a scheduler backed by lister fixtures of n nodes
+// with a LocalVolume each and m pods each requesting one local volume pvc.
+func newBenchWorld(tb testing.TB, n, m int) (*LocalVolumeScheduler, []corev1.Node, []*corev1.Pod) {
+	lvIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	pvcIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	scIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+
+	scName := types.VGName
+	if err := scIndexer.Add(&storagev1.StorageClass{
+		ObjectMeta:  metav1.ObjectMeta{Name: scName},
+		Provisioner: types.DriverName,
+	}); err != nil {
+		tb.Fatalf("add storageclass error = %s", err.Error())
+	}
+
+	nodes := make([]corev1.Node, 0, n)
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("bench-node-%d", i)
+		nodes = append(nodes, corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}})
+		if err := lvIndexer.Add(&v1alpha1.LocalVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: corev1.NamespaceDefault},
+			Status: v1alpha1.LocalVolumeStatus{
+				TotalSize: 100,
+				FreeSize:  uint64(10 + i%90),
+				PreAllocated: map[string]string{
+					types.MakePVCKey(benchNamespace, fmt.Sprintf("bench-pvc-%d", i%m)): "",
+				},
+			},
+		}); err != nil {
+			tb.Fatalf("add local volume error = %s", err.Error())
+		}
+	}
+
+	pods := make([]*corev1.Pod, 0, m)
+	for j := 0; j < m; j++ {
+		pvcName := fmt.Sprintf("bench-pvc-%d", j)
+		if err := pvcIndexer.Add(&corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: pvcName, Namespace: benchNamespace},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				StorageClassName: &scName,
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceStorage: resource.MustParse(fmt.Sprintf("%dGi", 1+j%10)),
+					},
+				},
+			},
+		}); err != nil {
+			tb.Fatalf("add pvc error = %s", err.Error())
+		}
+		pods = append(pods, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("bench-pod-%d", j), Namespace: benchNamespace},
+			Spec: corev1.PodSpec{
+				Volumes: []corev1.Volume{{
+					Name: "data",
+					VolumeSource: corev1.VolumeSource{
+						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: pvcName},
+					},
+				}},
+			},
+		})
+	}
+
+	lvs := &LocalVolumeScheduler{
+		pvcLister:          listerscorev1.NewPersistentVolumeClaimLister(pvcIndexer),
+		storageClassLister: listersstoragev1.NewStorageClassLister(scIndexer),
+		localVolumeLister:  lvlisters.NewLocalVolumeLister(lvIndexer),
+		ctx:                logging.WithLogger(context.Background(), zap.NewNop().Sugar()),
+	}
+	return lvs, nodes, pods
+}
+
+func BenchmarkSchedulerPredicate(b *testing.B) {
+	n := benchDimension("BENCH_NODES", 100)
+	m := benchDimension("BENCH_PODS", 50)
+	lvs, nodes, pods := newBenchWorld(b, n, m)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		args := schedulerapi.ExtenderArgs{
+			Pod:   pods[i%len(pods)],
+			Nodes: &corev1.NodeList{Items: nodes},
+		}
+		result := lvs.PredicateHandler(args)
+		if result == nil {
+			b.Fatal("predicate returned no result")
+		}
+	}
+	b.StopTimer()
+	reportThroughput(b, n, start)
+}
+
+func BenchmarkSchedulerPrioritize(b *testing.B) {
+	n := benchDimension("BENCH_NODES", 100)
+	m := benchDimension("BENCH_PODS", 50)
+	lvs, nodes, pods := newBenchWorld(b, n, m)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		args := schedulerapi.ExtenderArgs{
+			Pod:   pods[i%len(pods)],
+			Nodes: &corev1.NodeList{Items: nodes},
+		}
+		if _, err := lvs.PrioritizeHandler(args); err != nil {
+			b.Fatalf("prioritize error = %s", err.Error())
+		}
+	}
+	b.StopTimer()
+	reportThroughput(b, n, start)
+}
+
+// reportThroughput adds pods/s and node evaluations/s to the benchmark
+// output.
+func reportThroughput(b *testing.B, nodesPerPod int, start time.Time) {
+	elapsed := time.Since(start).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	b.ReportMetric(float64(b.N)/elapsed, "pods/s")
+	b.ReportMetric(float64(b.N*nodesPerPod)/elapsed, "node-evals/s")
+}
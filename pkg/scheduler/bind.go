@@ -1,16 +1,41 @@
 package scheduler
 
 import (
-	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"flag"
+	"fmt"
+	"time"
+
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	schedulerapi "k8s.io/kubernetes/pkg/scheduler/apis/extender/v1"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/apis/storage/v1alpha1"
+	lverrors "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/errors"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/status"
 )
 
+var bindRetryBudget = flag.Duration("bind-retry-budget", 2*time.Second,
+	"Total time one bind call may spend retrying transient api failures before the error goes back to the scheduler, 0 fails fast")
+
 func (lvs *LocalVolumeScheduler) BindHandler(args schedulerapi.ExtenderBindingArgs) *schedulerapi.ExtenderBindingResult {
 	logger := logging.FromContext(lvs.ctx)
 
-	err := lvs.bind(args)
+	// a conflict or timeout is cheaper to retry here than to fail the
+	// whole scheduling cycle, the budget covers the reservation and the
+	// bind together
+	deadline := time.Now().Add(*bindRetryBudget)
+	err := lvs.retryTransient(deadline, "reserve", func() error {
+		return lvs.bind(args)
+	})
+
+	// the reservation is now either durable in PreAllocated or the
+	// scheduling cycle restarts from prioritize, either way the scoring
+	// assumption has served its purpose
+	if lvs.assumed != nil {
+		lvs.assumed.forget(args.PodNamespace + "/" + args.PodName)
+	}
 
 	if err != nil {
 		return &schedulerapi.ExtenderBindingResult{
@@ -24,40 +49,117 @@ func (lvs *LocalVolumeScheduler) BindHandler(args schedulerapi.ExtenderBindingAr
 				Name: args.Node,
 			},
 		}
-		if err := lvs.kubeClient.CoreV1().Pods(b.Namespace).Bind(b); err != nil {
+		if err := lvs.retryTransient(deadline, "bind", func() error {
+			return lvs.kubeClient.CoreV1().Pods(b.Namespace).Bind(b)
+		}); err != nil {
 			return &schedulerapi.ExtenderBindingResult{
 				Error: err.Error(),
 			}
 		}
 
+		lvs.slo.finish(args.PodNamespace + "/" + args.PodName)
 		logger.Infof("local volume scheduler handle bind: pod(%s) namespace(%s) bind node(%s) success",
 			args.PodName, args.PodNamespace, args.Node)
 		return &schedulerapi.ExtenderBindingResult{}
 	}
 }
 
+// retryTransient runs op until it succeeds, the error stops looking
+// transient or the deadline of the shared budget passes, with an
+// exponential backoff in between.
+func (lvs *LocalVolumeScheduler) retryTransient(deadline time.Time, step string, op func() error) error {
+	delay := 50 * time.Millisecond
+	for {
+		err := op()
+		if err == nil || !retriableBindError(err) || time.Now().Add(delay).After(deadline) {
+			return err
+		}
+		logging.FromContext(lvs.ctx).Infof("local volume scheduler %s retries in %v after: %s", step, delay, err.Error())
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// retriableBindError reports whether a retry inside the bind call can
+// still win, capacity and validation failures never can.
+func retriableBindError(err error) bool {
+	if category, ok := lverrors.CategoryOf(err); ok {
+		return category == lverrors.Conflict || category == lverrors.Transient
+	}
+	return apierrors.IsConflict(err) || apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) || apierrors.IsTooManyRequests(err)
+}
+
 func (lvs *LocalVolumeScheduler) bind(args schedulerapi.ExtenderBindingArgs) error {
 	pod, err := lvs.podLister.Pods(args.PodNamespace).Get(args.PodName)
+	if apierrors.IsNotFound(err) && lvs.kubeClient != nil {
+		// the pod can lag behind the watch right after creation, fall
+		// back to a live read on cache miss
+		pod, err = lvs.kubeClient.CoreV1().Pods(args.PodNamespace).Get(args.PodName, metav1.GetOptions{})
+	}
 	if err != nil {
 		return err
 	}
+	return lvs.reservePodClaims(pod, args.Node)
+}
+
+// reservePodClaims writes the reservations of all local claims of the
+// pod into the LocalVolume of the node, the bind path and the no-bind
+// reservation controller share it.
+func (lvs *LocalVolumeScheduler) reservePodClaims(pod *corev1.Pod, nodeName string) error {
 	pvcNames := lvs.getPodLocalVolumePVCNames(pod)
 
-	lv, err := lvs.localVolumeLister.LocalVolumes(corev1.NamespaceDefault).Get(args.Node)
+	lv, err := lvs.localVolumeLister.LocalVolumes(corev1.NamespaceDefault).Get(nodeName)
 	if err != nil {
 		return err
 	}
 
-	copylv := lv.DeepCopy()
-	if copylv.Status.PreAllocated == nil {
-		copylv.Status.PreAllocated = make(map[string]string)
+	// reserve the whole gang of claims or nothing, a pod with several
+	// local pvcs must not be left half reserved on a node that cannot
+	// hold all of them. Reservations of a retried bind are its own and
+	// do not count against the pod.
+	requestSize := lvs.getPodLocalVolumeRequestSize(pod)
+	reserved := lvs.preAllocatedSize(lv, pvcNames)
+	if lv.Status.FreeSize < reserved+requestSize {
+		return lverrors.Wrap(lverrors.Conflict, fmt.Errorf(
+			"node %s cannot hold all %d local claims of pod %s/%s: free %d, pending %d, requested %d",
+			nodeName, len(pvcNames), pod.Namespace, pod.Name, lv.Status.FreeSize, reserved, requestSize))
 	}
-	for _, v := range pvcNames {
-		copylv.Status.PreAllocated[v] = ""
+
+	// add only the new keys with a merge patch, applying the whole map
+	// from the informer cache would erase reservations of a concurrent
+	// bind the cache has not seen yet and resurrect entries the expiry
+	// controller just removed, both without a conflict to catch it
+	added := make([]string, 0, len(pvcNames))
+	for key := range pvcNames {
+		added = append(added, key)
 	}
-	if _, err := lvs.localVolumeClient.LocalV1alpha1().LocalVolumes(corev1.NamespaceDefault).UpdateStatus(copylv); err != nil {
-		return err
+	if err := status.AddPreAllocated(lvs.localVolumeClient, lv.Name, added); err != nil {
+		if apierrors.IsConflict(err) {
+			return lverrors.Wrap(lverrors.Conflict, err)
+		}
+		return lverrors.Wrap(lverrors.Transient, err)
 	}
 
+	lvs.logReservations(lv.Name, pvcNames)
 	return nil
 }
+
+// logReservations appends reserve records to the allocation log of the
+// node, failures are only logged, the reservation itself already stuck.
+func (lvs *LocalVolumeScheduler) logReservations(nodeName string, pvcNames map[string]string) {
+	now := metav1.Now()
+	records := make([]v1alpha1.AllocationRecord, 0, len(pvcNames))
+	for key := range pvcNames {
+		records = append(records, v1alpha1.AllocationRecord{
+			Op:    v1alpha1.AllocationOpReserve,
+			PVC:   key,
+			Size:  lvs.pvcRequestSize(key),
+			Time:  now,
+			Actor: status.SchedulerFieldManager,
+		})
+	}
+	if err := status.AppendAllocations(lvs.localVolumeClient, nodeName, records...); err != nil {
+		logging.FromContext(lvs.ctx).Warnf("append allocation log of node(%s) error = %s", nodeName, err.Error())
+	}
+}
@@ -0,0 +1,131 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	listerscorev1 "k8s.io/client-go/listers/core/v1"
+	listersstoragev1 "k8s.io/client-go/listers/storage/v1"
+	"k8s.io/client-go/tools/cache"
+	schedulerapi "k8s.io/kubernetes/pkg/scheduler/apis/extender/v1"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/apis/storage/v1alpha1"
+	lvlisters "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/listers/storage/v1alpha1"
+	lverrors "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/errors"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+)
+
+// TestBindGangCapacity binds a pod with two local claims against a node
+// that can only hold one of them, the bind must fail as a whole instead
+// of reserving half the gang.
+func TestBindGangCapacity(t *testing.T) {
+	lvIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	pvcIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	scIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	podIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+
+	scName := types.VGName
+	if err := scIndexer.Add(&storagev1.StorageClass{
+		ObjectMeta:  metav1.ObjectMeta{Name: scName},
+		Provisioner: types.DriverName,
+	}); err != nil {
+		t.Fatalf("add storageclass error = %s", err.Error())
+	}
+
+	if err := lvIndexer.Add(&v1alpha1.LocalVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "gang-node", Namespace: corev1.NamespaceDefault},
+		Status:     v1alpha1.LocalVolumeStatus{TotalSize: 100, FreeSize: 5},
+	}); err != nil {
+		t.Fatalf("add local volume error = %s", err.Error())
+	}
+
+	volumes := make([]corev1.Volume, 0, 2)
+	for i := 0; i < 2; i++ {
+		pvcName := fmt.Sprintf("gang-pvc-%d", i)
+		if err := pvcIndexer.Add(&corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: pvcName, Namespace: "gang"},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				StorageClassName: &scName,
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("3Gi")},
+				},
+			},
+		}); err != nil {
+			t.Fatalf("add pvc error = %s", err.Error())
+		}
+		volumes = append(volumes, corev1.Volume{
+			Name: pvcName,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: pvcName},
+			},
+		})
+	}
+
+	if err := podIndexer.Add(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "gang-pod", Namespace: "gang"},
+		Spec:       corev1.PodSpec{Volumes: volumes},
+	}); err != nil {
+		t.Fatalf("add pod error = %s", err.Error())
+	}
+
+	lvs := &LocalVolumeScheduler{
+		podLister:          listerscorev1.NewPodLister(podIndexer),
+		pvcLister:          listerscorev1.NewPersistentVolumeClaimLister(pvcIndexer),
+		storageClassLister: listersstoragev1.NewStorageClassLister(scIndexer),
+		localVolumeLister:  lvlisters.NewLocalVolumeLister(lvIndexer),
+		ctx:                logging.WithLogger(context.Background(), zap.NewNop().Sugar()),
+	}
+
+	err := lvs.bind(schedulerapi.ExtenderBindingArgs{
+		PodName:      "gang-pod",
+		PodNamespace: "gang",
+		Node:         "gang-node",
+	})
+	if err == nil {
+		t.Fatal("bind of a gang over node capacity did not fail")
+	}
+	if !lverrors.IsCategory(err, lverrors.Conflict) {
+		t.Errorf("bind error = %v, want a conflict", err)
+	}
+}
+
+func TestPreAllocatedSizeExcludes(t *testing.T) {
+	pvcIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for i := 0; i < 2; i++ {
+		if err := pvcIndexer.Add(&corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("gang-pvc-%d", i), Namespace: "gang"},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("3Gi")},
+				},
+			},
+		}); err != nil {
+			t.Fatalf("add pvc error = %s", err.Error())
+		}
+	}
+
+	lvs := &LocalVolumeScheduler{pvcLister: listerscorev1.NewPersistentVolumeClaimLister(pvcIndexer)}
+	lv := &v1alpha1.LocalVolume{
+		Status: v1alpha1.LocalVolumeStatus{
+			PreAllocated: map[string]string{
+				types.MakePVCKey("gang", "gang-pvc-0"): "",
+				types.MakePVCKey("gang", "gang-pvc-1"): "",
+			},
+		},
+	}
+
+	if got := lvs.preAllocatedSize(lv, nil); got != 6 {
+		t.Errorf("preAllocatedSize = %d, want 6", got)
+	}
+	exclude := map[string]string{types.MakePVCKey("gang", "gang-pvc-0"): ""}
+	if got := lvs.preAllocatedSize(lv, exclude); got != 3 {
+		t.Errorf("preAllocatedSize with exclusion = %d, want 3", got)
+	}
+}
@@ -0,0 +1,102 @@
+package scheduler
+
+import (
+	"flag"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/api/core/v1"
+	schedulerapi "k8s.io/kubernetes/pkg/scheduler/apis/extender/v1"
+)
+
+var predicateCacheTTL = flag.Duration("predicate-cache-ttl", time.Second,
+	"How long identical predicate results are answered from cache, 0 disables the cache")
+
+const predicateCacheMaxEntries = 1024
+
+// predicateCache memoizes predicate results for bursts of identical
+// pending pods. Entries are keyed by the request signature (local
+// volume request size and candidate nodes) and are only served while
+// the capacity snapshot they were computed from is still current, every
+// LocalVolume or pvc event bumps the snapshot version.
+type predicateCache struct {
+	version uint64
+
+	mu      sync.Mutex
+	entries map[string]predicateCacheEntry
+}
+
+type predicateCacheEntry struct {
+	result  *schedulerapi.ExtenderFilterResult
+	version uint64
+	expiry  time.Time
+}
+
+func newPredicateCache() *predicateCache {
+	return &predicateCache{
+		entries: make(map[string]predicateCacheEntry),
+	}
+}
+
+// bump invalidates all cached results, it is hooked up to the informer
+// events of the resources the predicate reads.
+func (c *predicateCache) bump() {
+	atomic.AddUint64(&c.version, 1)
+}
+
+func (c *predicateCache) get(key string) (*schedulerapi.ExtenderFilterResult, bool) {
+	version := atomic.LoadUint64(&c.version)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if entry.version != version || time.Now().After(entry.expiry) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.result, true
+}
+
+func (c *predicateCache) put(key string, result *schedulerapi.ExtenderFilterResult) {
+	version := atomic.LoadUint64(&c.version)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= predicateCacheMaxEntries {
+		c.entries = make(map[string]predicateCacheEntry)
+	}
+	c.entries[key] = predicateCacheEntry{
+		result:  result,
+		version: version,
+		expiry:  time.Now().Add(*predicateCacheTTL),
+	}
+}
+
+// predicateCacheKey builds the request signature, identical pods (same
+// request size) filtering the same candidate nodes share one entry.
+func predicateCacheKey(requestSize uint64, nodes []v1.Node) string {
+	names := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		name := node.Name
+		// a disk pressure flip must not be masked by a cached result
+		if nodeHasDiskPressure(&node) {
+			name += "!pressure"
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := fnv.New64a()
+	for _, name := range names {
+		h.Write([]byte(name))
+		h.Write([]byte{0})
+	}
+	return fmt.Sprintf("%d/%x", requestSize, h.Sum64())
+}
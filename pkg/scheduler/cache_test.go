@@ -0,0 +1,50 @@
+package scheduler
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	schedulerapi "k8s.io/kubernetes/pkg/scheduler/apis/extender/v1"
+)
+
+func TestPredicateCache(t *testing.T) {
+	lvs, nodes, pods := newBenchWorld(t, 4, 2)
+	lvs.predicateCache = newPredicateCache()
+
+	args := schedulerapi.ExtenderArgs{
+		Pod:   pods[0],
+		Nodes: &corev1.NodeList{Items: nodes},
+	}
+
+	first := lvs.PredicateHandler(args)
+	second := lvs.PredicateHandler(args)
+	if first != second {
+		t.Error("identical request must be served from cache")
+	}
+
+	// a capacity event invalidates the cached result
+	lvs.predicateCache.bump()
+	third := lvs.PredicateHandler(args)
+	if third == second {
+		t.Error("bumped cache must not serve the stale result")
+	}
+}
+
+func TestPredicateCacheKey(t *testing.T) {
+	nodes := []corev1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "node-a"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node-b"}},
+	}
+	reversed := []corev1.Node{nodes[1], nodes[0]}
+
+	if predicateCacheKey(5, nodes) != predicateCacheKey(5, reversed) {
+		t.Error("node order must not change the key")
+	}
+	if predicateCacheKey(5, nodes) == predicateCacheKey(6, nodes) {
+		t.Error("request size must change the key")
+	}
+	if predicateCacheKey(5, nodes) == predicateCacheKey(5, nodes[:1]) {
+		t.Error("candidate set must change the key")
+	}
+}
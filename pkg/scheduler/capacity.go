@@ -0,0 +1,53 @@
+package scheduler
+
+import (
+	"flag"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/metrics"
+)
+
+var nodeGroupLabel = flag.String("nodegroup-label", "cluster-autoscaler.kubernetes.io/node-group",
+	"Node label whose value groups nodes for the capacity exhausted autoscaler signal")
+
+const (
+	// unknownNodeGroup groups the nodes without the nodegroup label.
+	unknownNodeGroup = "unknown"
+
+	// ReasonCapacityExhausted is the event reason signaling that no
+	// node could fit the local volume request of a pod.
+	ReasonCapacityExhausted = "LocalVolumeCapacityExhausted"
+)
+
+// signalCapacityExhausted publishes that no node can fit the local
+// volume request of the pod: one warning event on the pod for operators
+// and one counter tick per rejected nodegroup, so autoscaler
+// integrations can add nodes with local disks.
+func (lvs *LocalVolumeScheduler) signalCapacityExhausted(pod *corev1.Pod, requestSize uint64, rejected []corev1.Node) {
+	seen := make(map[string]string)
+	groups := make([]string, 0, len(rejected))
+	for _, node := range rejected {
+		group := node.Labels[*nodeGroupLabel]
+		if group == "" {
+			group = unknownNodeGroup
+		}
+		if _, ok := seen[group]; ok {
+			continue
+		}
+		seen[group] = ""
+		groups = append(groups, group)
+		metrics.RecordCapacityExhausted(group)
+	}
+	sort.Strings(groups)
+
+	logging.FromContext(lvs.ctx).Infof("local volume scheduler handle predicate: pod(%s) namespace(%s) request size(%v) exceeds the free size of every node, nodegroups(%v)",
+		pod.Name, pod.Namespace, requestSize, groups)
+
+	if lvs.recorder != nil {
+		lvs.recorder.Eventf(pod, corev1.EventTypeWarning, ReasonCapacityExhausted,
+			"no node can fit the local volume request of %dGi, nodegroups out of capacity: %v", requestSize, groups)
+	}
+}
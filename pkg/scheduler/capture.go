@@ -0,0 +1,150 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	schedulerapi "k8s.io/kubernetes/pkg/scheduler/apis/extender/v1"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+)
+
+// captureRecord is one sanitized request/response pair of the extender.
+type captureRecord struct {
+	Time     time.Time       `json:"time"`
+	Path     string          `json:"path"`
+	Request  json.RawMessage `json:"request"`
+	Response json.RawMessage `json:"response"`
+}
+
+type capturer struct {
+	mu  sync.Mutex
+	dir string
+	max int
+	seq int
+}
+
+var captureState *capturer
+
+// EnableCapture turn on the capture debug mode, request/response pairs of
+// the predicate and bind handlers are recorded into a ring of max files
+// under dir.
+func EnableCapture(dir string, max int) error {
+	// the sequence number wraps modulo max, a ring without slots cannot
+	// hold a single capture
+	if max <= 0 {
+		return fmt.Errorf("capture ring size must be positive, got %d", max)
+	}
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return err
+	}
+	captureState = &capturer{dir: dir, max: max}
+	return nil
+}
+
+func captureRequest(path string, request, response []byte) {
+	if captureState == nil {
+		return
+	}
+	logger := logging.GetLogger()
+
+	record := captureRecord{
+		Time:     time.Now(),
+		Path:     path,
+		Request:  sanitizeRequest(path, request),
+		Response: response,
+	}
+	data, err := json.Marshal(&record)
+	if err != nil {
+		logger.Errorf("capture marshal record error = %s", err.Error())
+		return
+	}
+
+	captureState.mu.Lock()
+	defer captureState.mu.Unlock()
+	seq := captureState.seq
+	captureState.seq++
+
+	name := filepath.Join(captureState.dir, fmt.Sprintf("capture-%08d.json", seq%captureState.max))
+	if err := ioutil.WriteFile(name, data, 0640); err != nil {
+		logger.Errorf("capture write %s error = %s", name, err.Error())
+	}
+}
+
+// sanitizeRequest drop container env from the pod of a predicate request,
+// env may hold credentials and is useless for scheduling.
+func sanitizeRequest(path string, request []byte) []byte {
+	if path != predicatesPath {
+		return request
+	}
+
+	var args schedulerapi.ExtenderArgs
+	if err := json.Unmarshal(request, &args); err != nil || args.Pod == nil {
+		return request
+	}
+	for i := range args.Pod.Spec.InitContainers {
+		args.Pod.Spec.InitContainers[i].Env = nil
+		args.Pod.Spec.InitContainers[i].EnvFrom = nil
+	}
+	for i := range args.Pod.Spec.Containers {
+		args.Pod.Spec.Containers[i].Env = nil
+		args.Pod.Spec.Containers[i].EnvFrom = nil
+	}
+	out, err := json.Marshal(&args)
+	if err != nil {
+		return request
+	}
+	return out
+}
+
+// Replay feed all captured predicate requests from dir back into the
+// handlers and log recorded against current responses. Bind requests are
+// not replayed because binding mutates the cluster, only their recorded
+// responses are printed.
+func Replay(ctx context.Context, lvs *LocalVolumeScheduler, dir string) error {
+	logger := logging.FromContext(ctx)
+
+	files, err := filepath.Glob(filepath.Join(dir, "capture-*.json"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		var record captureRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			logger.Errorf("replay skip %s, unmarshal error = %s", file, err.Error())
+			continue
+		}
+
+		switch record.Path {
+		case predicatesPath:
+			var args schedulerapi.ExtenderArgs
+			if err := json.Unmarshal(record.Request, &args); err != nil {
+				logger.Errorf("replay skip %s, bad predicate request = %s", file, err.Error())
+				continue
+			}
+			result := lvs.PredicateHandler(args)
+			current, _ := json.Marshal(result)
+			logger.Infof("replay %s path(%s)\nrecorded response: %s\ncurrent response: %s",
+				file, record.Path, string(record.Response), string(current))
+		case bindPath:
+			logger.Infof("replay %s path(%s) skipped, bind is not replayed\nrecorded request: %s\nrecorded response: %s",
+				file, record.Path, string(record.Request), string(record.Response))
+		default:
+			logger.Infof("replay %s path(%s) has no replay handler", file, record.Path)
+		}
+	}
+	return nil
+}
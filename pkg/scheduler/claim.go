@@ -0,0 +1,98 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+)
+
+// ClaimValidationRoute validates claim creates and size updates against
+// the maxSize parameter of their local volume storage class, one
+// misconfigured claim cannot swallow the whole vg of a node this way.
+// Wire the path into a ValidatingWebhookConfiguration for
+// persistentvolumeclaims, admission only reaches it when the scheduler
+// serves tls.
+func ClaimValidationRoute(lvs *LocalVolumeScheduler) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		logger := logging.FromContext(lvs.ctx)
+		checkBody(w, r)
+		limitBody(w, r)
+
+		var review admissionv1beta1.AdmissionReview
+		if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		response := &admissionv1beta1.AdmissionResponse{Allowed: true}
+		if review.Request != nil {
+			response.UID = review.Request.UID
+
+			var pvc corev1.PersistentVolumeClaim
+			if err := json.Unmarshal(review.Request.Object.Raw, &pvc); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if reason := lvs.claimBlockReason(&pvc); reason != "" {
+				logger.Infof("local volume scheduler denies claim %s/%s: %s", pvc.Namespace, pvc.Name, reason)
+				response.Allowed = false
+				response.Result = &metav1.Status{Message: reason}
+			}
+		}
+
+		review.Response = response
+		resultBody, err := json.Marshal(review)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(resultBody)
+	}
+}
+
+// claimBlockReason returns why the claim may not be admitted, an empty
+// string allows it. Claims of other provisioners and classes without a
+// cap always pass.
+func (lvs *LocalVolumeScheduler) claimBlockReason(pvc *corev1.PersistentVolumeClaim) string {
+	className := ""
+	if class, ok := pvc.Annotations[corev1.BetaStorageClassAnnotation]; ok {
+		className = class
+	} else if pvc.Spec.StorageClassName != nil {
+		className = *pvc.Spec.StorageClassName
+	}
+	if className == "" {
+		return ""
+	}
+
+	sc, err := lvs.storageClassLister.Get(className)
+	if err != nil || sc.Provisioner != types.DriverName {
+		return ""
+	}
+	capValue := sc.Parameters[types.MaxSizeParam]
+	if capValue == "" {
+		return ""
+	}
+	max, err := resource.ParseQuantity(capValue)
+	if err != nil {
+		// a broken parameter is a class problem, provisioning surfaces it
+		return ""
+	}
+
+	request, ok := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	if !ok || request.Cmp(max) <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("claim requests %s but storage class %s caps local volumes at %s=%s",
+		request.String(), className, types.MaxSizeParam, capValue)
+}
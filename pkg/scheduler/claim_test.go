@@ -0,0 +1,83 @@
+package scheduler
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	listersstoragev1 "k8s.io/client-go/listers/storage/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+)
+
+// newClaimWorld builds a scheduler with one capped local class and one
+// foreign class without a cap.
+func newClaimWorld(t *testing.T) *LocalVolumeScheduler {
+	scIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+
+	if err := scIndexer.Add(&storagev1.StorageClass{
+		ObjectMeta:  metav1.ObjectMeta{Name: "local-capped"},
+		Provisioner: types.DriverName,
+		Parameters:  map[string]string{types.MaxSizeParam: "100Gi"},
+	}); err != nil {
+		t.Fatalf("add storageclass error = %s", err.Error())
+	}
+	if err := scIndexer.Add(&storagev1.StorageClass{
+		ObjectMeta:  metav1.ObjectMeta{Name: "remote"},
+		Provisioner: "other.csi.example.com",
+		Parameters:  map[string]string{types.MaxSizeParam: "1Gi"},
+	}); err != nil {
+		t.Fatalf("add storageclass error = %s", err.Error())
+	}
+
+	return &LocalVolumeScheduler{
+		storageClassLister: listersstoragev1.NewStorageClassLister(scIndexer),
+	}
+}
+
+func claimOf(className, request string) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "claim-1", Namespace: "apps"},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			StorageClassName: &className,
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: resource.MustParse(request),
+				},
+			},
+		},
+	}
+}
+
+func TestClaimOverCapBlocked(t *testing.T) {
+	lvs := newClaimWorld(t)
+	if reason := lvs.claimBlockReason(claimOf("local-capped", "200Gi")); reason == "" {
+		t.Fatal("claim over the class cap was allowed")
+	}
+}
+
+func TestClaimWithinCapAllowed(t *testing.T) {
+	lvs := newClaimWorld(t)
+	if reason := lvs.claimBlockReason(claimOf("local-capped", "100Gi")); reason != "" {
+		t.Fatalf("claim at the class cap was blocked: %s", reason)
+	}
+}
+
+func TestClaimOfForeignClassIgnored(t *testing.T) {
+	lvs := newClaimWorld(t)
+	if reason := lvs.claimBlockReason(claimOf("remote", "200Gi")); reason != "" {
+		t.Fatalf("claim of a foreign provisioner was blocked: %s", reason)
+	}
+}
+
+func TestClaimWithoutClassIgnored(t *testing.T) {
+	lvs := newClaimWorld(t)
+	pvc := claimOf("", "200Gi")
+	pvc.Spec.StorageClassName = nil
+	if reason := lvs.claimBlockReason(pvc); reason != "" {
+		t.Fatalf("claim without a class was blocked: %s", reason)
+	}
+}
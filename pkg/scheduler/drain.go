@@ -0,0 +1,100 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/apis/storage/v1alpha1"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+)
+
+// DrainValidationRoute validates pod evictions, a drain may not evict a
+// pod with local data before every local claim of the pod has a
+// succeeded LocalVolumeMigration. A migration reaches Succeeded once the
+// data sits on the target node and the new volume is parked for the
+// claim name, the eviction the gate then admits completes the handover.
+// The check is skipped when the pod carries the force-drain annotation.
+// Admission requests only reach the route when the scheduler serves tls,
+// wire the path into a ValidatingWebhookConfiguration for pods/eviction.
+func DrainValidationRoute(lvs *LocalVolumeScheduler) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		logger := logging.FromContext(lvs.ctx)
+		checkBody(w, r)
+		limitBody(w, r)
+
+		var review admissionv1beta1.AdmissionReview
+		if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		response := &admissionv1beta1.AdmissionResponse{Allowed: true}
+		if review.Request != nil {
+			response.UID = review.Request.UID
+			if reason := lvs.evictionBlockReason(review.Request.Namespace, review.Request.Name); reason != "" {
+				logger.Infof("local volume scheduler denies eviction of pod(%s/%s): %s",
+					review.Request.Namespace, review.Request.Name, reason)
+				response.Allowed = false
+				response.Result = &metav1.Status{Message: reason}
+			}
+		}
+
+		review.Response = response
+		resultBody, err := json.Marshal(review)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(resultBody)
+	}
+}
+
+// evictionBlockReason returns why the pod may not be evicted, an empty
+// string allows the eviction.
+func (lvs *LocalVolumeScheduler) evictionBlockReason(namespace, name string) string {
+	pod, err := lvs.podLister.Pods(namespace).Get(name)
+	if apierrors.IsNotFound(err) && lvs.kubeClient != nil {
+		pod, err = lvs.kubeClient.CoreV1().Pods(namespace).Get(name, metav1.GetOptions{})
+	}
+	if err != nil {
+		// a pod the scheduler cannot see does not block the drain
+		return ""
+	}
+
+	if pod.Annotations[types.ForceDrainAnnotation] == "true" {
+		return ""
+	}
+
+	for pvcKey := range lvs.getPodLocalVolumePVCNames(pod) {
+		_, pvcName := types.SplitPVCKey(pvcKey)
+		if !lvs.pvcMigrated(namespace, pvcName) {
+			return fmt.Sprintf("claim %s holds local data without a succeeded migration, "+
+				"create a LocalVolumeMigration or annotate the pod with %s=true",
+				pvcName, types.ForceDrainAnnotation)
+		}
+	}
+	return ""
+}
+
+// pvcMigrated reports whether a succeeded migration covers the claim.
+func (lvs *LocalVolumeScheduler) pvcMigrated(namespace, pvcName string) bool {
+	migrations, err := lvs.localVolumeClient.LocalV1alpha1().LocalVolumeMigrations(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return false
+	}
+	for _, m := range migrations.Items {
+		if m.Spec.PVCName == pvcName && m.Status.Phase == v1alpha1.MigrationPhaseSucceeded {
+			return true
+		}
+	}
+	return false
+}
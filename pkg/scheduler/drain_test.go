@@ -0,0 +1,98 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	listerscorev1 "k8s.io/client-go/listers/core/v1"
+	listersstoragev1 "k8s.io/client-go/listers/storage/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/apis/storage/v1alpha1"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/clientset/versioned/fake"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+)
+
+// newDrainWorld builds a scheduler whose pod drain-1 uses one local
+// claim, the migration objects come from the caller.
+func newDrainWorld(t *testing.T, pod *corev1.Pod, migrations ...*v1alpha1.LocalVolumeMigration) *LocalVolumeScheduler {
+	pvcIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	scIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	podIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+
+	scName := types.VGName
+	if err := scIndexer.Add(&storagev1.StorageClass{
+		ObjectMeta:  metav1.ObjectMeta{Name: scName},
+		Provisioner: types.DriverName,
+	}); err != nil {
+		t.Fatalf("add storageclass error = %s", err.Error())
+	}
+	if err := pvcIndexer.Add(&corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "drain-pvc", Namespace: "drain"},
+		Spec:       corev1.PersistentVolumeClaimSpec{StorageClassName: &scName},
+	}); err != nil {
+		t.Fatalf("add pvc error = %s", err.Error())
+	}
+	if err := podIndexer.Add(pod); err != nil {
+		t.Fatalf("add pod error = %s", err.Error())
+	}
+
+	client := fake.NewSimpleClientset()
+	for _, m := range migrations {
+		if _, err := client.LocalV1alpha1().LocalVolumeMigrations(m.Namespace).Create(m); err != nil {
+			t.Fatalf("create migration error = %s", err.Error())
+		}
+	}
+
+	return &LocalVolumeScheduler{
+		podLister:          listerscorev1.NewPodLister(podIndexer),
+		pvcLister:          listerscorev1.NewPersistentVolumeClaimLister(pvcIndexer),
+		storageClassLister: listersstoragev1.NewStorageClassLister(scIndexer),
+		localVolumeClient:  client,
+		ctx:                logging.WithLogger(context.Background(), zap.NewNop().Sugar()),
+	}
+}
+
+func drainTestPod(annotations map[string]string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "drain-1", Namespace: "drain", Annotations: annotations},
+		Spec: corev1.PodSpec{
+			Volumes: []corev1.Volume{{
+				Name: "data",
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "drain-pvc"},
+				},
+			}},
+		},
+	}
+}
+
+func TestEvictionBlockedWithoutMigration(t *testing.T) {
+	lvs := newDrainWorld(t, drainTestPod(nil))
+	if reason := lvs.evictionBlockReason("drain", "drain-1"); reason == "" {
+		t.Fatal("eviction of a pod with unmigrated local data was allowed")
+	}
+}
+
+func TestEvictionAllowedAfterMigration(t *testing.T) {
+	lvs := newDrainWorld(t, drainTestPod(nil), &v1alpha1.LocalVolumeMigration{
+		ObjectMeta: metav1.ObjectMeta{Name: "move-drain-pvc", Namespace: "drain"},
+		Spec:       v1alpha1.LocalVolumeMigrationSpec{PVCName: "drain-pvc", TargetNode: "other-node"},
+		Status:     v1alpha1.LocalVolumeMigrationStatus{Phase: v1alpha1.MigrationPhaseSucceeded},
+	})
+	if reason := lvs.evictionBlockReason("drain", "drain-1"); reason != "" {
+		t.Fatalf("eviction blocked although the claim is migrated: %s", reason)
+	}
+}
+
+func TestEvictionAllowedWithOverride(t *testing.T) {
+	lvs := newDrainWorld(t, drainTestPod(map[string]string{types.ForceDrainAnnotation: "true"}))
+	if reason := lvs.evictionBlockReason("drain", "drain-1"); reason != "" {
+		t.Fatalf("eviction blocked although the pod carries the override: %s", reason)
+	}
+}
@@ -0,0 +1,155 @@
+package scheduler
+
+import (
+	"context"
+	"flag"
+	"time"
+
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/injection/informers/storage/v1alpha1/localvolume"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/controller"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/metrics"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/status"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+)
+
+var (
+	reservationTTL = flag.Duration("reservation-ttl", 10*time.Minute,
+		"Reservations whose volume is not provisioned within this window are expired and their capacity freed, 0 keeps reservations forever")
+)
+
+const (
+	// ReconcilerName is the name of the reconciler
+	ExpiryReconcilerName = "ReservationExpiry"
+)
+
+// NewExpiryController ages the PreAllocated entries of every node and
+// removes the ones whose claim never got its volume, a deleted pod or a
+// failed provisioning would otherwise hold the reserved capacity
+// forever.
+func NewExpiryController(
+	ctx context.Context,
+	lvs *LocalVolumeScheduler,
+) *controller.Impl {
+	logger := logging.FromContext(ctx)
+	lvInformer := localvolume.Get(ctx)
+
+	r := &ExpiryReconciler{
+		lvs: lvs,
+	}
+
+	impl := controller.NewImpl(r, logger, ExpiryReconcilerName)
+	// entries age without any event arriving, the reconciler paces
+	// itself through requeues while reservations are pending
+	r.enqueueAfter = impl.EnqueueKeyAfter
+
+	lvInformer.Informer().AddEventHandler(controller.HandleAll(impl.Enqueue))
+
+	logger.Info("Reservation Expiry Controller Started")
+	return impl
+}
+
+type ExpiryReconciler struct {
+	lvs          *LocalVolumeScheduler
+	enqueueAfter func(key k8stypes.NamespacedName, delay time.Duration)
+}
+
+func (r *ExpiryReconciler) Reconcile(ctx context.Context, key string) error {
+	logger := logging.FromContext(ctx)
+
+	if *reservationTTL <= 0 {
+		return nil
+	}
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logger.Errorw("Invalid resource key", zap.Error(err))
+		return nil
+	}
+
+	lv, err := r.lvs.localVolumeLister.LocalVolumes(namespace).Get(name)
+	if err != nil && apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	expired := make([]string, 0)
+	pending := false
+	for pvcKey, value := range lv.Status.PreAllocated {
+		age, ok := status.ReservationAge(value)
+		if !ok {
+			// unstamped entry of an older scheduler, age unknown
+			continue
+		}
+		if age < *reservationTTL {
+			pending = true
+			continue
+		}
+
+		claimNamespace, claimName := types.SplitPVCKey(pvcKey)
+		if claimNamespace == "" {
+			continue
+		}
+		pvc, err := r.lvs.getPVC(claimNamespace, claimName)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+		// a bound claim got its volume, the agent converts the
+		// reservation when the publish lands
+		if err == nil && pvc.Spec.VolumeName != "" {
+			continue
+		}
+		// a pod still placed on the node may just be slow to start, give
+		// the provisioning more time
+		if err == nil && r.podOnNodeWantsClaim(claimNamespace, claimName, lv.Name) {
+			pending = true
+			continue
+		}
+		expired = append(expired, pvcKey)
+	}
+
+	if len(expired) > 0 {
+		if err := status.RemovePreAllocated(r.lvs.localVolumeClient, lv.Name, expired); err != nil {
+			return err
+		}
+		metrics.RecordReservationExpired(lv.Name, len(expired))
+		logger.Infof("Reservation Expiry Controller expired %d reservations on node(%s): %v",
+			len(expired), lv.Name, expired)
+	}
+
+	if pending && r.enqueueAfter != nil {
+		delay := *reservationTTL
+		if delay > time.Minute {
+			delay = time.Minute
+		}
+		r.enqueueAfter(k8stypes.NamespacedName{Namespace: namespace, Name: name}, delay)
+	}
+	return nil
+}
+
+// podOnNodeWantsClaim reports whether a pod placed on the node still
+// references the claim.
+func (r *ExpiryReconciler) podOnNodeWantsClaim(namespace, claimName, nodeName string) bool {
+	pods, err := r.lvs.podLister.Pods(namespace).List(labels.Everything())
+	if err != nil {
+		return false
+	}
+	for _, p := range pods {
+		if p.Spec.NodeName != nodeName {
+			continue
+		}
+		for _, volume := range p.Spec.Volumes {
+			if volume.PersistentVolumeClaim != nil && volume.PersistentVolumeClaim.ClaimName == claimName {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+)
+
+var explainAfterFailures = flag.Int("explain-after-failures", 3,
+	"Consecutive scheduling failures of a pod after which the extender writes the explanation annotation, 0 disables it")
+
+// explainTracker counts consecutive attempts where no node passed the
+// predicate for a pod, the explanation only goes out once the pod keeps
+// failing, one unlucky cycle is not worth an annotation write.
+type explainTracker struct {
+	mu       sync.Mutex
+	failures map[string]int
+}
+
+func newExplainTracker() *explainTracker {
+	return &explainTracker{failures: make(map[string]int)}
+}
+
+func (t *explainTracker) fail(key string) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.failures[key]++
+	return t.failures[key]
+}
+
+func (t *explainTracker) reset(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.failures, key)
+}
+
+// schedulingExplanation is the annotation payload, one entry per node
+// with the capacity numbers or the reason it was rejected on.
+type schedulingExplanation struct {
+	Attempts    int               `json:"attempts"`
+	RequestGB   uint64            `json:"requestGB"`
+	EvaluatedAt string            `json:"evaluatedAt"`
+	Nodes       map[string]string `json:"nodes"`
+}
+
+// explainSchedulingFailure annotates the pod with the evaluated capacity
+// numbers once it has failed often enough, self service explain data
+// without access to the extender logs.
+func (lvs *LocalVolumeScheduler) explainSchedulingFailure(pod *corev1.Pod, requestSize uint64,
+	capacityRejected []corev1.Node, failed map[string]string) {
+	if lvs.explain == nil || lvs.kubeClient == nil || *explainAfterFailures <= 0 {
+		return
+	}
+	attempts := lvs.explain.fail(types.MakePVCKey(pod.Namespace, pod.Name))
+	if attempts < *explainAfterFailures {
+		return
+	}
+
+	nodes := make(map[string]string, len(capacityRejected)+len(failed))
+	for _, node := range capacityRejected {
+		nodes[node.Name] = fmt.Sprintf("free %dGi does not fit request %dGi plus reservations",
+			lvs.getNodeFreeSize(node.Name), requestSize)
+	}
+	for name, reason := range failed {
+		nodes[name] = reason
+	}
+
+	explanation, err := json.Marshal(schedulingExplanation{
+		Attempts:    attempts,
+		RequestGB:   requestSize,
+		EvaluatedAt: time.Now().UTC().Format(time.RFC3339),
+		Nodes:       nodes,
+	})
+	if err != nil {
+		return
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				types.SchedulingExplanationAnnotation: string(explanation),
+			},
+		},
+	})
+	if err != nil {
+		return
+	}
+
+	if _, err := lvs.kubeClient.CoreV1().Pods(pod.Namespace).Patch(pod.Name, k8stypes.MergePatchType, patch); err != nil {
+		logging.FromContext(lvs.ctx).Warnf("annotate scheduling explanation of pod(%s/%s) error = %s",
+			pod.Namespace, pod.Name, err.Error())
+		return
+	}
+	logging.FromContext(lvs.ctx).Infof("local volume scheduler explained %d failed attempts on pod(%s/%s)",
+		attempts, pod.Namespace, pod.Name)
+}
+
+// clearSchedulingFailures forgets the failure streak of a pod once a
+// node passed again.
+func (lvs *LocalVolumeScheduler) clearSchedulingFailures(pod *corev1.Pod) {
+	if lvs.explain != nil {
+		lvs.explain.reset(types.MakePVCKey(pod.Namespace, pod.Name))
+	}
+}
@@ -1,11 +1,20 @@
 package scheduler
 
 import (
+	"flag"
+	"fmt"
+
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
 	"k8s.io/api/core/v1"
 	schedulerapi "k8s.io/kubernetes/pkg/scheduler/apis/extender/v1"
 )
 
+var (
+	rejectDiskPressure = flag.Bool("reject-disk-pressure", true,
+		"Reject nodes whose kubelet reports the DiskPressure condition, kubelet folds nodefs and imagefs pressure into it and would evict the pod right after placement")
+)
+
 func (lvs *LocalVolumeScheduler) PredicateHandler(args schedulerapi.ExtenderArgs) *schedulerapi.ExtenderFilterResult {
 	pod := args.Pod
 	canSchedule := make([]v1.Node, 0, len(args.Nodes.Items))
@@ -13,6 +22,29 @@ func (lvs *LocalVolumeScheduler) PredicateHandler(args schedulerapi.ExtenderArgs
 	canNotSchedule := make(map[string]string)
 	logger := logging.FromContext(lvs.ctx)
 
+	// the decision clock of a pod with a local request starts at its
+	// first filter call and stops at the successful bind
+	if lvs.getPodLocalVolumeRequestSize(pod) > 0 {
+		lvs.slo.begin(types.MakePVCKey(pod.Namespace, pod.Name))
+	}
+
+	// bursts of identical pending pods are answered from cache, the
+	// namespace, daemonset identity and priority band take part in the
+	// key because the capacity reservations and headroom depend on them
+	cacheKey := ""
+	if lvs.predicateCache != nil && *predicateCacheTTL > 0 {
+		if requestSize := lvs.getPodLocalVolumeRequestSize(pod); requestSize > 0 {
+			cacheKey = fmt.Sprintf("%s|%s|%s|%t",
+				predicateCacheKey(requestSize, args.Nodes.Items), pod.Namespace, daemonSetOf(pod), isHighPriority(pod))
+			if result, ok := lvs.predicateCache.get(cacheKey); ok {
+				logger.Infof("local volume scheduler handle predicate: pod(%s) namespace(%s) served from cache",
+					pod.Name, pod.Namespace)
+				return result
+			}
+		}
+	}
+
+	capacityRejected := make([]v1.Node, 0)
 	for _, node := range args.Nodes.Items {
 		result, err := lvs.predicate(*pod, node)
 		if err != nil {
@@ -20,9 +52,25 @@ func (lvs *LocalVolumeScheduler) PredicateHandler(args schedulerapi.ExtenderArgs
 		} else if result {
 			canSchedule = append(canSchedule, node)
 			canScheduleNodeNames[node.Name] = ""
+		} else {
+			capacityRejected = append(capacityRejected, node)
 		}
 	}
 
+	// every node rejected on capacity is the signal an autoscaler can
+	// act on, nodes failing for other reasons do not count
+	if len(canSchedule) == 0 && len(capacityRejected) > 0 {
+		lvs.signalCapacityExhausted(pod, lvs.getPodLocalVolumeRequestSize(pod), capacityRejected)
+	}
+
+	// a pod failing everywhere again and again gets the numbers written
+	// on itself, pods with no local request never count as failing
+	if requestSize := lvs.getPodLocalVolumeRequestSize(pod); requestSize > 0 && len(canSchedule) == 0 {
+		lvs.explainSchedulingFailure(pod, requestSize, capacityRejected, canNotSchedule)
+	} else {
+		lvs.clearSchedulingFailures(pod)
+	}
+
 	result := schedulerapi.ExtenderFilterResult{
 		Nodes: &v1.NodeList{
 			Items: canSchedule,
@@ -34,6 +82,10 @@ func (lvs *LocalVolumeScheduler) PredicateHandler(args schedulerapi.ExtenderArgs
 	logger.Infof("local volume scheduler handle predicate: pod(%s) namespace(%s) can schedule nodes(%v)",
 		pod.Name, pod.Namespace, canScheduleNodeNames)
 
+	if cacheKey != "" {
+		lvs.predicateCache.put(cacheKey, &result)
+	}
+
 	return &result
 }
 
@@ -49,8 +101,40 @@ func (lvs *LocalVolumeScheduler) predicate(pod v1.Pod, node v1.Node) (bool, erro
 		return true, nil
 	}
 
-	if lvFreeSize >= requestSize {
+	if lvs.isNodeStorageCordoned(node.Name) {
+		return false, fmt.Errorf("node(%s) local volume storage is cordoned", node.Name)
+	}
+
+	if lvs.isNodeAgentStale(node.Name) {
+		return false, fmt.Errorf("node(%s) local volume agent heartbeat is stale", node.Name)
+	}
+
+	// a node under disk pressure evicts what it gets, when the vg shares
+	// the device with the image filesystem the new volume would make the
+	// pressure worse on top
+	if *rejectDiskPressure && nodeHasDiskPressure(&node) {
+		return false, fmt.Errorf("node(%s) kubelet reports disk pressure", node.Name)
+	}
+
+	// the reserved slice of the node only counts against pods outside
+	// its consumer list, the priority headroom only against low
+	// priority pods
+	reserved := lvs.reservedSizeFor(&pod, node.Name)
+	headroom := lvs.priorityHeadroom(&pod, node.Name)
+
+	if lvFreeSize >= requestSize+reserved+headroom {
 		return true, nil
 	}
 	return false, nil
 }
+
+// nodeHasDiskPressure reports whether the kubelet of the node currently
+// raises the DiskPressure condition.
+func nodeHasDiskPressure(node *v1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeDiskPressure {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -1,15 +1,24 @@
 package scheduler
 
 import (
-	"math"
 	"math/rand"
+	"strings"
 	"time"
 
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
 	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	schedulerapi "k8s.io/kubernetes/pkg/scheduler/apis/extender/v1"
 )
 
+const (
+	// statefulSetSpreadPenalty lowers the score of a node for every
+	// replica of the pod's StatefulSet already placed on it, the zone
+	// penalty is softer so node spread wins over zone spread.
+	statefulSetSpreadPenalty     = 4
+	statefulSetZoneSpreadPenalty = 2
+)
+
 func (lvs *LocalVolumeScheduler) PrioritizeHandler(args schedulerapi.ExtenderArgs) (*schedulerapi.HostPriorityList, error) {
 	return lvs.prioritize(*args.Pod, args.Nodes.Items)
 }
@@ -19,10 +28,38 @@ func (lvs *LocalVolumeScheduler) prioritize(pod v1.Pod, nodes []v1.Node) (*sched
 	logger := logging.FromContext(lvs.ctx)
 	requestSize := lvs.getPodLocalVolumeRequestSize(&pod)
 
+	// spread replicas of the same StatefulSet over nodes and zones, a
+	// node or zone loss should not take several of them out at once
+	var replicasPerNode, replicasPerZone map[string]int
+	if requestSize > 0 {
+		if owner := statefulSetOf(&pod); owner != "" {
+			replicasPerNode = lvs.statefulSetReplicasPerNode(pod.Namespace, owner, pod.Name)
+			replicasPerZone = make(map[string]int)
+			for i := range nodes {
+				if zone := nodeZone(&nodes[i]); zone != "" {
+					replicasPerZone[zone] += replicasPerNode[nodes[i].Name]
+				}
+			}
+		}
+	}
+
+	podKey := pod.Namespace + "/" + pod.Name
+
 	var priorityList schedulerapi.HostPriorityList
 	priorityList = make([]schedulerapi.HostPriority, len(nodes))
 	for i, node := range nodes {
 		freeSize := lvs.getNodeFreeSize(node.Name)
+
+		// other pending pods already scored onto the node count against
+		// it until their bind lands, concurrent cycles must not all pick
+		// the same nearly full node
+		if lvs.assumed != nil {
+			if assumed := lvs.assumed.sizeOnNode(node.Name, podKey); assumed >= freeSize {
+				freeSize = 0
+			} else {
+				freeSize -= assumed
+			}
+		}
 		logger.Infof("local volume scheduler handle pod(%s, namespace = %s) requestsize(%d) prioritize: node(%s) free size(%d)",
 			pod.Namespace, pod.Name, requestSize, node.Name, freeSize)
 
@@ -30,23 +67,101 @@ func (lvs *LocalVolumeScheduler) prioritize(pod v1.Pod, nodes []v1.Node) (*sched
 			Host: node.Name,
 		}
 
-		if requestSize == 0 && freeSize == 0 {
+		if lvs.isNodeSlowDisk(node.Name) {
+			// failing or slow disks get the lowest score
+			priorityList[i].Score = 0
+
+		} else if requestSize == 0 && freeSize == 0 {
 			priorityList[i].Score = randInt64Range(1, 10)
 
 		} else if requestSize == 0 && freeSize > 0 {
 			priorityList[i].Score = randInt64Range(1, 5)
 
 		} else if freeSize > requestSize {
-			priorityList[i].Score = getScoreByNodeLocalVolumeSize(int64(freeSize))
+			score := getScoreByNodeLocalVolumeSize(int64(freeSize))
+			if n := replicasPerNode[node.Name]; n > 0 {
+				score -= int64(n) * statefulSetSpreadPenalty
+			}
+			if zone := nodeZone(&node); zone != "" {
+				// siblings on other nodes of the zone, the ones on the
+				// node itself are already penalized above
+				if n := replicasPerZone[zone] - replicasPerNode[node.Name]; n > 0 {
+					score -= int64(n) * statefulSetZoneSpreadPenalty
+				}
+			}
+			// a node with enough free space still beats the infeasible ones
+			if score < 1 {
+				score = 1
+			}
+			priorityList[i].Score = score
 
 		} else {
 			priorityList[i].Score = 0
 		}
 	}
 
+	// the scheduler will most likely pick the best scored node, reserve
+	// the request there until the bind arrives or the assumption expires
+	if requestSize > 0 && lvs.assumed != nil && *assumedReservationTTL > 0 {
+		best := -1
+		for i := range priorityList {
+			if priorityList[i].Score > 0 && (best < 0 || priorityList[i].Score > priorityList[best].Score) {
+				best = i
+			}
+		}
+		if best >= 0 {
+			lvs.assumed.assume(podKey, priorityList[best].Host, requestSize)
+		}
+	}
+
 	return &priorityList, nil
 }
 
+// statefulSetOf returns the name of the StatefulSet the pod belongs to,
+// from the owner reference or, when the pod carries none, the pod-name
+// label every StatefulSet pod has. Empty when the pod is not part of one.
+func statefulSetOf(pod *v1.Pod) string {
+	for _, ref := range pod.OwnerReferences {
+		if ref.Kind == "StatefulSet" {
+			return ref.Name
+		}
+	}
+	if _, ok := pod.Labels["statefulset.kubernetes.io/pod-name"]; ok {
+		// pod names follow <statefulset>-<ordinal>
+		if i := strings.LastIndex(pod.Name, "-"); i > 0 {
+			return pod.Name[:i]
+		}
+	}
+	return ""
+}
+
+// statefulSetReplicasPerNode counts the already placed pods of the
+// StatefulSet by node, the pod being scheduled itself is skipped.
+func (lvs *LocalVolumeScheduler) statefulSetReplicasPerNode(namespace, name, self string) map[string]int {
+	result := make(map[string]int)
+	if lvs.podLister == nil {
+		return result
+	}
+	pods, err := lvs.podLister.Pods(namespace).List(labels.Everything())
+	if err != nil {
+		return result
+	}
+	for _, p := range pods {
+		if p.Name == self || p.Spec.NodeName == "" || statefulSetOf(p) != name {
+			continue
+		}
+		result[p.Spec.NodeName]++
+	}
+	return result
+}
+
+func nodeZone(node *v1.Node) string {
+	if zone, ok := node.Labels[v1.LabelZoneFailureDomainStable]; ok {
+		return zone
+	}
+	return node.Labels[v1.LabelZoneFailureDomain]
+}
+
 func getScoreByNodeLocalVolumeSize(localvolumeSize int64) int64 {
 	score := localvolumeSize % 10
 
@@ -0,0 +1,149 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	listerscorev1 "k8s.io/client-go/listers/core/v1"
+	listersstoragev1 "k8s.io/client-go/listers/storage/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/apis/storage/v1alpha1"
+	lvlisters "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/listers/storage/v1alpha1"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+)
+
+// TestPrioritizeStatefulSetSpread places two replicas of a StatefulSet in
+// one zone and expects the remaining replica to score highest on the node
+// of the empty zone.
+func TestPrioritizeStatefulSetSpread(t *testing.T) {
+	lvIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	pvcIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	scIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	podIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+
+	scName := types.VGName
+	if err := scIndexer.Add(&storagev1.StorageClass{
+		ObjectMeta:  metav1.ObjectMeta{Name: scName},
+		Provisioner: types.DriverName,
+	}); err != nil {
+		t.Fatalf("add storageclass error = %s", err.Error())
+	}
+
+	zones := []string{"z1", "z1", "z2"}
+	nodes := make([]corev1.Node, 0, len(zones))
+	for i, zone := range zones {
+		name := fmt.Sprintf("spread-node-%d", i)
+		nodes = append(nodes, corev1.Node{ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{corev1.LabelZoneFailureDomainStable: zone},
+		}})
+		// FreeSize 20 gives every node the same base score
+		if err := lvIndexer.Add(&v1alpha1.LocalVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: corev1.NamespaceDefault},
+			Status:     v1alpha1.LocalVolumeStatus{TotalSize: 100, FreeSize: 20},
+		}); err != nil {
+			t.Fatalf("add local volume error = %s", err.Error())
+		}
+	}
+
+	owner := metav1.OwnerReference{Kind: "StatefulSet", Name: "web"}
+	for i := 0; i < 2; i++ {
+		if err := podIndexer.Add(&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            fmt.Sprintf("web-%d", i),
+				Namespace:       "spread",
+				OwnerReferences: []metav1.OwnerReference{owner},
+			},
+			Spec: corev1.PodSpec{NodeName: fmt.Sprintf("spread-node-%d", i)},
+		}); err != nil {
+			t.Fatalf("add pod error = %s", err.Error())
+		}
+	}
+
+	if err := pvcIndexer.Add(&corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "data-web-2", Namespace: "spread"},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			StorageClassName: &scName,
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("5Gi")},
+			},
+		},
+	}); err != nil {
+		t.Fatalf("add pvc error = %s", err.Error())
+	}
+
+	lvs := &LocalVolumeScheduler{
+		podLister:          listerscorev1.NewPodLister(podIndexer),
+		pvcLister:          listerscorev1.NewPersistentVolumeClaimLister(pvcIndexer),
+		storageClassLister: listersstoragev1.NewStorageClassLister(scIndexer),
+		localVolumeLister:  lvlisters.NewLocalVolumeLister(lvIndexer),
+		ctx:                logging.WithLogger(context.Background(), zap.NewNop().Sugar()),
+	}
+
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "web-2",
+			Namespace:       "spread",
+			OwnerReferences: []metav1.OwnerReference{owner},
+		},
+		Spec: corev1.PodSpec{
+			Volumes: []corev1.Volume{{
+				Name: "data",
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "data-web-2"},
+				},
+			}},
+		},
+	}
+
+	result, err := lvs.prioritize(pod, nodes)
+	if err != nil {
+		t.Fatalf("prioritize error = %s", err.Error())
+	}
+
+	scores := make(map[string]int64, len(*result))
+	for _, hp := range *result {
+		scores[hp.Host] = hp.Score
+	}
+	// base score 10, the z1 nodes each hold one replica (-4) and share
+	// the zone with the other (-2)
+	for _, host := range []string{"spread-node-0", "spread-node-1"} {
+		if scores[host] != 4 {
+			t.Errorf("score of %s = %d, want 4", host, scores[host])
+		}
+	}
+	if scores["spread-node-2"] != 10 {
+		t.Errorf("score of spread-node-2 = %d, want 10", scores["spread-node-2"])
+	}
+}
+
+func TestStatefulSetOf(t *testing.T) {
+	byOwner := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:            "web-0",
+		OwnerReferences: []metav1.OwnerReference{{Kind: "StatefulSet", Name: "web"}},
+	}}
+	if got := statefulSetOf(byOwner); got != "web" {
+		t.Errorf("statefulSetOf by owner = %q, want web", got)
+	}
+
+	byLabel := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:   "db-main-3",
+		Labels: map[string]string{"statefulset.kubernetes.io/pod-name": "db-main-3"},
+	}}
+	if got := statefulSetOf(byLabel); got != "db-main" {
+		t.Errorf("statefulSetOf by label = %q, want db-main", got)
+	}
+
+	plain := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "standalone"}}
+	if got := statefulSetOf(plain); got != "" {
+		t.Errorf("statefulSetOf of plain pod = %q, want empty", got)
+	}
+}
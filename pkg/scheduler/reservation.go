@@ -0,0 +1,153 @@
+package scheduler
+
+import (
+	"context"
+	"flag"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/informers/core/v1/pod"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/controller"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/metrics"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+)
+
+var (
+	noBind = flag.Bool("no-bind", false,
+		"Do not register the bind route, reservations are written by a controller watching pod.spec.nodeName and the pv node affinity pins the volume. For clusters where granting bind permissions to an extender is not acceptable")
+)
+
+const (
+	// ReconcilerName is the name of the reconciler
+	ReservationReconcilerName = "Reservation"
+)
+
+// NoBindMode reports whether the extender should only filter and score,
+// leaving bind to the default scheduler.
+func NoBindMode() bool {
+	return *noBind
+}
+
+// NewReservationController reserves local volume capacity for pods the
+// default scheduler has already placed. In no-bind mode it replaces the
+// bind route, in bind mode it backfills pods that were bound without
+// consulting the extender, a second scheduler or a manually set
+// nodeName would otherwise leave their claims unaccounted.
+func NewReservationController(
+	ctx context.Context,
+	lvs *LocalVolumeScheduler,
+) *controller.Impl {
+	logger := logging.FromContext(ctx)
+	podInformer := pod.Get(ctx)
+
+	r := &ReservationReconciler{
+		lvs: lvs,
+	}
+
+	impl := controller.NewImpl(r, logger, ReservationReconcilerName)
+
+	// only placed pods carry a node to reserve on
+	podInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: scheduledPodFilter,
+		Handler:    controller.HandleAll(impl.Enqueue),
+	})
+
+	logger.Info("Reservation Controller Started")
+	return impl
+}
+
+func scheduledPodFilter(obj interface{}) bool {
+	p, ok := obj.(*corev1.Pod)
+	if !ok {
+		return false
+	}
+	return p.Spec.NodeName != ""
+}
+
+type ReservationReconciler struct {
+	lvs *LocalVolumeScheduler
+}
+
+func (r *ReservationReconciler) Reconcile(ctx context.Context, key string) error {
+	logger := logging.FromContext(ctx)
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logger.Errorw("Invalid resource key", zap.Error(err))
+		return nil
+	}
+
+	p, err := r.lvs.podLister.Pods(namespace).Get(name)
+	if err != nil && errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if p.Spec.NodeName == "" {
+		return nil
+	}
+	pvcNames := r.lvs.getPodLocalVolumePVCNames(p)
+	if len(pvcNames) == 0 {
+		return nil
+	}
+
+	// pods the extender bound itself are already reserved, only the rest
+	// needs backfilling
+	if r.claimsReserved(p.Spec.NodeName, pvcNames) {
+		if r.lvs.assumed != nil {
+			r.lvs.assumed.forget(key)
+		}
+		return nil
+	}
+
+	if err := r.lvs.reservePodClaims(p, p.Spec.NodeName); err != nil {
+		return err
+	}
+
+	// the reservation is durable in PreAllocated now, the scoring
+	// assumption has served its purpose
+	if r.lvs.assumed != nil {
+		r.lvs.assumed.forget(key)
+	}
+
+	if !NoBindMode() {
+		// in bind mode reaching this point means another scheduler or a
+		// direct nodeName placed the pod
+		metrics.RecordExternalBindReservation(p.Spec.NodeName)
+		logger.Warnf("Reservation Controller backfilled pod(%s/%s) bound outside the extender on node(%s)",
+			namespace, name, p.Spec.NodeName)
+		return nil
+	}
+
+	logger.Infof("Reservation Controller reserved local claims of pod(%s/%s) on node(%s)",
+		namespace, name, p.Spec.NodeName)
+	return nil
+}
+
+// claimsReserved reports whether every claim is already accounted for,
+// either pending in the PreAllocated set or bound to a provisioned
+// volume. A pod reconciled again after its volumes were provisioned
+// must not be reserved a second time.
+func (r *ReservationReconciler) claimsReserved(nodeName string, pvcNames map[string]string) bool {
+	lv, err := r.lvs.localVolumeLister.LocalVolumes(corev1.NamespaceDefault).Get(nodeName)
+	if err != nil {
+		return false
+	}
+	for key := range pvcNames {
+		if _, ok := lv.Status.PreAllocated[key]; ok {
+			continue
+		}
+		namespace, name := types.SplitPVCKey(key)
+		if namespace == "" {
+			return false
+		}
+		if pvc, err := r.lvs.getPVC(namespace, name); err != nil || pvc.Spec.VolumeName == "" {
+			return false
+		}
+	}
+	return true
+}
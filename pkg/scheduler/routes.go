@@ -4,15 +4,19 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
 	"net/http"
+	"sync"
 
+	"github.com/google/uuid"
 	"github.com/julienschmidt/httprouter"
+	"go.uber.org/zap"
 	schedulerapi "k8s.io/kubernetes/pkg/scheduler/apis/extender/v1"
 
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
-	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/version"
 )
 
 const (
@@ -22,8 +26,24 @@ const (
 	predicatesPath = apiPrefix + "/predicates"
 	prioritiesPath = apiPrefix + "/priorities"
 	preemptionPath = apiPrefix + "/preemption"
+	drainPath      = "/webhook/drain"
+	claimPath      = "/webhook/claim"
 )
 
+var (
+	maxRequestBodyBytes = flag.Int64("max-request-body-bytes", 32<<20,
+		"Largest request body the extender routes accept, bigger requests fail the decode instead of buffering unbounded, 0 disables the cap")
+	maxNodesPerRequest = flag.Int("max-nodes-per-request", 5000,
+		"Largest node list one predicate or preemption request may carry, 0 disables the cap")
+)
+
+// captureBuffers pools the tee copies of the request bodies, the
+// capture files are written before a route returns so the buffers can
+// be reused.
+var captureBuffers = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
 func checkBody(w http.ResponseWriter, r *http.Request) {
 	if r.Body == nil {
 		http.Error(w, "Please send a request body", 400)
@@ -31,12 +51,47 @@ func checkBody(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// limitBody caps how much of the request body the decoders read, the
+// decode fails cleanly once the cap is passed.
+func limitBody(w http.ResponseWriter, r *http.Request) {
+	if *maxRequestBodyBytes > 0 {
+		r.Body = http.MaxBytesReader(w, r.Body, *maxRequestBodyBytes)
+	}
+}
+
+// nodeCountExceeded reports whether a request carries more objects than
+// the extender is willing to hold in memory at once.
+func nodeCountExceeded(count int) bool {
+	return *maxNodesPerRequest > 0 && count > *maxNodesPerRequest
+}
+
+// warmupRetryAfter is what the extender asks a caller to wait while the
+// caches are still syncing after a restart.
+const warmupRetryAfter = "2"
+
+// warmupGate refuses a decision route with 503 until the informer caches
+// the handlers read from are synced, an empty cache would happily bind a
+// pod onto a node that cannot hold it.
+func warmupGate(lvs *LocalVolumeScheduler, h httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		if !lvs.CachesSynced() {
+			w.Header().Set("Retry-After", warmupRetryAfter)
+			http.Error(w, "local volume scheduler caches are still syncing", http.StatusServiceUnavailable)
+			return
+		}
+		h(w, r, p)
+	}
+}
+
 func PredicateRoute(lvs *LocalVolumeScheduler) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 		checkBody(w, r)
+		limitBody(w, r)
 
-		var buf bytes.Buffer
-		body := io.TeeReader(r.Body, &buf)
+		buf := captureBuffers.Get().(*bytes.Buffer)
+		buf.Reset()
+		defer captureBuffers.Put(buf)
+		body := io.TeeReader(r.Body, buf)
 
 		var extenderArgs schedulerapi.ExtenderArgs
 		var extenderFilterResult *schedulerapi.ExtenderFilterResult
@@ -47,6 +102,11 @@ func PredicateRoute(lvs *LocalVolumeScheduler) httprouter.Handle {
 				FailedNodes: nil,
 				Error:       err.Error(),
 			}
+		} else if extenderArgs.Nodes != nil && nodeCountExceeded(len(extenderArgs.Nodes.Items)) {
+			extenderFilterResult = &schedulerapi.ExtenderFilterResult{
+				Error: fmt.Sprintf("request carries %d nodes, the extender accepts at most %d",
+					len(extenderArgs.Nodes.Items), *maxNodesPerRequest),
+			}
 		} else {
 			extenderFilterResult = lvs.PredicateHandler(extenderArgs)
 		}
@@ -54,6 +114,7 @@ func PredicateRoute(lvs *LocalVolumeScheduler) httprouter.Handle {
 		if resultBody, err := json.Marshal(extenderFilterResult); err != nil {
 			panic(err)
 		} else {
+			captureRequest(predicatesPath, buf.Bytes(), resultBody)
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusOK)
 			w.Write(resultBody)
@@ -64,9 +125,12 @@ func PredicateRoute(lvs *LocalVolumeScheduler) httprouter.Handle {
 func PrioritizeRoute(lvs *LocalVolumeScheduler) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 		checkBody(w, r)
+		limitBody(w, r)
 
-		var buf bytes.Buffer
-		body := io.TeeReader(r.Body, &buf)
+		buf := captureBuffers.Get().(*bytes.Buffer)
+		buf.Reset()
+		defer captureBuffers.Put(buf)
+		body := io.TeeReader(r.Body, buf)
 
 		var extenderArgs schedulerapi.ExtenderArgs
 		var hostPriorityList *schedulerapi.HostPriorityList
@@ -75,6 +139,12 @@ func PrioritizeRoute(lvs *LocalVolumeScheduler) httprouter.Handle {
 			panic(err)
 		}
 
+		if extenderArgs.Nodes != nil && nodeCountExceeded(len(extenderArgs.Nodes.Items)) {
+			http.Error(w, fmt.Sprintf("request carries %d nodes, the extender accepts at most %d",
+				len(extenderArgs.Nodes.Items), *maxNodesPerRequest), http.StatusBadRequest)
+			return
+		}
+
 		if list, err := lvs.PrioritizeHandler(extenderArgs); err != nil {
 			panic(err)
 		} else {
@@ -94,9 +164,12 @@ func PrioritizeRoute(lvs *LocalVolumeScheduler) httprouter.Handle {
 func BindRoute(lvs *LocalVolumeScheduler) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 		checkBody(w, r)
+		limitBody(w, r)
 
-		var buf bytes.Buffer
-		body := io.TeeReader(r.Body, &buf)
+		buf := captureBuffers.Get().(*bytes.Buffer)
+		buf.Reset()
+		defer captureBuffers.Put(buf)
+		body := io.TeeReader(r.Body, buf)
 
 		var extenderBindingArgs schedulerapi.ExtenderBindingArgs
 		var extenderBindingResult *schedulerapi.ExtenderBindingResult
@@ -112,6 +185,7 @@ func BindRoute(lvs *LocalVolumeScheduler) httprouter.Handle {
 		if resultBody, err := json.Marshal(extenderBindingResult); err != nil {
 			panic(err)
 		} else {
+			captureRequest(bindPath, buf.Bytes(), resultBody)
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusOK)
 			w.Write(resultBody)
@@ -123,9 +197,12 @@ func PreemptionRoute(lvs *LocalVolumeScheduler) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 		logger := logging.FromContext(context.Background())
 		checkBody(w, r)
+		limitBody(w, r)
 
-		var buf bytes.Buffer
-		body := io.TeeReader(r.Body, &buf)
+		buf := captureBuffers.Get().(*bytes.Buffer)
+		buf.Reset()
+		defer captureBuffers.Put(buf)
+		body := io.TeeReader(r.Body, buf)
 
 		var extenderPreemptionArgs schedulerapi.ExtenderPreemptionArgs
 		var extenderPreemptionResult *schedulerapi.ExtenderPreemptionResult
@@ -133,6 +210,9 @@ func PreemptionRoute(lvs *LocalVolumeScheduler) httprouter.Handle {
 		if err := json.NewDecoder(body).Decode(&extenderPreemptionArgs); err != nil {
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusBadRequest)
+		} else if nodeCountExceeded(len(extenderPreemptionArgs.NodeNameToVictims) + len(extenderPreemptionArgs.NodeNameToMetaVictims)) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
 		} else {
 			extenderPreemptionResult = lvs.PreemptionHandler(extenderPreemptionArgs)
 		}
@@ -149,7 +229,12 @@ func PreemptionRoute(lvs *LocalVolumeScheduler) httprouter.Handle {
 }
 
 func VersionRoute(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
-	fmt.Fprint(w, fmt.Sprint(types.Version))
+	resultBody, err := json.Marshal(version.Get())
+	if err != nil {
+		panic(err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resultBody)
 }
 
 func AddVersion(router *httprouter.Router) {
@@ -158,19 +243,19 @@ func AddVersion(router *httprouter.Router) {
 
 func DebugLogging(h httprouter.Handle, path string) httprouter.Handle {
 	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
-		logger := logging.FromContext(context.Background())
+		logger := logging.FromContext(context.Background()).With(zap.String(logging.TraceId, uuid.New().String()))
 		logger.Debugf("debug path = %s, request body = %+v", path, r.Body)
-		h(w, r, p)
+		h(w, r.WithContext(logging.WithLogger(r.Context(), logger)), p)
 		logger.Debugf("debug path = %s, response=", path, w)
 	}
 }
 
 func AddPredicate(router *httprouter.Router, lvs *LocalVolumeScheduler) {
-	router.POST(predicatesPath, DebugLogging(PredicateRoute(lvs), predicatesPath))
+	router.POST(predicatesPath, DebugLogging(warmupGate(lvs, PredicateRoute(lvs)), predicatesPath))
 }
 
 func AddPrioritize(router *httprouter.Router, lvs *LocalVolumeScheduler) {
-	router.POST(prioritiesPath, DebugLogging(PrioritizeRoute(lvs), prioritiesPath))
+	router.POST(prioritiesPath, DebugLogging(warmupGate(lvs, PrioritizeRoute(lvs)), prioritiesPath))
 }
 
 func AddBind(router *httprouter.Router, lvs *LocalVolumeScheduler) {
@@ -178,7 +263,7 @@ func AddBind(router *httprouter.Router, lvs *LocalVolumeScheduler) {
 	if handle, _, _ := router.Lookup("POST", bindPath); handle != nil {
 		logger.Warnf("warning: AddBind was called more then once!")
 	} else {
-		router.POST(bindPath, DebugLogging(BindRoute(lvs), bindPath))
+		router.POST(bindPath, DebugLogging(warmupGate(lvs, BindRoute(lvs)), bindPath))
 	}
 }
 
@@ -187,6 +272,14 @@ func AddPreemption(router *httprouter.Router, lvs *LocalVolumeScheduler) {
 	if handle, _, _ := router.Lookup("POST", preemptionPath); handle != nil {
 		logger.Warnf("warning: AddPreemption was called more then once!")
 	} else {
-		router.POST(preemptionPath, DebugLogging(PreemptionRoute(lvs), preemptionPath))
+		router.POST(preemptionPath, DebugLogging(warmupGate(lvs, PreemptionRoute(lvs)), preemptionPath))
 	}
 }
+
+func AddDrainValidation(router *httprouter.Router, lvs *LocalVolumeScheduler) {
+	router.POST(drainPath, DebugLogging(DrainValidationRoute(lvs), drainPath))
+}
+
+func AddClaimValidation(router *httprouter.Router, lvs *LocalVolumeScheduler) {
+	router.POST(claimPath, DebugLogging(ClaimValidationRoute(lvs), claimPath))
+}
@@ -15,6 +15,8 @@ import (
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/informers/core/v1/pod"
 	sc "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/informers/storage/v1/storageclass"
 	lv "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/listers/storage/v1alpha1"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/controller"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/events"
 )
 
 type LocalVolumeScheduler struct {
@@ -24,15 +26,37 @@ type LocalVolumeScheduler struct {
 	localVolumeLister  lv.LocalVolumeLister
 	localVolumeClient  versioned.Interface
 	kubeClient         kubernetes.Interface
+	predicateCache     *predicateCache
+	assumed            *assumedReservations
+	explain            *explainTracker
+	slo                *sloTracker
+	recorder           *events.DedupRecorder
+	hasSynced          []func() bool
 	ctx                context.Context
 }
 
+// CachesSynced reports whether all caches the decisions read from are
+// warm, the routes refuse to answer before that.
+func (lvs *LocalVolumeScheduler) CachesSynced() bool {
+	for _, synced := range lvs.hasSynced {
+		if !synced() {
+			return false
+		}
+	}
+	return true
+}
+
 func NewLocalVolumeScheduler(ctx context.Context) *LocalVolumeScheduler {
 	podInformer := pod.Get(ctx)
 	pvcInformer := pvc.Get(ctx)
 	scInformer := sc.Get(ctx)
 	lvInformer := localvolume.Get(ctx)
 
+	// every capacity relevant event invalidates the predicate cache
+	cache := newPredicateCache()
+	lvInformer.Informer().AddEventHandler(controller.HandleAll(func(interface{}) { cache.bump() }))
+	pvcInformer.Informer().AddEventHandler(controller.HandleAll(func(interface{}) { cache.bump() }))
+
 	return &LocalVolumeScheduler{
 		podLister:          podInformer.Lister(),
 		pvcLister:          pvcInformer.Lister(),
@@ -40,6 +64,17 @@ func NewLocalVolumeScheduler(ctx context.Context) *LocalVolumeScheduler {
 		localVolumeLister:  lvInformer.Lister(),
 		localVolumeClient:  client.Get(ctx),
 		kubeClient:         kubeclient.Get(ctx),
+		predicateCache:     cache,
+		assumed:            newAssumedReservations(*assumedReservationTTL),
+		explain:            newExplainTracker(),
+		slo:                newSLOTracker(),
+		hasSynced: []func() bool{
+			podInformer.Informer().HasSynced,
+			pvcInformer.Informer().HasSynced,
+			scInformer.Informer().HasSynced,
+			lvInformer.Informer().HasSynced,
+		},
+		recorder:           events.NewDedupRecorder(events.NewRecorder(kubeclient.Get(ctx), "LocalVolumeScheduler"), events.DefaultDedupWindow),
 		ctx:                ctx,
 	}
 }
@@ -0,0 +1,61 @@
+package scheduler
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// Serve runs the extender http server. With tls files http/2 is
+// negotiated over alpn, without them the server speaks h2c so the
+// kube-scheduler can still multiplex its requests over one connection.
+// Responses compress with gzip when the client accepts it, the node
+// lists exchanged on every scheduling cycle shrink considerably in big
+// clusters.
+func Serve(addr, tlsCertFile, tlsKeyFile string, handler http.Handler) error {
+	handler = gzipHandler(handler)
+
+	server := &http.Server{Addr: addr, Handler: handler}
+	if tlsCertFile != "" {
+		return server.ListenAndServeTLS(tlsCertFile, tlsKeyFile)
+	}
+	server.Handler = h2c.NewHandler(handler, &http2.Server{})
+	return server.ListenAndServe()
+}
+
+var gzipWriters = sync.Pool{
+	New: func() interface{} {
+		return gzip.NewWriter(nil)
+	},
+}
+
+// gzipHandler compresses responses for clients that accept gzip.
+func gzipHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		gz := gzipWriters.Get().(*gzip.Writer)
+		defer gzipWriters.Put(gz)
+		gz.Reset(w)
+		defer gz.Close()
+
+		w.Header().Set("Content-Encoding", "gzip")
+		next.ServeHTTP(&gzipResponseWriter{ResponseWriter: w, gz: gz}, r)
+	})
+}
+
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	gz *gzip.Writer
+}
+
+func (w *gzipResponseWriter) Write(data []byte) (int, error) {
+	return w.gz.Write(data)
+}
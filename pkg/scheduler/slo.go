@@ -0,0 +1,107 @@
+package scheduler
+
+import (
+	"flag"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/metrics"
+)
+
+var decisionLatencySLO = flag.Duration("decision-latency-slo", 0,
+	"Rolling p99 target for the filter to bind latency of a pod, violations are counted and logged, 0 disables the tracking")
+
+const (
+	// sloWindowSize is how many recent decisions the rolling p99 is
+	// computed over.
+	sloWindowSize = 200
+	// sloStaleStart is when an unfinished decision is dropped, the pod
+	// was bound elsewhere or gave up.
+	sloStaleStart = 10 * time.Minute
+	// sloLogInterval rate limits the violation log line, the metric
+	// counts every evaluation.
+	sloLogInterval = time.Minute
+)
+
+// sloTracker measures the end-to-end latency from the first predicate
+// call of a pod to its successful bind and keeps a rolling window to
+// evaluate the p99 against the target.
+type sloTracker struct {
+	mu        sync.Mutex
+	starts    map[string]time.Time
+	durations []time.Duration
+	lastLog   time.Time
+}
+
+func newSLOTracker() *sloTracker {
+	return &sloTracker{starts: make(map[string]time.Time)}
+}
+
+// begin marks the first predicate call of a pod, later calls while the
+// decision is still open keep the original start.
+func (t *sloTracker) begin(key string) {
+	if t == nil || *decisionLatencySLO <= 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	if _, ok := t.starts[key]; !ok {
+		t.starts[key] = now
+	}
+	// Decisions that never finish would pin their start forever.
+	for k, started := range t.starts {
+		if now.Sub(started) > sloStaleStart {
+			delete(t.starts, k)
+		}
+	}
+}
+
+// finish closes the decision of a pod after its bind succeeded, records
+// the latency and evaluates the rolling p99 against the target.
+func (t *sloTracker) finish(key string) {
+	if t == nil || *decisionLatencySLO <= 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	started, ok := t.starts[key]
+	if !ok {
+		return
+	}
+	delete(t.starts, key)
+
+	elapsed := time.Since(started)
+	metrics.RecordSchedulingDecision(elapsed.Seconds())
+
+	t.durations = append(t.durations, elapsed)
+	if len(t.durations) > sloWindowSize {
+		t.durations = t.durations[len(t.durations)-sloWindowSize:]
+	}
+
+	p99 := quantile(t.durations, 0.99)
+	if p99 <= *decisionLatencySLO {
+		return
+	}
+	metrics.RecordSchedulingSLOViolation()
+	if time.Since(t.lastLog) >= sloLogInterval {
+		t.lastLog = time.Now()
+		logging.GetLogger().Warnf("scheduling decision latency slo violated: p99 %v over the last %d decisions, target %v",
+			p99, len(t.durations), *decisionLatencySLO)
+	}
+}
+
+// quantile returns the q quantile of the durations, the slice is copied
+// so the rolling window keeps its order.
+func quantile(durations []time.Duration, q float64) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	idx := int(q * float64(len(sorted)-1))
+	return sorted[idx]
+}
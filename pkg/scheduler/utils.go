@@ -1,13 +1,41 @@
 package scheduler
 
 import (
+	"flag"
 	"math"
+	"strconv"
+	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/apis/storage/v1alpha1"
 	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
 )
 
+var agentStaleWindow = flag.Duration("agent-stale-window", 3*time.Minute,
+	"Heartbeats older than this mark the agent of a node as down, its node takes no new local volumes, 0 disables the check")
+
+var (
+	priorityHeadroomPercent = flag.Uint64("priority-headroom-percent", 0,
+		"Percent of the vg kept free for high priority pods during capacity crunch, 0 disables the headroom")
+	priorityHeadroomMinPriority = flag.Int("priority-headroom-min-priority", 1000000,
+		"Pod priority at or above which a pod may consume the priority headroom")
+)
+
+// getPVC reads from the informer cache and only falls back to a live
+// read when the cache does not have the claim yet, freshly created
+// claims can lag behind the watch during scheduling bursts.
+func (lvs *LocalVolumeScheduler) getPVC(namespace, name string) (*corev1.PersistentVolumeClaim, error) {
+	pvc, err := lvs.pvcLister.PersistentVolumeClaims(namespace).Get(name)
+	if err == nil || !apierrors.IsNotFound(err) || lvs.kubeClient == nil {
+		return pvc, err
+	}
+	return lvs.kubeClient.CoreV1().PersistentVolumeClaims(namespace).Get(name, metav1.GetOptions{})
+}
+
 func (lvs *LocalVolumeScheduler) getPodLocalVolumeRequestSize(pod *corev1.Pod) uint64 {
 	var result uint64
 
@@ -16,7 +44,7 @@ func (lvs *LocalVolumeScheduler) getPodLocalVolumeRequestSize(pod *corev1.Pod) u
 			pvcName := volume.PersistentVolumeClaim.ClaimName
 
 			// get pvc
-			pvc, err := lvs.pvcLister.PersistentVolumeClaims(pod.Namespace).Get(pvcName)
+			pvc, err := lvs.getPVC(pod.Namespace, pvcName)
 			if err != nil {
 				continue
 			}
@@ -47,7 +75,7 @@ func (lvs *LocalVolumeScheduler) getPodLocalVolumePVCNames(pod *corev1.Pod) map[
 			pvcName := volume.PersistentVolumeClaim.ClaimName
 
 			// get pvc
-			pvc, err := lvs.pvcLister.PersistentVolumeClaims(pod.Namespace).Get(pvcName)
+			pvc, err := lvs.getPVC(pod.Namespace, pvcName)
 			if err != nil {
 				continue
 			}
@@ -71,11 +99,19 @@ func (lvs *LocalVolumeScheduler) getNodeFreeSize(nodeName string) uint64 {
 	if err != nil {
 		return 0
 	}
+	return lv.Status.FreeSize - lvs.preAllocatedSize(lv, nil)
+}
 
-	var preallocateSize uint64
+// preAllocatedSize sums the sizes of the pending claims of the node, the
+// keys in exclude are skipped.
+func (lvs *LocalVolumeScheduler) preAllocatedSize(lv *v1alpha1.LocalVolume, exclude map[string]string) uint64 {
+	var result uint64
 	for key := range lv.Status.PreAllocated {
+		if _, ok := exclude[key]; ok {
+			continue
+		}
 		pvcNS, pvcName := types.SplitPVCKey(key)
-		pvc, err := lvs.pvcLister.PersistentVolumeClaims(pvcNS).Get(pvcName)
+		pvc, err := lvs.getPVC(pvcNS, pvcName)
 		if err != nil {
 			continue
 		}
@@ -85,7 +121,110 @@ func (lvs *LocalVolumeScheduler) getNodeFreeSize(nodeName string) uint64 {
 			continue
 		}
 		realSize := uint64(math.Ceil(float64(size.Value()) / 1024 / 1024 / 1024))
-		preallocateSize = preallocateSize + realSize
+		result = result + realSize
+	}
+	return result
+}
+
+// pvcRequestSize returns the requested size in GB of a claim key.
+func (lvs *LocalVolumeScheduler) pvcRequestSize(key string) uint64 {
+	pvcNS, pvcName := types.SplitPVCKey(key)
+	pvc, err := lvs.getPVC(pvcNS, pvcName)
+	if err != nil {
+		return 0
+	}
+	size, ok := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	if !ok {
+		return 0
+	}
+	return uint64(math.Ceil(float64(size.Value()) / 1024 / 1024 / 1024))
+}
+
+func (lvs *LocalVolumeScheduler) isNodeStorageCordoned(nodeName string) bool {
+	lv, err := lvs.localVolumeLister.LocalVolumes(corev1.NamespaceDefault).Get(nodeName)
+	if err != nil {
+		return false
+	}
+	return lv.Annotations[types.LocalVolumeCordonAnnotation] == "true"
+}
+
+// reservedSizeFor returns the slice of the vg the pod may not consume,
+// zero for the system workloads the reserved-for annotation of the
+// node lists.
+func (lvs *LocalVolumeScheduler) reservedSizeFor(pod *corev1.Pod, nodeName string) uint64 {
+	lv, err := lvs.localVolumeLister.LocalVolumes(corev1.NamespaceDefault).Get(nodeName)
+	if err != nil {
+		return 0
+	}
+	reserved, err := strconv.ParseUint(lv.Annotations[types.ReservedCapacityAnnotation], 10, 64)
+	if err != nil || reserved == 0 {
+		return 0
+	}
+	for _, consumer := range strings.Split(lv.Annotations[types.ReservedForAnnotation], ",") {
+		consumer = strings.TrimSpace(consumer)
+		if consumer == "" {
+			continue
+		}
+		if consumer == pod.Namespace || consumer == daemonSetOf(pod) {
+			return 0
+		}
+	}
+	return reserved
+}
+
+// priorityHeadroom returns the slice of the vg the pod must leave free
+// for higher priority pods, zero once the pod priority reaches the
+// threshold.
+func (lvs *LocalVolumeScheduler) priorityHeadroom(pod *corev1.Pod, nodeName string) uint64 {
+	if *priorityHeadroomPercent == 0 || isHighPriority(pod) {
+		return 0
+	}
+	lv, err := lvs.localVolumeLister.LocalVolumes(corev1.NamespaceDefault).Get(nodeName)
+	if err != nil {
+		return 0
+	}
+	return lv.Status.TotalSize * *priorityHeadroomPercent / 100
+}
+
+func isHighPriority(pod *corev1.Pod) bool {
+	return pod.Spec.Priority != nil && *pod.Spec.Priority >= int32(*priorityHeadroomMinPriority)
+}
+
+// daemonSetOf returns the namespace/name of the DaemonSet owning the
+// pod, empty when the pod is not part of one.
+func daemonSetOf(pod *corev1.Pod) string {
+	for _, ref := range pod.OwnerReferences {
+		if ref.Kind == "DaemonSet" {
+			return pod.Namespace + "/" + ref.Name
+		}
+	}
+	return ""
+}
+
+// isNodeAgentStale reports whether the agent of the node stopped
+// refreshing its heartbeat. Nodes without a heartbeat run an agent that
+// predates the field and pass, an upgrade must not fence the fleet.
+func (lvs *LocalVolumeScheduler) isNodeAgentStale(nodeName string) bool {
+	if *agentStaleWindow <= 0 {
+		return false
+	}
+	lv, err := lvs.localVolumeLister.LocalVolumes(corev1.NamespaceDefault).Get(nodeName)
+	if err != nil || lv.Status.Heartbeat == nil {
+		return false
+	}
+	return time.Since(lv.Status.Heartbeat.Time) > *agentStaleWindow
+}
+
+func (lvs *LocalVolumeScheduler) isNodeSlowDisk(nodeName string) bool {
+	lv, err := lvs.localVolumeLister.LocalVolumes(corev1.NamespaceDefault).Get(nodeName)
+	if err != nil {
+		return false
+	}
+
+	for _, condition := range lv.Status.Conditions {
+		if condition.Type == v1alpha1.LocalVolumeConditionSlowDisk && condition.Status == v1alpha1.ConditionTrue {
+			return true
+		}
 	}
-	return lv.Status.FreeSize - preallocateSize
+	return false
 }
@@ -1,9 +1,65 @@
 package scheduler
 
-import(
+import (
 	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/apis/storage/v1alpha1"
+	lvlisters "github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/listers/storage/v1alpha1"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
 )
 
 func Test(t *testing.T) {
 
 }
+
+// TestReservedSizeFor checks that the reserved slice of a node counts
+// against general pods but not against the listed consumers.
+func TestReservedSizeFor(t *testing.T) {
+	lvIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	if err := lvIndexer.Add(&v1alpha1.LocalVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "reserved-node",
+			Namespace: corev1.NamespaceDefault,
+			Annotations: map[string]string{
+				types.ReservedCapacityAnnotation: "20",
+				types.ReservedForAnnotation:      "kube-system, logging/fluentd",
+			},
+		},
+	}); err != nil {
+		t.Fatalf("add local volume error = %s", err.Error())
+	}
+	lvs := &LocalVolumeScheduler{localVolumeLister: lvlisters.NewLocalVolumeLister(lvIndexer)}
+
+	general := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "prod"}}
+	if got := lvs.reservedSizeFor(general, "reserved-node"); got != 20 {
+		t.Errorf("reserved size for general pod = %d, want 20", got)
+	}
+
+	system := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "kube-proxy", Namespace: "kube-system"}}
+	if got := lvs.reservedSizeFor(system, "reserved-node"); got != 0 {
+		t.Errorf("reserved size for kube-system pod = %d, want 0", got)
+	}
+
+	daemon := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:            "fluentd-x2ll4",
+		Namespace:       "logging",
+		OwnerReferences: []metav1.OwnerReference{{Kind: "DaemonSet", Name: "fluentd"}},
+	}}
+	if got := lvs.reservedSizeFor(daemon, "reserved-node"); got != 0 {
+		t.Errorf("reserved size for daemonset pod = %d, want 0", got)
+	}
+
+	// a pod of another daemonset in an unlisted namespace stays out
+	otherDaemon := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:            "node-exporter-9fkc2",
+		Namespace:       "monitoring",
+		OwnerReferences: []metav1.OwnerReference{{Kind: "DaemonSet", Name: "node-exporter"}},
+	}}
+	if got := lvs.reservedSizeFor(otherDaemon, "reserved-node"); got != 20 {
+		t.Errorf("reserved size for unlisted daemonset pod = %d, want 20", got)
+	}
+}
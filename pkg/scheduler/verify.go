@@ -0,0 +1,181 @@
+package scheduler
+
+import (
+	"context"
+	"flag"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/kube/injection/informers/core/v1/pod"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/controller"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/logging"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/metrics"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/status"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+)
+
+var (
+	bindVerificationWindow = flag.Duration("bind-verification-window", 5*time.Minute,
+		"How long a bound pod gets to start running before its unprovisioned local claims are released again, 0 disables the verification")
+)
+
+const (
+	// ReconcilerName is the name of the reconciler
+	VerificationReconcilerName = "BindVerification"
+
+	// ReasonBindVerificationFailed is the event reason attached to a pod
+	// whose local volumes never came up after the bind.
+	ReasonBindVerificationFailed = "LocalVolumeBindVerificationFailed"
+)
+
+// NewVerificationController checks that pods the extender bound actually
+// get their local volumes provisioned and start, the bind itself is
+// optimistic. A pod still stuck past the window has its pending
+// reservations released and the failure written onto the pod, otherwise
+// a broken provisioner silently holds the capacity.
+func NewVerificationController(
+	ctx context.Context,
+	lvs *LocalVolumeScheduler,
+) *controller.Impl {
+	logger := logging.FromContext(ctx)
+	podInformer := pod.Get(ctx)
+
+	r := &VerificationReconciler{
+		lvs: lvs,
+	}
+
+	impl := controller.NewImpl(r, logger, VerificationReconcilerName)
+	// a stuck pod emits no event at the deadline, the reconciler requeues
+	// itself until the verdict is in
+	r.enqueueAfter = impl.EnqueueKeyAfter
+
+	podInformer.Informer().AddEventHandler(cache.FilteringResourceEventHandler{
+		FilterFunc: scheduledPodFilter,
+		Handler:    controller.HandleAll(impl.Enqueue),
+	})
+
+	logger.Info("Bind Verification Controller Started")
+	return impl
+}
+
+type VerificationReconciler struct {
+	lvs          *LocalVolumeScheduler
+	enqueueAfter func(key k8stypes.NamespacedName, delay time.Duration)
+}
+
+func (r *VerificationReconciler) Reconcile(ctx context.Context, key string) error {
+	logger := logging.FromContext(ctx)
+
+	if *bindVerificationWindow <= 0 {
+		return nil
+	}
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		logger.Errorw("Invalid resource key", zap.Error(err))
+		return nil
+	}
+
+	p, err := r.lvs.podLister.Pods(namespace).Get(name)
+	if err != nil && apierrors.IsNotFound(err) {
+		// a deleted pod is the expiry controller's case
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if p.Spec.NodeName == "" || len(r.lvs.getPodLocalVolumePVCNames(p)) == 0 {
+		return nil
+	}
+
+	// a running pod has all its volumes attached, the bind held up
+	if p.Status.Phase == corev1.PodRunning || p.Status.Phase == corev1.PodSucceeded {
+		return nil
+	}
+
+	age := time.Since(bindTime(p))
+	if age < *bindVerificationWindow {
+		if r.enqueueAfter != nil {
+			r.enqueueAfter(k8stypes.NamespacedName{Namespace: namespace, Name: name}, *bindVerificationWindow-age)
+		}
+		return nil
+	}
+
+	// past the window only claims without a volume count as failed, a pod
+	// pending on an image pull or a scheduling gate is not our problem
+	unbound := r.unboundClaims(p)
+	if len(unbound) == 0 {
+		return nil
+	}
+
+	if err := r.releaseReservations(p.Spec.NodeName, unbound); err != nil {
+		return err
+	}
+
+	metrics.RecordBindVerificationFailed(p.Spec.NodeName)
+	if r.lvs.recorder != nil {
+		r.lvs.recorder.Eventf(p, corev1.EventTypeWarning, ReasonBindVerificationFailed,
+			"local claims %v of pod bound to node %s were not provisioned within %v, their reservations were released",
+			unbound, p.Spec.NodeName, *bindVerificationWindow)
+	}
+	logger.Warnf("Bind Verification Controller released claims %v of pod(%s/%s) on node(%s): not provisioned within %v",
+		unbound, namespace, name, p.Spec.NodeName, *bindVerificationWindow)
+	return nil
+}
+
+// bindTime returns when the pod was placed on its node, the PodScheduled
+// condition carries it, pods bound before the condition existed fall
+// back to their creation time.
+func bindTime(p *corev1.Pod) time.Time {
+	for _, condition := range p.Status.Conditions {
+		if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionTrue {
+			return condition.LastTransitionTime.Time
+		}
+	}
+	return p.CreationTimestamp.Time
+}
+
+// unboundClaims returns the local claims of the pod that still have no
+// volume, sorted so the diagnostics stay stable.
+func (r *VerificationReconciler) unboundClaims(p *corev1.Pod) []string {
+	unbound := make([]string, 0)
+	for key := range r.lvs.getPodLocalVolumePVCNames(p) {
+		claimNamespace, claimName := types.SplitPVCKey(key)
+		if claimNamespace == "" {
+			continue
+		}
+		pvc, err := r.lvs.getPVC(claimNamespace, claimName)
+		if err != nil || pvc.Spec.VolumeName == "" {
+			unbound = append(unbound, key)
+		}
+	}
+	sort.Strings(unbound)
+	return unbound
+}
+
+// releaseReservations removes the pending entries of the claims from the
+// LocalVolume of the node, entries already gone are skipped.
+func (r *VerificationReconciler) releaseReservations(nodeName string, claims []string) error {
+	lv, err := r.lvs.localVolumeLister.LocalVolumes(corev1.NamespaceDefault).Get(nodeName)
+	if err != nil && apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	stale := make([]string, 0, len(claims))
+	for _, key := range claims {
+		if _, ok := lv.Status.PreAllocated[key]; ok {
+			stale = append(stale, key)
+		}
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+	return status.RemovePreAllocated(r.lvs.localVolumeClient, lv.Name, stale)
+}
@@ -0,0 +1,121 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestProvisioningAndDeletion(t *testing.T) {
+	f := NewFramework(t)
+	defer f.TearDown(t)
+
+	pvcName := PodName("pvc")
+	podName := PodName("pod")
+
+	f.CreatePVC(t, pvcName, "1Gi")
+	f.CreatePod(t, podName, pvcName)
+	f.WaitForPodRunning(t, podName)
+	volumeName := f.WaitForPVCBound(t, pvcName)
+
+	// delete pod and pvc, the lv and pv must go away
+	if err := f.KubeClient.CoreV1().Pods(f.Namespace).Delete(podName, &metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("delete pod(%s) error = %s", podName, err.Error())
+	}
+	if err := f.KubeClient.CoreV1().PersistentVolumeClaims(f.Namespace).Delete(pvcName, &metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("delete pvc(%s) error = %s", pvcName, err.Error())
+	}
+	f.WaitForPVDeleted(t, volumeName)
+}
+
+func TestExpansion(t *testing.T) {
+	f := NewFramework(t)
+	defer f.TearDown(t)
+
+	pvcName := PodName("pvc")
+	podName := PodName("pod")
+
+	f.CreatePVC(t, pvcName, "1Gi")
+	f.CreatePod(t, podName, pvcName)
+	f.WaitForPodRunning(t, podName)
+	f.WaitForPVCBound(t, pvcName)
+
+	f.ExpandPVC(t, pvcName, "2Gi")
+
+	// recreate the pod, publish resizes the lv and filesystem
+	if err := f.KubeClient.CoreV1().Pods(f.Namespace).Delete(podName, &metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("delete pod(%s) error = %s", podName, err.Error())
+	}
+	time.Sleep(10 * time.Second)
+	podName2 := PodName("pod")
+	f.CreatePod(t, podName2, pvcName)
+	f.WaitForPodRunning(t, podName2)
+}
+
+func TestCapacityReported(t *testing.T) {
+	f := NewFramework(t)
+	defer f.TearDown(t)
+
+	lvs, err := f.LVClient.LocalV1alpha1().LocalVolumes(corev1.NamespaceDefault).List(metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("list local volumes error = %s", err.Error())
+	}
+	if len(lvs.Items) == 0 {
+		t.Fatal("no LocalVolume resources registered, agent not running?")
+	}
+	for _, lv := range lvs.Items {
+		if lv.Status.TotalSize == 0 {
+			t.Errorf("node(%s) reports zero total size", lv.Name)
+		}
+	}
+}
+
+func TestNodeFailure(t *testing.T) {
+	if os.Getenv("E2E_NODE_FAILURE") == "" {
+		t.Skip("set E2E_NODE_FAILURE=1 to run the disruptive node failure scenario")
+	}
+
+	f := NewFramework(t)
+	defer f.TearDown(t)
+
+	pvcName := PodName("pvc")
+	podName := PodName("pod")
+
+	f.CreatePVC(t, pvcName, "1Gi")
+	f.CreatePod(t, podName, pvcName)
+	f.WaitForPodRunning(t, podName)
+	volumeName := f.WaitForPVCBound(t, pvcName)
+	node := f.NodeOfPV(t, volumeName)
+
+	// a kind node is a docker container named after the node, stopping
+	// the container is the failure
+	f.StopKindNode(t, node)
+	defer f.StartKindNode(t, node)
+	f.WaitForNodeNotReady(t, node)
+
+	// a new local claim must not land on the dead node, in a cluster
+	// without another eligible node the pod stays pending
+	secondPVC := PodName("pvc")
+	secondPod := PodName("pod")
+	f.CreatePVC(t, secondPVC, "1Gi")
+	f.CreatePod(t, secondPod, secondPVC)
+
+	scheduledNode, scheduled := f.WaitForPodScheduledWithin(t, secondPod, 2*time.Minute)
+	if !scheduled {
+		t.Logf("pod(%s) stayed pending after the failure of node %s", secondPod, node)
+		return
+	}
+	if scheduledNode == node {
+		t.Fatalf("pod(%s) was scheduled onto the failed node %s", secondPod, node)
+	}
+	secondVolume := f.WaitForPVCBound(t, secondPVC)
+	if volumeNode := f.NodeOfPV(t, secondVolume); volumeNode == node {
+		t.Fatalf("volume %s of pod(%s) landed on the failed node %s", secondVolume, secondPod, volumeNode)
+	}
+}
@@ -0,0 +1,277 @@
+//go:build e2e
+// +build e2e
+
+package e2e
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/google/uuid"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/client/clientset/versioned"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+)
+
+const (
+	// PollInterval of all wait loops.
+	PollInterval = 2 * time.Second
+	// DefaultTimeout of all wait loops.
+	DefaultTimeout = 5 * time.Minute
+
+	testStorageClass = "local-volume-csi"
+)
+
+// Framework holds the clients and the test namespace of one e2e test.
+type Framework struct {
+	KubeClient kubernetes.Interface
+	LVClient   versioned.Interface
+	Namespace  string
+}
+
+// NewFramework connect to the cluster from KUBECONFIG and create a fresh
+// test namespace. Call TearDown when the test is done.
+func NewFramework(t *testing.T) *Framework {
+	kubeconfig := os.Getenv("KUBECONFIG")
+	if kubeconfig == "" {
+		t.Fatal("KUBECONFIG must point to the e2e cluster")
+	}
+
+	cfg, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		t.Fatalf("build config error = %s", err.Error())
+	}
+	kubeClient, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		t.Fatalf("build kube client error = %s", err.Error())
+	}
+	lvClient, err := versioned.NewForConfig(cfg)
+	if err != nil {
+		t.Fatalf("build local volume client error = %s", err.Error())
+	}
+
+	f := &Framework{
+		KubeClient: kubeClient,
+		LVClient:   lvClient,
+		Namespace:  "lv-e2e-" + uuid.New().String()[:8],
+	}
+
+	_, err = kubeClient.CoreV1().Namespaces().Create(&corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: f.Namespace},
+	})
+	if err != nil {
+		t.Fatalf("create namespace(%s) error = %s", f.Namespace, err.Error())
+	}
+	return f
+}
+
+// TearDown remove the test namespace and everything in it.
+func (f *Framework) TearDown(t *testing.T) {
+	err := f.KubeClient.CoreV1().Namespaces().Delete(f.Namespace, &metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		t.Errorf("delete namespace(%s) error = %s", f.Namespace, err.Error())
+	}
+}
+
+// CreatePVC create a pvc of the local volume storage class.
+func (f *Framework) CreatePVC(t *testing.T, name, size string) *corev1.PersistentVolumeClaim {
+	storageClass := testStorageClass
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: f.Namespace},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			StorageClassName: &storageClass,
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: resource.MustParse(size),
+				},
+			},
+		},
+	}
+
+	pvc, err := f.KubeClient.CoreV1().PersistentVolumeClaims(f.Namespace).Create(pvc)
+	if err != nil {
+		t.Fatalf("create pvc(%s) error = %s", name, err.Error())
+	}
+	return pvc
+}
+
+// CreatePod create a pod consuming the given pvc.
+func (f *Framework) CreatePod(t *testing.T, name, pvcName string) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: f.Namespace},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:    "writer",
+				Image:   "busybox",
+				Command: []string{"sh", "-c", "sleep 3600"},
+				VolumeMounts: []corev1.VolumeMount{{
+					Name:      "data",
+					MountPath: "/data",
+				}},
+			}},
+			Volumes: []corev1.Volume{{
+				Name: "data",
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+						ClaimName: pvcName,
+					},
+				},
+			}},
+		},
+	}
+
+	pod, err := f.KubeClient.CoreV1().Pods(f.Namespace).Create(pod)
+	if err != nil {
+		t.Fatalf("create pod(%s) error = %s", name, err.Error())
+	}
+	return pod
+}
+
+// WaitForPVCBound wait until the pvc is bound and return its pv name.
+func (f *Framework) WaitForPVCBound(t *testing.T, name string) string {
+	volumeName := ""
+	err := wait.PollImmediate(PollInterval, DefaultTimeout, func() (bool, error) {
+		pvc, err := f.KubeClient.CoreV1().PersistentVolumeClaims(f.Namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		volumeName = pvc.Spec.VolumeName
+		return pvc.Status.Phase == corev1.ClaimBound, nil
+	})
+	if err != nil {
+		t.Fatalf("pvc(%s) not bound: %s", name, err.Error())
+	}
+	return volumeName
+}
+
+// WaitForPodRunning wait until the pod runs.
+func (f *Framework) WaitForPodRunning(t *testing.T, name string) {
+	err := wait.PollImmediate(PollInterval, DefaultTimeout, func() (bool, error) {
+		pod, err := f.KubeClient.CoreV1().Pods(f.Namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return pod.Status.Phase == corev1.PodRunning, nil
+	})
+	if err != nil {
+		t.Fatalf("pod(%s) not running: %s", name, err.Error())
+	}
+}
+
+// WaitForPVDeleted wait until the pv is gone after pvc deletion.
+func (f *Framework) WaitForPVDeleted(t *testing.T, volumeName string) {
+	err := wait.PollImmediate(PollInterval, DefaultTimeout, func() (bool, error) {
+		_, err := f.KubeClient.CoreV1().PersistentVolumes().Get(volumeName, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	})
+	if err != nil {
+		t.Fatalf("pv(%s) not deleted: %s", volumeName, err.Error())
+	}
+}
+
+// ExpandPVC grow the pvc to newSize.
+func (f *Framework) ExpandPVC(t *testing.T, name, newSize string) {
+	pvc, err := f.KubeClient.CoreV1().PersistentVolumeClaims(f.Namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get pvc(%s) error = %s", name, err.Error())
+	}
+
+	pvc.Spec.Resources.Requests[corev1.ResourceStorage] = resource.MustParse(newSize)
+	if _, err := f.KubeClient.CoreV1().PersistentVolumeClaims(f.Namespace).Update(pvc); err != nil {
+		t.Fatalf("expand pvc(%s) error = %s", name, err.Error())
+	}
+}
+
+// NodeOfPV return the node owning the pv.
+func (f *Framework) NodeOfPV(t *testing.T, volumeName string) string {
+	pv, err := f.KubeClient.CoreV1().PersistentVolumes().Get(volumeName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get pv(%s) error = %s", volumeName, err.Error())
+	}
+	for _, term := range pv.Spec.NodeAffinity.Required.NodeSelectorTerms {
+		for _, expression := range term.MatchExpressions {
+			if expression.Key == types.TopologyNodeKey && len(expression.Values) > 0 {
+				return expression.Values[0]
+			}
+		}
+	}
+	t.Fatalf("pv(%s) has no node affinity", volumeName)
+	return ""
+}
+
+// StopKindNode stop the docker container backing a kind node, which is
+// how the disruptive scenarios fail a node.
+func (f *Framework) StopKindNode(t *testing.T, node string) {
+	if out, err := exec.Command("docker", "stop", node).CombinedOutput(); err != nil {
+		t.Fatalf("stop kind node %s error = %s: %s", node, err.Error(), string(out))
+	}
+}
+
+// StartKindNode start a stopped kind node again so later scenarios get
+// their cluster back.
+func (f *Framework) StartKindNode(t *testing.T, node string) {
+	if out, err := exec.Command("docker", "start", node).CombinedOutput(); err != nil {
+		t.Errorf("start kind node %s error = %s: %s", node, err.Error(), string(out))
+	}
+}
+
+// WaitForNodeNotReady wait until the kubelet of the node stops reporting
+// Ready.
+func (f *Framework) WaitForNodeNotReady(t *testing.T, name string) {
+	err := wait.PollImmediate(PollInterval, DefaultTimeout, func() (bool, error) {
+		node, err := f.KubeClient.CoreV1().Nodes().Get(name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		for _, condition := range node.Status.Conditions {
+			if condition.Type == corev1.NodeReady {
+				return condition.Status != corev1.ConditionTrue, nil
+			}
+		}
+		return true, nil
+	})
+	if err != nil {
+		t.Fatalf("node(%s) still ready: %s", name, err.Error())
+	}
+}
+
+// WaitForPodScheduledWithin return the node of the pod once the
+// scheduler placed it, false when it is still pending after the window.
+func (f *Framework) WaitForPodScheduledWithin(t *testing.T, name string, window time.Duration) (string, bool) {
+	scheduledNode := ""
+	err := wait.PollImmediate(PollInterval, window, func() (bool, error) {
+		pod, err := f.KubeClient.CoreV1().Pods(f.Namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		scheduledNode = pod.Spec.NodeName
+		return scheduledNode != "", nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return "", false
+	}
+	if err != nil {
+		t.Fatalf("watch pod(%s) error = %s", name, err.Error())
+	}
+	return scheduledNode, true
+}
+
+// PodName build a unique pod name for a scenario.
+func PodName(scenario string) string {
+	return fmt.Sprintf("%s-%s", scenario, uuid.New().String()[:8])
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2019 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package version holds the build metadata injected by hack/build.sh
+// through ldflags, so support can quickly identify what is running.
+package version
+
+import (
+	"runtime"
+
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/features"
+	"github.com/kubernetes-local-volume/kubernetes-local-volume/pkg/common/types"
+)
+
+var (
+	// GitSHA is the git commit the binary was built from,
+	// injected by hack/build.sh.
+	GitSHA = "GitNotFound"
+	// BuildDate is the UTC time the binary was built at,
+	// injected by hack/build.sh.
+	BuildDate = "BuildDateNotFound"
+)
+
+// Info describes a running binary.
+type Info struct {
+	Version      string   `json:"version"`
+	GitCommit    string   `json:"gitCommit"`
+	BuildDate    string   `json:"buildDate"`
+	GoVersion    string   `json:"goVersion"`
+	FeatureGates []string `json:"featureGates"`
+}
+
+// Get returns the build metadata of the running binary.
+func Get() Info {
+	return Info{
+		Version:      types.Version,
+		GitCommit:    GitSHA,
+		BuildDate:    BuildDate,
+		GoVersion:    runtime.Version(),
+		FeatureGates: features.EnabledGates(),
+	}
+}